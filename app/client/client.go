@@ -0,0 +1,566 @@
+// Package client is the Go client of the app gateway, it calls the HTTP
+// routes of the gateway and keeps the session of the user it signs in.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+)
+
+const (
+	// DefaultBaseURL is where the gateway listens in docker-compose.
+	DefaultBaseURL = "http://localhost:8080"
+
+	// DefaultTimeout bounds every call unless WithTimeout says otherwise.
+	DefaultTimeout = 10 * time.Second
+)
+
+var errResponse = errors.New("unexpected response")
+
+type contextKey int
+
+const tokenContextKey contextKey = iota
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithBaseURL is the address of the gateway, DefaultBaseURL by default.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithTimeout bounds every call, zero leaves them unbounded.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithHTTPClient makes the calls through client instead of
+// http.DefaultClient.
+func WithHTTPClient(client service.HTTPClient) Option {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// WithSession starts the client with the tokens of a session opened before.
+func WithSession(token, refreshToken string) Option {
+	return func(c *Client) {
+		c.session = session{token, refreshToken}
+	}
+}
+
+// Client calls the gateway. It keeps the tokens of the last sign up, sign in
+// or refresh and uses them whenever a method is given an empty token, or
+// needs one without taking it, refreshing them once when they have expired.
+type Client struct {
+	client  service.HTTPClient
+	baseURL string
+	timeout time.Duration
+
+	signUp, signIn, logOut, getAllUsers, profile, deleteAccount  endpoint.Endpoint
+	updateProfile, changePassword, forgotPassword, resetPassword endpoint.Endpoint
+	verifyEmail, refreshToken, deleteUser, suspendUser           endpoint.Endpoint
+
+	mtx     sync.RWMutex
+	session session
+
+	// refreshMtx lets one call at a time refresh the session, a refresh
+	// token can only be used once.
+	refreshMtx sync.Mutex
+}
+
+// session is the token and refresh token of the signed in user.
+type session struct {
+	token, refreshToken string
+}
+
+// New ...
+func New(options ...Option) (*Client, error) {
+	c := &Client{
+		client:  http.DefaultClient,
+		baseURL: DefaultBaseURL,
+		timeout: DefaultTimeout,
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	base, err := url.Parse(strings.TrimSuffix(c.baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+
+	newEndpoint := func(
+		method, path string,
+		enc httptransport.EncodeRequestFunc,
+		dec httptransport.DecodeResponseFunc,
+	) endpoint.Endpoint {
+		target := *base
+		target.Path += path
+
+		e := httptransport.NewClient(
+			method,
+			&target,
+			enc,
+			dec,
+			httptransport.SetClient(c.client),
+			httptransport.ClientBefore(tokenToHeader),
+		).Endpoint()
+
+		return withTimeout(c.timeout)(e)
+	}
+
+	c.signUp = newEndpoint(http.MethodPost, "/signup", encodeBody, decodeResponse[service.TokenErrorResponse])
+	c.signIn = newEndpoint(http.MethodPost, "/signin", encodeBody, decodeResponse[service.TokenErrorResponse])
+	c.logOut = newEndpoint(http.MethodPost, "/logout", encodeEmpty, decodeResponse[service.ErrorResponse])
+	c.getAllUsers = newEndpoint(http.MethodGet, "/users", encodeUsersQuery, decodeResponse[usersResponse])
+	c.profile = newEndpoint(http.MethodPost, "/profile", encodeFields, decodeResponse[userResponse])
+	c.deleteAccount = newEndpoint(http.MethodDelete, "/profile", encodeEmpty, decodeResponse[service.ErrorResponse])
+	c.updateProfile = newEndpoint(http.MethodPut, "/profile", encodeBody, decodeResponse[service.TokenErrorResponse])
+	c.changePassword = newEndpoint(http.MethodPost, "/profile/password", encodeBody, decodeResponse[service.ErrorResponse])
+	c.forgotPassword = newEndpoint(http.MethodPost, "/password/forgot", encodeBody, decodeResponse[service.ErrorResponse])
+	c.resetPassword = newEndpoint(http.MethodPost, "/password/reset", encodeBody, decodeResponse[service.ErrorResponse])
+	c.verifyEmail = newEndpoint(http.MethodGet, "/verify-email", encodeTokenQuery, decodeResponse[service.ErrorResponse])
+	c.refreshToken = newEndpoint(http.MethodPost, "/token/refresh", encodeBody, decodeResponse[service.TokenErrorResponse])
+	c.deleteUser = newEndpoint(http.MethodDelete, "/users", encodeBody, decodeResponse[service.ErrorResponse])
+	c.suspendUser = newEndpoint(http.MethodPatch, "/users/suspended", encodeBody, decodeResponse[service.ErrorResponse])
+
+	return c, nil
+}
+
+// Session is the token and refresh token the client holds.
+func (c *Client) Session() (token, refreshToken string) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+
+	return c.session.token, c.session.refreshToken
+}
+
+func (c *Client) setSession(token, refreshToken string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.session = session{token, refreshToken}
+}
+
+// endSession forgets the session when token is its token.
+func (c *Client) endSession(token string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if c.session.token == token {
+		c.session = session{}
+	}
+}
+
+// SignUp ...
+func (c *Client) SignUp(ctx context.Context, username, password, email string) (token, refreshToken string, err error) {
+	response, err := c.signUp(ctx, service.UsernamePasswordEmailRequest{
+		Username: username,
+		Password: password,
+		Email:    email,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return c.startSession(response)
+}
+
+// SignIn ...
+func (c *Client) SignIn(ctx context.Context, username, password string) (token, refreshToken string, err error) {
+	response, err := c.signIn(ctx, service.UsernamePasswordRequest{Username: username, Password: password})
+	if err != nil {
+		return "", "", err
+	}
+
+	return c.startSession(response)
+}
+
+// LogOut ...
+func (c *Client) LogOut(ctx context.Context, token string) (err error) {
+	var used string
+
+	_, err = c.authorized(ctx, token, func(ctx context.Context, token string) (any, error) {
+		used = token
+
+		return c.logOut(ctx, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.endSession(used)
+
+	return nil
+}
+
+// GetAllUsers needs the session of an admin.
+func (c *Client) GetAllUsers(ctx context.Context, query dbapp.UsersQuery) (users []dbapp.User, total int, next string, err error) {
+	response, err := c.authorized(ctx, "", func(ctx context.Context, _ string) (any, error) {
+		return c.getAllUsers(ctx, service.UsersQueryRequest{Query: query})
+	})
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	resp, ok := response.(usersResponse)
+	if !ok {
+		return nil, 0, "", fmt.Errorf("%w: isn't of type usersResponse", errResponse)
+	}
+
+	return resp.Users, resp.Total, resp.Next, nil
+}
+
+// Profile ...
+func (c *Client) Profile(ctx context.Context, token string) (user dbapp.User, err error) {
+	return c.projectedProfile(ctx, token)
+}
+
+func (c *Client) projectedProfile(ctx context.Context, token string, fields ...string) (user dbapp.User, err error) {
+	response, err := c.authorized(ctx, token, func(ctx context.Context, _ string) (any, error) {
+		return c.profile(ctx, service.TokenFieldsRequest{Fields: fields})
+	})
+	if err != nil {
+		return dbapp.User{}, err
+	}
+
+	resp, ok := response.(userResponse)
+	if !ok {
+		return dbapp.User{}, fmt.Errorf("%w: isn't of type userResponse", errResponse)
+	}
+
+	return resp.User, nil
+}
+
+// DeleteAccount ...
+func (c *Client) DeleteAccount(ctx context.Context, token string) (err error) {
+	var used string
+
+	_, err = c.authorized(ctx, token, func(ctx context.Context, token string) (any, error) {
+		used = token
+
+		return c.deleteAccount(ctx, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.endSession(used)
+
+	return nil
+}
+
+// UpdateProfile renews the session held when it is the one updated. The
+// refresh token is empty when the token was kept.
+func (c *Client) UpdateProfile(ctx context.Context, token, username, email string) (newToken, refreshToken string, err error) {
+	var used string
+
+	response, err := c.authorized(ctx, token, func(ctx context.Context, token string) (any, error) {
+		used = token
+
+		return c.updateProfile(ctx, service.TokenUsernameEmailRequest{Username: username, Email: email})
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return c.renewSession(response, used)
+}
+
+// ChangePassword ...
+func (c *Client) ChangePassword(ctx context.Context, token, oldPassword, newPassword string) (err error) {
+	_, err = c.authorized(ctx, token, func(ctx context.Context, _ string) (any, error) {
+		return c.changePassword(ctx, service.TokenOldNewPasswordRequest{
+			OldPassword: oldPassword,
+			NewPassword: newPassword,
+		})
+	})
+
+	return err
+}
+
+// ForgotPassword ...
+func (c *Client) ForgotPassword(ctx context.Context, email string) (err error) {
+	_, err = c.forgotPassword(ctx, service.EmailRequest{Email: email})
+
+	return err
+}
+
+// ResetPassword takes the token of the reset password mail.
+func (c *Client) ResetPassword(ctx context.Context, token, password string) (err error) {
+	_, err = c.resetPassword(ctx, service.TokenPasswordRequest{Token: token, Password: password})
+
+	return err
+}
+
+// VerifyEmail takes the token of the verification mail.
+func (c *Client) VerifyEmail(ctx context.Context, token string) (err error) {
+	_, err = c.verifyEmail(ctx, service.TokenRequest{Token: token})
+
+	return err
+}
+
+// RefreshToken refreshes the session held when refreshToken is empty or its
+// refresh token, other refresh tokens leave it alone.
+func (c *Client) RefreshToken(ctx context.Context, refreshToken string) (token, newRefreshToken string, err error) {
+	if refreshToken == "" {
+		_, refreshToken = c.Session()
+	}
+
+	response, err := c.refreshToken(ctx, service.RefreshTokenRequest{RefreshToken: refreshToken})
+	if err != nil {
+		return "", "", err
+	}
+
+	return c.renewSession(response, refreshToken)
+}
+
+// Authorize checks that the user of token has role, the gateway has no route
+// for it so it is the role of its profile.
+func (c *Client) Authorize(ctx context.Context, token, role string) (err error) {
+	user, err := c.projectedProfile(ctx, token, "role")
+	if err != nil {
+		return err
+	}
+
+	if user.Role != role {
+		return service.ErrForbidden
+	}
+
+	return nil
+}
+
+// DeleteUser needs the session of an admin.
+func (c *Client) DeleteUser(ctx context.Context, id int) (err error) {
+	_, err = c.authorized(ctx, "", func(ctx context.Context, _ string) (any, error) {
+		return c.deleteUser(ctx, service.IDRequest{ID: id})
+	})
+
+	return err
+}
+
+// SuspendUser needs the session of an admin.
+func (c *Client) SuspendUser(ctx context.Context, id int, suspended bool) (err error) {
+	_, err = c.authorized(ctx, "", func(ctx context.Context, _ string) (any, error) {
+		return c.suspendUser(ctx, service.IDSuspendedRequest{ID: id, Suspended: suspended})
+	})
+
+	return err
+}
+
+// startSession holds the tokens of response.
+func (c *Client) startSession(response any) (token, refreshToken string, err error) {
+	resp, ok := response.(service.TokenErrorResponse)
+	if !ok {
+		return "", "", fmt.Errorf("%w: isn't of type TokenErrorResponse", errResponse)
+	}
+
+	c.setSession(resp.Token, resp.RefreshToken)
+
+	return resp.Token, resp.RefreshToken, nil
+}
+
+// renewSession returns the tokens of response and holds them in place of the
+// session held when used, the token or refresh token the call was made with,
+// is one of its own. A response without a refresh token keeps the one held.
+func (c *Client) renewSession(response any, used string) (token, refreshToken string, err error) {
+	resp, ok := response.(service.TokenErrorResponse)
+	if !ok {
+		return "", "", fmt.Errorf("%w: isn't of type TokenErrorResponse", errResponse)
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if used != "" && (used == c.session.token || used == c.session.refreshToken) {
+		c.session.token = resp.Token
+
+		if resp.RefreshToken != "" {
+			c.session.refreshToken = resp.RefreshToken
+		}
+	}
+
+	return resp.Token, resp.RefreshToken, nil
+}
+
+// authorized calls call with token, or the token of the session when it is
+// empty. A session whose token was rejected is refreshed and the call made
+// again once.
+func (c *Client) authorized(
+	ctx context.Context,
+	token string,
+	call func(ctx context.Context, token string) (any, error),
+) (any, error) {
+	if token != "" {
+		return call(context.WithValue(ctx, tokenContextKey, token), token)
+	}
+
+	token, refreshToken := c.Session()
+
+	response, err := call(context.WithValue(ctx, tokenContextKey, token), token)
+	if err == nil || refreshToken == "" || !errors.Is(err, service.ErrUnauthorized) {
+		return response, err
+	}
+
+	token, refreshErr := c.refreshSession(ctx, token)
+	if refreshErr != nil {
+		return nil, err
+	}
+
+	return call(context.WithValue(ctx, tokenContextKey, token), token)
+}
+
+// refreshSession refreshes the session whose token was rejected, unless
+// another call already did it while this one waited.
+func (c *Client) refreshSession(ctx context.Context, rejected string) (token string, err error) {
+	c.refreshMtx.Lock()
+	defer c.refreshMtx.Unlock()
+
+	token, refreshToken := c.Session()
+	if token != rejected {
+		return token, nil
+	}
+
+	if refreshToken == "" {
+		return "", service.ErrTokenNotValid
+	}
+
+	token, _, err = c.RefreshToken(ctx, refreshToken)
+
+	return token, err
+}
+
+func withTimeout(timeout time.Duration) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		if timeout <= 0 {
+			return next
+		}
+
+		return func(ctx context.Context, request any) (any, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// usersResponse is service.UsersErrorResponse read into users, the views
+// have the JSON names of dbapp.User.
+type usersResponse struct {
+	Next  string       `json:"next"`
+	Users []dbapp.User `json:"users"`
+	Total int          `json:"total"`
+}
+
+// userResponse is service.UserErrorResponse read into a user.
+type userResponse struct {
+	User dbapp.User `json:"user"`
+}
+
+func tokenToHeader(ctx context.Context, r *http.Request) context.Context {
+	if token, _ := ctx.Value(tokenContextKey).(string); token != "" {
+		r.Header.Set("Authorization", token)
+	}
+
+	return ctx
+}
+
+func encodeBody(ctx context.Context, r *http.Request, request any) error {
+	if err := httptransport.EncodeJSONRequest(ctx, r, request); err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	return nil
+}
+
+func encodeEmpty(_ context.Context, _ *http.Request, _ any) error {
+	return nil
+}
+
+func encodeFields(_ context.Context, r *http.Request, request any) error {
+	req, ok := request.(service.TokenFieldsRequest)
+	if !ok {
+		return fmt.Errorf("%w: isn't of type TokenFieldsRequest", service.ErrRequest)
+	}
+
+	if len(req.Fields) > 0 {
+		r.URL.RawQuery = url.Values{"fields": {strings.Join(req.Fields, ",")}}.Encode()
+	}
+
+	return nil
+}
+
+func encodeUsersQuery(_ context.Context, r *http.Request, request any) error {
+	req, ok := request.(service.UsersQueryRequest)
+	if !ok {
+		return fmt.Errorf("%w: isn't of type UsersQueryRequest", service.ErrRequest)
+	}
+
+	query := url.Values{}
+
+	for name, value := range map[string]string{
+		"cursor":   req.Query.Cursor,
+		"username": req.Query.UsernamePrefix,
+		"email":    req.Query.EmailPrefix,
+		"sort":     req.Query.Sort,
+		"fields":   strings.Join(req.Fields, ","),
+	} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+
+	if req.Query.Limit > 0 {
+		query.Set("limit", strconv.Itoa(req.Query.Limit))
+	}
+
+	r.URL.RawQuery = query.Encode()
+
+	return nil
+}
+
+func encodeTokenQuery(_ context.Context, r *http.Request, request any) error {
+	req, ok := request.(service.TokenRequest)
+	if !ok {
+		return fmt.Errorf("%w: isn't of type TokenRequest", service.ErrRequest)
+	}
+
+	r.URL.RawQuery = url.Values{"token": {req.Token}}.Encode()
+
+	return nil
+}
+
+// decodeResponse reads a response into response, failures into the typed
+// error of their problem document.
+func decodeResponse[response any](_ context.Context, r *http.Response) (any, error) {
+	if r.StatusCode >= http.StatusBadRequest {
+		return nil, service.ProblemError(r)
+	}
+
+	var resp response
+	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+		return nil, &service.Error{Kind: service.ErrWebServer, Err: fmt.Errorf("failed to decode response: %w", err)}
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,517 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/app/client"
+	"github.com/cfabrica46/gokit-crud/app/service"
+	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	tokenTest        string = "token"
+	refreshTokenTest string = "refresh"
+	usernameTest     string = "username"
+	passwordTest     string = "password"
+	emailTest        string = "email@email.com"
+)
+
+type gatewayCall struct {
+	method, path, query, authorization, body string
+}
+
+// newGateway answers every call with status and response, recording it.
+func newGateway(t *testing.T, status int, response string) (*httptest.Server, *[]gatewayCall) {
+	t.Helper()
+
+	var calls []gatewayCall
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		calls = append(calls, gatewayCall{
+			method:        r.Method,
+			path:          r.URL.Path,
+			query:         r.URL.RawQuery,
+			authorization: r.Header.Get("Authorization"),
+			body:          strings.TrimSpace(string(body)),
+		})
+
+		if status >= http.StatusBadRequest {
+			w.Header().Set("Content-Type", "application/problem+json")
+		}
+
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(response))
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server, &calls
+}
+
+func TestClient(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		call     func(*client.Client) error
+		name     string
+		response string
+		outCall  gatewayCall
+	}{
+		{
+			name:     "SignUp",
+			response: `{"token":"token","refreshToken":"refresh"}`,
+			call: func(c *client.Client) error {
+				_, _, err := c.SignUp(context.TODO(), usernameTest, passwordTest, emailTest)
+
+				return err
+			},
+			outCall: gatewayCall{
+				method: http.MethodPost,
+				path:   "/signup",
+				body:   `{"username":"username","password":"password","email":"email@email.com"}`,
+			},
+		},
+		{
+			name:     "SignIn",
+			response: `{"token":"token","refreshToken":"refresh"}`,
+			call: func(c *client.Client) error {
+				_, _, err := c.SignIn(context.TODO(), usernameTest, passwordTest)
+
+				return err
+			},
+			outCall: gatewayCall{
+				method: http.MethodPost,
+				path:   "/signin",
+				body:   `{"username":"username","password":"password"}`,
+			},
+		},
+		{
+			name:     "LogOut",
+			response: `{}`,
+			call: func(c *client.Client) error {
+				return c.LogOut(context.TODO(), tokenTest)
+			},
+			outCall: gatewayCall{method: http.MethodPost, path: "/logout", authorization: tokenTest},
+		},
+		{
+			name:     "GetAllUsers",
+			response: `{"users":[{"id":1,"username":"username"}],"total":1}`,
+			call: func(c *client.Client) error {
+				_, _, _, err := c.GetAllUsers(context.TODO(), dbapp.UsersQuery{UsernamePrefix: "user", Limit: 10})
+
+				return err
+			},
+			outCall: gatewayCall{
+				method:        http.MethodGet,
+				path:          "/users",
+				query:         "limit=10&username=user",
+				authorization: tokenTest,
+			},
+		},
+		{
+			name:     "Profile",
+			response: `{"user":{"id":1}}`,
+			call: func(c *client.Client) error {
+				_, err := c.Profile(context.TODO(), "")
+
+				return err
+			},
+			outCall: gatewayCall{method: http.MethodPost, path: "/profile", authorization: tokenTest},
+		},
+		{
+			name:     "DeleteAccount",
+			response: `{}`,
+			call: func(c *client.Client) error {
+				return c.DeleteAccount(context.TODO(), tokenTest)
+			},
+			outCall: gatewayCall{method: http.MethodDelete, path: "/profile", authorization: tokenTest},
+		},
+		{
+			name:     "UpdateProfile",
+			response: `{"token":"token"}`,
+			call: func(c *client.Client) error {
+				_, _, err := c.UpdateProfile(context.TODO(), tokenTest, usernameTest, emailTest)
+
+				return err
+			},
+			outCall: gatewayCall{
+				method:        http.MethodPut,
+				path:          "/profile",
+				authorization: tokenTest,
+				body:          `{"username":"username","email":"email@email.com"}`,
+			},
+		},
+		{
+			name:     "ChangePassword",
+			response: `{}`,
+			call: func(c *client.Client) error {
+				return c.ChangePassword(context.TODO(), tokenTest, passwordTest, "new")
+			},
+			outCall: gatewayCall{
+				method:        http.MethodPost,
+				path:          "/profile/password",
+				authorization: tokenTest,
+				body:          `{"oldPassword":"password","newPassword":"new"}`,
+			},
+		},
+		{
+			name:     "ForgotPassword",
+			response: `{}`,
+			call: func(c *client.Client) error {
+				return c.ForgotPassword(context.TODO(), emailTest)
+			},
+			outCall: gatewayCall{
+				method: http.MethodPost,
+				path:   "/password/forgot",
+				body:   `{"email":"email@email.com"}`,
+			},
+		},
+		{
+			name:     "ResetPassword",
+			response: `{}`,
+			call: func(c *client.Client) error {
+				return c.ResetPassword(context.TODO(), "reset", passwordTest)
+			},
+			outCall: gatewayCall{
+				method: http.MethodPost,
+				path:   "/password/reset",
+				body:   `{"token":"reset","password":"password"}`,
+			},
+		},
+		{
+			name:     "VerifyEmail",
+			response: `{}`,
+			call: func(c *client.Client) error {
+				return c.VerifyEmail(context.TODO(), "verify")
+			},
+			outCall: gatewayCall{method: http.MethodGet, path: "/verify-email", query: "token=verify"},
+		},
+		{
+			name:     "RefreshToken",
+			response: `{"token":"token","refreshToken":"refresh"}`,
+			call: func(c *client.Client) error {
+				_, _, err := c.RefreshToken(context.TODO(), "")
+
+				return err
+			},
+			outCall: gatewayCall{
+				method: http.MethodPost,
+				path:   "/token/refresh",
+				body:   `{"refreshToken":"refresh"}`,
+			},
+		},
+		{
+			name:     "Authorize",
+			response: `{"user":{"role":"admin"}}`,
+			call: func(c *client.Client) error {
+				return c.Authorize(context.TODO(), "", dbapp.RoleAdmin)
+			},
+			outCall: gatewayCall{method: http.MethodPost, path: "/profile", query: "fields=role", authorization: tokenTest},
+		},
+		{
+			name:     "DeleteUser",
+			response: `{}`,
+			call: func(c *client.Client) error {
+				return c.DeleteUser(context.TODO(), 1)
+			},
+			outCall: gatewayCall{method: http.MethodDelete, path: "/users", authorization: tokenTest, body: `{"id":1}`},
+		},
+		{
+			name:     "SuspendUser",
+			response: `{}`,
+			call: func(c *client.Client) error {
+				return c.SuspendUser(context.TODO(), 1, true)
+			},
+			outCall: gatewayCall{
+				method:        http.MethodPatch,
+				path:          "/users/suspended",
+				authorization: tokenTest,
+				body:          `{"id":1,"suspended":true}`,
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, calls := newGateway(t, http.StatusOK, tt.response)
+
+			c, err := client.New(client.WithBaseURL(server.URL), client.WithSession(tokenTest, refreshTokenTest))
+			assert.NoError(t, err)
+
+			assert.NoError(t, tt.call(c))
+			assert.Equal(t, []gatewayCall{tt.outCall}, *calls)
+		})
+	}
+}
+
+func TestClientSession(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newGateway(t, http.StatusOK, `{"token":"token","refreshToken":"refresh"}`)
+
+	c, err := client.New(client.WithBaseURL(server.URL+"/"), client.WithHTTPClient(server.Client()))
+	assert.NoError(t, err)
+
+	_, _, err = c.SignIn(context.TODO(), usernameTest, passwordTest)
+	assert.NoError(t, err)
+
+	token, refreshToken := c.Session()
+	assert.Equal(t, tokenTest, token)
+	assert.Equal(t, refreshTokenTest, refreshToken)
+
+	assert.NoError(t, c.LogOut(context.TODO(), "other"))
+
+	token, _ = c.Session()
+	assert.Equal(t, tokenTest, token)
+
+	assert.NoError(t, c.LogOut(context.TODO(), ""))
+
+	token, refreshToken = c.Session()
+	assert.Empty(t, token)
+	assert.Empty(t, refreshToken)
+}
+
+func TestClientRenewsSession(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name            string
+		inToken         string
+		inResponse      string
+		outToken        string
+		outRefreshToken string
+	}{
+		{
+			name:            "NewSession",
+			inResponse:      `{"token":"new","refreshToken":"newrefresh"}`,
+			outToken:        "new",
+			outRefreshToken: "newrefresh",
+		},
+		{
+			name:            "TokenKept",
+			inResponse:      `{"token":"token"}`,
+			outToken:        tokenTest,
+			outRefreshToken: refreshTokenTest,
+		},
+		{
+			name:            "OtherSession",
+			inToken:         "other",
+			inResponse:      `{"token":"new","refreshToken":"newrefresh"}`,
+			outToken:        tokenTest,
+			outRefreshToken: refreshTokenTest,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, _ := newGateway(t, http.StatusOK, tt.inResponse)
+
+			c, err := client.New(
+				client.WithBaseURL(server.URL),
+				client.WithHTTPClient(server.Client()),
+				client.WithSession(tokenTest, refreshTokenTest),
+			)
+			assert.NoError(t, err)
+
+			_, _, err = c.UpdateProfile(context.TODO(), tt.inToken, "newusername", "")
+			assert.NoError(t, err)
+
+			token, refreshToken := c.Session()
+			assert.Equal(t, tt.outToken, token)
+			assert.Equal(t, tt.outRefreshToken, refreshToken)
+		})
+	}
+}
+
+func TestClientRefreshesSession(t *testing.T) {
+	t.Parallel()
+
+	var authorizations []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+
+		switch {
+		case r.URL.Path == "/token/refresh":
+			_, _ = w.Write([]byte(`{"token":"new","refreshToken":"newrefresh"}`))
+		case r.Header.Get("Authorization") == "new":
+			_, _ = w.Write([]byte(`{"user":{"id":1,"username":"username"}}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"status":401,"detail":"token expired"}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := client.New(client.WithBaseURL(server.URL), client.WithSession(tokenTest, refreshTokenTest))
+	assert.NoError(t, err)
+
+	user, err := c.Profile(context.TODO(), "")
+	assert.NoError(t, err)
+	assert.Equal(t, dbapp.User{ID: 1, Username: usernameTest}, user)
+	assert.Equal(t, []string{tokenTest, "", "new"}, authorizations)
+
+	token, refreshToken := c.Session()
+	assert.Equal(t, "new", token)
+	assert.Equal(t, "newrefresh", refreshToken)
+
+	// an explicit token is not refreshed.
+	_, err = c.Profile(context.TODO(), tokenTest)
+	assert.ErrorIs(t, err, service.ErrUnauthorized)
+}
+
+func TestClientRefreshesSessionOnce(t *testing.T) {
+	t.Parallel()
+
+	const calls = 4
+
+	var (
+		rejected  sync.WaitGroup
+		refreshes int32
+	)
+
+	rejected.Add(calls)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token/refresh":
+			// token-app revokes the session when a refresh token is reused.
+			if atomic.AddInt32(&refreshes, 1) > 1 {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"status":401,"detail":"refresh token reused"}`))
+
+				return
+			}
+
+			_, _ = w.Write([]byte(`{"token":"new","refreshToken":"newrefresh"}`))
+		case r.Header.Get("Authorization") == "new":
+			_, _ = w.Write([]byte(`{"user":{"id":1,"username":"username"}}`))
+		default:
+			// every call is rejected before any of them refreshes.
+			rejected.Done()
+			rejected.Wait()
+
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"status":401,"detail":"token expired"}`))
+		}
+	}))
+	defer server.Close()
+
+	c, err := client.New(client.WithBaseURL(server.URL), client.WithSession(tokenTest, refreshTokenTest))
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, calls)
+
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := c.Profile(context.TODO(), "")
+			errs <- err
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&refreshes))
+
+	token, refreshToken := c.Session()
+	assert.Equal(t, "new", token)
+	assert.Equal(t, "newrefresh", refreshToken)
+}
+
+func TestClientErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		outErr   error
+		name     string
+		response string
+		inStatus int
+	}{
+		{
+			name:     "ErrorNotFound",
+			inStatus: http.StatusNotFound,
+			response: `{"status":404,"detail":"user not found"}`,
+			outErr:   service.ErrNotFound,
+		},
+		{
+			name:     "ErrorConflict",
+			inStatus: http.StatusConflict,
+			response: `{"status":409,"detail":"username already exists"}`,
+			outErr:   service.ErrConflict,
+		},
+		{
+			name:     "ErrorForbidden",
+			inStatus: http.StatusOK,
+			response: `{"user":{"role":"user"}}`,
+			outErr:   service.ErrForbidden,
+		},
+		{
+			name:     "ErrorDecode",
+			inStatus: http.StatusOK,
+			response: `not json`,
+			outErr:   service.ErrWebServer,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, _ := newGateway(t, tt.inStatus, tt.response)
+
+			c, err := client.New(client.WithBaseURL(server.URL))
+			assert.NoError(t, err)
+
+			assert.ErrorIs(t, c.Authorize(context.TODO(), tokenTest, dbapp.RoleAdmin), tt.outErr)
+		})
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	t.Parallel()
+
+	release := make(chan struct{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	c, err := client.New(client.WithBaseURL(server.URL), client.WithTimeout(10*time.Millisecond))
+	assert.NoError(t, err)
+
+	err = c.ForgotPassword(context.TODO(), emailTest)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), err)
+}
+
+func TestNewErrorBaseURL(t *testing.T) {
+	t.Parallel()
+
+	_, err := client.New(client.WithBaseURL("://gateway"))
+	assert.ErrorContains(t, err, "invalid base URL")
+}
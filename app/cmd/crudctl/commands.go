@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
+	tokenapp "github.com/cfabrica46/gokit-crud/token-app/service"
+)
+
+var errUnhealthy = errors.New("some services are down")
+
+// newFlagSet parses the flags of a command, their errors are usage errors.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	return fs
+}
+
+func parse(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%w: %s: %v", errUsage, fs.Name(), err)
+	}
+
+	return nil
+}
+
+// listUsers pages through the users of database-app, search narrows them by
+// the prefixes of their username and email.
+func (c *ctl) listUsers(ctx context.Context, name string, args []string) error {
+	var query dbapp.UsersQuery
+
+	fs := newFlagSet("users " + name)
+	fs.IntVar(&query.Limit, "limit", 0, "users per page")
+	fs.StringVar(&query.Cursor, "cursor", "", "next of the previous page")
+	fs.StringVar(&query.Sort, "sort", "", "asc or desc")
+	fs.StringVar(&query.UsernamePrefix, "username", "", "prefix of the username")
+	fs.StringVar(&query.EmailPrefix, "email", "", "prefix of the email")
+
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if name == "search" && query.UsernamePrefix == "" && query.EmailPrefix == "" {
+		return fmt.Errorf("%w: users search needs -username or -email", errUsage)
+	}
+
+	var response dbapp.UsersErrorResponse
+
+	if err := service.RequestFunc(
+		ctx,
+		c.client,
+		query,
+		service.NewHTTPComponents(c.database+"/users", http.MethodGet),
+		&response,
+	); err != nil {
+		return err
+	}
+
+	return c.out.users(response)
+}
+
+// createUser inserts a user in database-app.
+func (c *ctl) createUser(ctx context.Context, args []string) error {
+	var request dbapp.UsernamePasswordEmailRequest
+
+	fs := newFlagSet("users create")
+	fs.StringVar(&request.Username, "username", "", "username")
+	fs.StringVar(&request.Email, "email", "", "email")
+
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if request.Username == "" || request.Email == "" {
+		return fmt.Errorf("%w: users create needs -username and -email", errUsage)
+	}
+
+	password, err := c.readPassword()
+	if err != nil {
+		return err
+	}
+
+	request.Password = password
+
+	var response dbapp.IDErrorResponse
+
+	if err = service.RequestFunc(
+		ctx,
+		c.client,
+		request,
+		service.NewHTTPComponents(c.database+"/user", http.MethodPost),
+		&response,
+	); err != nil {
+		return err
+	}
+
+	return c.out.id(response)
+}
+
+// readPassword is CRUDCTL_PASSWORD, or else the first line of the standard
+// input.
+func (c *ctl) readPassword() (string, error) {
+	if c.password != "" {
+		return c.password, nil
+	}
+
+	line, err := bufio.NewReader(c.in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read the password: %w", err)
+	}
+
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", fmt.Errorf("%w: users create needs the password in CRUDCTL_PASSWORD or the standard input", errUsage)
+	}
+
+	return password, nil
+}
+
+// deleteUser revokes the tokens of a user and deletes it, whatever its
+// role or state.
+func (c *ctl) deleteUser(ctx context.Context, args []string) error {
+	id, err := parseID("users delete", args)
+	if err != nil {
+		return err
+	}
+
+	if err = c.revoke(ctx, id); err != nil {
+		return err
+	}
+
+	var response dbapp.RowsErrorResponse
+
+	if err = service.RequestFunc(
+		ctx,
+		c.client,
+		dbapp.IDRequest{ID: id},
+		service.NewHTTPComponents(c.database+"/user", http.MethodDelete),
+		&response,
+	); err != nil {
+		return err
+	}
+
+	if response.RowsAffected == 0 {
+		return fmt.Errorf("%w: user %d", service.ErrNotFound, id)
+	}
+
+	return c.out.rows(response)
+}
+
+// revokeTokens ends every session of a user.
+func (c *ctl) revokeTokens(ctx context.Context, args []string) error {
+	id, err := parseID("tokens revoke", args)
+	if err != nil {
+		return err
+	}
+
+	if err = c.revoke(ctx, id); err != nil {
+		return err
+	}
+
+	return c.out.message(fmt.Sprintf("revoked the tokens of user %d", id))
+}
+
+func (c *ctl) revoke(ctx context.Context, id int) error {
+	var response tokenapp.ErrorResponse
+
+	return service.RequestFunc(
+		ctx,
+		c.client,
+		tokenapp.IDRequest{ID: id},
+		service.NewHTTPComponents(c.token+"/tokens", http.MethodDelete),
+		&response,
+	)
+}
+
+// inspectToken shows the claims of a token and whether it is still valid.
+func (c *ctl) inspectToken(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("%w: tokens inspect needs a token", errUsage)
+	}
+
+	if c.secret == "" {
+		return fmt.Errorf("%w: tokens inspect needs -secret or SECRET", errUsage)
+	}
+
+	var claims tokenapp.IDUsernameEmailErrResponse
+
+	if err := service.RequestFunc(
+		ctx,
+		c.client,
+		tokenapp.TokenSecretRequest{Token: args[0], Secret: c.secret},
+		service.NewHTTPComponents(c.token+"/extract", http.MethodPost),
+		&claims,
+	); err != nil {
+		return err
+	}
+
+	var check tokenapp.CheckErrResponse
+
+	if err := service.RequestFunc(
+		ctx,
+		c.client,
+		tokenapp.Token{Token: args[0]},
+		service.NewHTTPComponents(c.token+"/check", http.MethodPost),
+		&check,
+	); err != nil {
+		return err
+	}
+
+	return c.out.claims(claims, check)
+}
+
+// health checks that every service answers, it fails when one of them
+// does not.
+func (c *ctl) health(ctx context.Context, args []string) error {
+	if err := parse(newFlagSet("health"), args); err != nil {
+		return err
+	}
+
+	statuses := make([]healthStatus, 0, 3)
+	healthy := true
+
+	for _, target := range []struct{ name, url string }{
+		{"app", c.gateway},
+		{"database-app", c.database},
+		{"token-app", c.token},
+	} {
+		status := healthStatus{Service: target.name, URL: target.url, Status: "up"}
+
+		if err := c.probe(ctx, target.url); err != nil {
+			status.Status, status.Error = "down", err.Error()
+			healthy = false
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	if err := c.out.health(statuses); err != nil {
+		return err
+	}
+
+	if !healthy {
+		return errUnhealthy
+	}
+
+	return nil
+}
+
+// probe asks a service whether it is ready, every service answers /readyz.
+func (c *ctl) probe(ctx context.Context, url string) error {
+	return service.DownstreamCheck(c.client, url+"/readyz")(ctx)
+}
+
+func parseID(name string, args []string) (int, error) {
+	fs := newFlagSet(name)
+	id := fs.Int("id", 0, "id of the user")
+
+	if err := parse(fs, args); err != nil {
+		return 0, err
+	}
+
+	if *id <= 0 {
+		return 0, fmt.Errorf("%w: %s needs -id", errUsage, name)
+	}
+
+	return *id, nil
+}
@@ -0,0 +1,140 @@
+// Command crudctl operates the system: it lists, searches, creates and
+// deletes users in database-app, revokes and inspects tokens in token-app
+// and checks that the app gateway and both services are ready.
+//
+// Usage:
+//
+//	crudctl [flags] users list [-limit n] [-cursor c] [-sort asc|desc]
+//	crudctl [flags] users search [-username prefix] [-email prefix] [-limit n] [-cursor c]
+//	crudctl [flags] users create -username u -email e
+//	crudctl [flags] users delete -id n
+//	crudctl [flags] tokens revoke -id n
+//	crudctl [flags] tokens inspect token
+//	crudctl [flags] health
+//
+// users create reads the password from CRUDCTL_PASSWORD, or else from the
+// first line of the standard input, so that it is not left in the shell
+// history or the process list.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+)
+
+var errUsage = errors.New("usage")
+
+// ctl holds the addresses of the services and where the results go.
+type ctl struct {
+	client                   service.HTTPClient
+	in                       io.Reader
+	out                      printer
+	gateway, database, token string
+	secret, password         string
+}
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run is crudctl with its arguments, it returns the exit code: 2 for usage
+// errors and 1 for any other failure.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("crudctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: crudctl [flags] users|tokens|health ...")
+		fs.PrintDefaults()
+	}
+
+	gateway := fs.String("gateway", envOr("CRUDCTL_GATEWAY", "http://localhost:8080"), "address of the app gateway")
+	database := fs.String("database", envOr("CRUDCTL_DATABASE", "http://localhost:7070"), "address of database-app")
+	token := fs.String("token", envOr("CRUDCTL_TOKEN", "http://localhost:9090"), "address of token-app")
+	secret := fs.String("secret", os.Getenv("SECRET"), "secret the tokens are signed with")
+	output := fs.String("output", "table", "output format: table or json")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout of every call")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	out, err := newPrinter(*output, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, "crudctl:", err)
+
+		return 2
+	}
+
+	c := &ctl{
+		client:   &http.Client{Timeout: *timeout},
+		in:       stdin,
+		out:      out,
+		gateway:  strings.TrimSuffix(*gateway, "/"),
+		database: strings.TrimSuffix(*database, "/"),
+		token:    strings.TrimSuffix(*token, "/"),
+		secret:   *secret,
+		password: os.Getenv("CRUDCTL_PASSWORD"),
+	}
+
+	if err = c.run(ctx, fs.Args()); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintln(stderr, "crudctl:", err)
+			fs.Usage()
+
+			return 2
+		}
+
+		fmt.Fprintln(stderr, "crudctl:", err)
+
+		return 1
+	}
+
+	return 0
+}
+
+// run dispatches the command in args.
+func (c *ctl) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: missing command", errUsage)
+	}
+
+	if args[0] == "health" {
+		return c.health(ctx, args[1:])
+	}
+
+	if len(args) < 2 {
+		return fmt.Errorf("%w: missing %s command", errUsage, args[0])
+	}
+
+	switch command := args[0] + " " + args[1]; command {
+	case "users list", "users search":
+		return c.listUsers(ctx, args[1], args[2:])
+	case "users create":
+		return c.createUser(ctx, args[2:])
+	case "users delete":
+		return c.deleteUser(ctx, args[2:])
+	case "tokens revoke":
+		return c.revokeTokens(ctx, args[2:])
+	case "tokens inspect":
+		return c.inspectToken(ctx, args[2:])
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, command)
+	}
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+
+	return fallback
+}
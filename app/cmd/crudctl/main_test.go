@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newServices answers as database-app and token-app would, recording the
+// calls.
+func newServices(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+
+	var calls []string
+
+	responses := map[string]string{
+		"GET /users":     `{"users":[{"id":1,"username":"admin","email":"admin@email.com","role":"admin"}],"total":2,"next":"1"}`,
+		"POST /user":     `{"id":2}`,
+		"DELETE /user":   `{"rowsAffected":1}`,
+		"DELETE /tokens": `{}`,
+		"POST /extract":  `{"id":1,"username":"admin","email":"admin@email.com","role":"admin"}`,
+		"POST /check":    `{"check":true}`,
+		"GET /readyz":    `{"status":"ok"}`,
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		call := r.Method + " " + r.URL.Path
+		calls = append(calls, strings.TrimSpace(call+" "+string(body)))
+
+		response, ok := responses[call]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"status":404,"detail":"not found"}`))
+
+			return
+		}
+
+		_, _ = w.Write([]byte(response))
+	}))
+
+	t.Cleanup(server.Close)
+
+	return server, &calls
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name     string
+		inStdin  string
+		inArgs   []string
+		outCalls []string
+		outLines []string
+		outCode  int
+	}{
+		{
+			name:     "UsersList",
+			inArgs:   []string{"users", "list", "-limit", "1"},
+			outCalls: []string{`GET /users {"limit":1}`},
+			outLines: []string{
+				"ID  USERNAME  EMAIL            ROLE   VERIFIED  SUSPENDED",
+				"1   admin     admin@email.com  admin  false     false",
+				"1 of 2 users, next page: -cursor 1",
+			},
+		},
+		{
+			name:     "UsersSearch",
+			inArgs:   []string{"users", "search", "-username", "ad"},
+			outCalls: []string{`GET /users {"username":"ad"}`},
+			outLines: []string{
+				"ID  USERNAME  EMAIL            ROLE   VERIFIED  SUSPENDED",
+				"1   admin     admin@email.com  admin  false     false",
+				"1 of 2 users, next page: -cursor 1",
+			},
+		},
+		{
+			name:    "ErrorUsersSearchWithoutPrefix",
+			inArgs:  []string{"users", "search"},
+			outCode: 2,
+		},
+		{
+			name:     "UsersCreate",
+			inStdin:  "password\n",
+			inArgs:   []string{"users", "create", "-username", "user", "-email", "user@email.com"},
+			outCalls: []string{`POST /user {"username":"user","password":"password","email":"user@email.com"}`},
+			outLines: []string{"created user 2"},
+		},
+		{
+			name:    "ErrorUsersCreateWithoutPassword",
+			inArgs:  []string{"users", "create", "-username", "user", "-email", "user@email.com"},
+			outCode: 2,
+		},
+		{
+			name:     "UsersDelete",
+			inArgs:   []string{"users", "delete", "-id", "2"},
+			outCalls: []string{`DELETE /tokens {"id":2}`, `DELETE /user {"id":2}`},
+			outLines: []string{"deleted 1 user(s)"},
+		},
+		{
+			name:    "ErrorUsersDeleteWithoutID",
+			inArgs:  []string{"users", "delete"},
+			outCode: 2,
+		},
+		{
+			name:     "TokensRevoke",
+			inArgs:   []string{"tokens", "revoke", "-id", "2"},
+			outCalls: []string{`DELETE /tokens {"id":2}`},
+			outLines: []string{"revoked the tokens of user 2"},
+		},
+		{
+			name:     "TokensInspect",
+			inArgs:   []string{"-secret", "secret", "tokens", "inspect", "token"},
+			outCalls: []string{`POST /extract {"token":"token","secret":"secret"}`, `POST /check {"token":"token"}`},
+			outLines: []string{
+				"ID  USERNAME  EMAIL            ROLE   VALID",
+				"1   admin     admin@email.com  admin  true",
+			},
+		},
+		{
+			name:     "TokensInspectJSON",
+			inArgs:   []string{"-secret", "secret", "-output", "json", "tokens", "inspect", "token"},
+			outCalls: []string{`POST /extract {"token":"token","secret":"secret"}`, `POST /check {"token":"token"}`},
+			outLines: []string{
+				"{",
+				`  "username": "admin",`,
+				`  "email": "admin@email.com",`,
+				`  "role": "admin",`,
+				`  "id": 1,`,
+				`  "valid": true`,
+				"}",
+			},
+		},
+		{
+			name:     "Health",
+			inArgs:   []string{"-output", "json", "health"},
+			outCalls: []string{"GET /readyz", "GET /readyz", "GET /readyz"},
+		},
+		{
+			name:    "ErrorUnknownCommand",
+			inArgs:  []string{"users", "rename"},
+			outCode: 2,
+		},
+		{
+			name:    "ErrorUnknownOutput",
+			inArgs:  []string{"-output", "yaml", "health"},
+			outCode: 2,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server, calls := newServices(t)
+
+			var stdout, stderr bytes.Buffer
+
+			args := append([]string{"-gateway", server.URL, "-database", server.URL, "-token", server.URL}, tt.inArgs...)
+
+			code := run(context.TODO(), args, strings.NewReader(tt.inStdin), &stdout, &stderr)
+
+			assert.Equal(t, tt.outCode, code, stderr.String())
+			assert.Equal(t, tt.outCalls, nilIfEmpty(*calls))
+
+			if tt.outLines != nil {
+				assert.Equal(t, strings.Join(tt.outLines, "\n")+"\n", stdout.String())
+			}
+		})
+	}
+}
+
+func TestRunHealthDown(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newServices(t)
+
+	var stdout, stderr bytes.Buffer
+
+	code := run(context.TODO(), []string{
+		"-gateway", server.URL,
+		"-database", server.URL,
+		"-token", "http://127.0.0.1:1",
+		"-output", "json",
+		"health",
+	}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stdout.String(), `"url": "http://127.0.0.1:1",
+    "status": "down",`)
+	assert.Contains(t, stderr.String(), errUnhealthy.Error())
+}
+
+func TestRunErrorNotFound(t *testing.T) {
+	t.Parallel()
+
+	server, _ := newServices(t)
+
+	var stdout, stderr bytes.Buffer
+
+	code := run(context.TODO(), []string{"-database", server.URL + "/missing", "users", "list"}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr.String(), "not found")
+}
+
+func nilIfEmpty(calls []string) []string {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	return calls
+}
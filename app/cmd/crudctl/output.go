@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
+	tokenapp "github.com/cfabrica46/gokit-crud/token-app/service"
+)
+
+// healthStatus is the state of a service.
+type healthStatus struct {
+	Service string `json:"service"`
+	URL     string `json:"url"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// printer writes the results as JSON or as a table.
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+func newPrinter(format string, w io.Writer) (printer, error) {
+	switch format {
+	case "table":
+		return printer{w: w}, nil
+	case "json":
+		return printer{w: w, json: true}, nil
+	default:
+		return printer{}, fmt.Errorf("%w: unknown output %q", errUsage, format)
+	}
+}
+
+func (p printer) users(response dbapp.UsersErrorResponse) error {
+	if p.json {
+		return p.encode(response)
+	}
+
+	rows := [][]string{{"ID", "USERNAME", "EMAIL", "ROLE", "VERIFIED", "SUSPENDED"}}
+	for _, user := range response.Users {
+		rows = append(rows, []string{
+			strconv.Itoa(user.ID),
+			user.Username,
+			user.Email,
+			user.Role,
+			strconv.FormatBool(user.EmailVerified),
+			strconv.FormatBool(user.Suspended),
+		})
+	}
+
+	if err := p.table(rows); err != nil {
+		return err
+	}
+
+	footer := fmt.Sprintf("%d of %d users", len(response.Users), response.Total)
+	if response.Next != "" {
+		footer += ", next page: -cursor " + response.Next
+	}
+
+	return p.message(footer)
+}
+
+func (p printer) id(response dbapp.IDErrorResponse) error {
+	if p.json {
+		return p.encode(response)
+	}
+
+	return p.message(fmt.Sprintf("created user %d", response.ID))
+}
+
+func (p printer) rows(response dbapp.RowsErrorResponse) error {
+	if p.json {
+		return p.encode(response)
+	}
+
+	return p.message(fmt.Sprintf("deleted %d user(s)", response.RowsAffected))
+}
+
+func (p printer) claims(claims tokenapp.IDUsernameEmailErrResponse, check tokenapp.CheckErrResponse) error {
+	if p.json {
+		return p.encode(struct {
+			tokenapp.IDUsernameEmailErrResponse
+			Valid bool `json:"valid"`
+		}{claims, check.Check})
+	}
+
+	return p.table([][]string{
+		{"ID", "USERNAME", "EMAIL", "ROLE", "VALID"},
+		{strconv.Itoa(claims.ID), claims.Username, claims.Email, claims.Role, strconv.FormatBool(check.Check)},
+	})
+}
+
+func (p printer) health(statuses []healthStatus) error {
+	if p.json {
+		return p.encode(statuses)
+	}
+
+	rows := [][]string{{"SERVICE", "URL", "STATUS", "ERROR"}}
+	for _, status := range statuses {
+		rows = append(rows, []string{status.Service, status.URL, status.Status, status.Error})
+	}
+
+	return p.table(rows)
+}
+
+func (p printer) message(message string) error {
+	if p.json {
+		return p.encode(map[string]string{"message": message})
+	}
+
+	if _, err := fmt.Fprintln(p.w, message); err != nil {
+		return fmt.Errorf("failed to print: %w", err)
+	}
+
+	return nil
+}
+
+func (p printer) encode(v any) error {
+	encoder := json.NewEncoder(p.w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to print: %w", err)
+	}
+
+	return nil
+}
+
+func (p printer) table(rows [][]string) error {
+	w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+
+	for _, row := range rows {
+		for i, cell := range row {
+			if i > 0 {
+				fmt.Fprint(w, "\t")
+			}
+
+			fmt.Fprint(w, cell)
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to print: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+// Package config is the configuration of the app gateway.
+package config
+
+import (
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/platform/settings"
+)
+
+// Config is read by Load, see settings.Load for the sources and the tags.
+type Config struct {
+	Port             string `env:"PORT" default:"8080" usage:"port to listen on" validate:"required,port"`
+	GRPCPort         string `env:"GRPC_PORT" usage:"port to serve gRPC on, off if empty" validate:"port"`
+	Secret           string `env:"SECRET" usage:"secret the tokens are signed with" secret:"true" validate:"required"`
+	MailOutbox       string `env:"MAIL_OUTBOX" default:"outbox" usage:"directory the mails are written to" validate:"required"`
+	IdempotencyStore string `env:"IDEMPOTENCY_STORE" default:"token" usage:"where the idempotency keys are kept" validate:"oneof=token memory"`
+	TraceFile        string `env:"TRACE_FILE" usage:"where the spans are exported: stdout or a path, off if empty"`
+	LogLevel         string `env:"LOG_LEVEL" default:"info" usage:"minimum level logged" validate:"oneof=debug info warn error"`
+	LogFormat        string `env:"LOG_FORMAT" default:"logfmt" usage:"format of the logs" validate:"oneof=logfmt json"`
+
+	DB    Downstream `prefix:"DB_"`
+	Token Downstream `prefix:"TOKEN_"`
+
+	IdempotencyTTL   time.Duration `env:"IDEMPOTENCY_TTL" default:"24h" usage:"how long the responses are replayed" validate:"positive"`
+	ShutdownTimeout  time.Duration `env:"SHUTDOWN_TIMEOUT" default:"15s" usage:"wait for the requests in flight on shutdown" validate:"positive"`
+	DrainDelay       time.Duration `env:"DRAIN_DELAY" default:"5s" usage:"wait after failing the readiness probe before shutting down"`
+	ChatPingInterval time.Duration `env:"CHAT_PING_INTERVAL" default:"30s" usage:"how often the chat clients are pinged" validate:"positive"`
+
+	RequireVerifiedEmail bool `env:"REQUIRE_VERIFIED_EMAIL" usage:"block sign ins until the email is verified"`
+	TrustProxy           bool `env:"TRUST_PROXY" usage:"read the client IP from X-Forwarded-For"`
+}
+
+// Downstream is how database-app or token-app is reached.
+type Downstream struct {
+	Host          string `env:"HOST" usage:"host of the service" validate:"required"`
+	Port          string `env:"PORT" usage:"port of the service" validate:"required,port"`
+	Instances     string `env:"INSTANCES" usage:"host:port instances to balance over"`
+	InstancesFile string `env:"INSTANCES_FILE" usage:"file with the instances, watched for changes"`
+
+	Timeout           time.Duration `env:"TIMEOUT" default:"5s" usage:"timeout of every attempt" validate:"positive"`
+	InstancesInterval time.Duration `env:"INSTANCES_INTERVAL" default:"5s" usage:"how often the file is read" validate:"positive"`
+	HealthInterval    time.Duration `env:"HEALTH_INTERVAL" default:"10s" usage:"how often the instances are probed" validate:"positive"`
+}
+
+// Load reads the configuration from the flags in args, the environment
+// through lookupEnv, the .env file and the YAML file, it fails when a value
+// is missing or malformed.
+func Load(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := &Config{}
+
+	if err := settings.Load(cfg, "app", args, lookupEnv); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// KeyValues is the effective configuration with the secrets redacted, to be
+// logged.
+func (c *Config) KeyValues() []any {
+	return settings.KeyValues(c)
+}
+
+// Addr is the host:port of the downstream.
+func (d Downstream) Addr() string {
+	return d.Host + ":" + d.Port
+}
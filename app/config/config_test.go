@@ -0,0 +1,123 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/app/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func lookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+
+		return value, ok
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	secret := filepath.Join(t.TempDir(), "secret")
+	assert.NoError(t, os.WriteFile(secret, []byte("s3cret\n"), 0o600))
+
+	envFile := filepath.Join(t.TempDir(), ".env")
+	assert.NoError(t, os.WriteFile(envFile, []byte("SECRET=\"secret\"\nTOKEN_HOST=token-app\nTOKEN_PORT=9090\n"), 0o600))
+
+	for _, tt := range []struct {
+		name   string
+		inArgs []string
+		inEnv  map[string]string
+		outCfg *config.Config
+		outErr string
+	}{
+		{
+			name:   "NoError",
+			inArgs: []string{"-env-file", envFile, "-db-timeout", "2s", "-trust-proxy"},
+			inEnv: map[string]string{
+				"DB_HOST":      "db-app",
+				"DB_PORT":      "7070",
+				"DB_INSTANCES": "db-app:7070,db-app-2:7070",
+				"SECRET_FILE":  secret,
+			},
+			outCfg: &config.Config{
+				Port:             "8080",
+				Secret:           "s3cret",
+				MailOutbox:       "outbox",
+				IdempotencyStore: "token",
+				LogLevel:         "info",
+				LogFormat:        "logfmt",
+				DB: config.Downstream{
+					Host:              "db-app",
+					Port:              "7070",
+					Instances:         "db-app:7070,db-app-2:7070",
+					Timeout:           2 * time.Second,
+					InstancesInterval: 5 * time.Second,
+					HealthInterval:    10 * time.Second,
+				},
+				Token: config.Downstream{
+					Host:              "token-app",
+					Port:              "9090",
+					Timeout:           5 * time.Second,
+					InstancesInterval: 5 * time.Second,
+					HealthInterval:    10 * time.Second,
+				},
+				IdempotencyTTL:   24 * time.Hour,
+				ShutdownTimeout:  15 * time.Second,
+				DrainDelay:       5 * time.Second,
+				ChatPingInterval: 30 * time.Second,
+				TrustProxy:       true,
+			},
+		},
+		{
+			name:   "ErrorRequired",
+			inEnv:  map[string]string{"DB_HOST": "db-app", "DB_PORT": "7070", "TOKEN_HOST": "token-app"},
+			outErr: "invalid configuration: SECRET: required; TOKEN_PORT: required",
+		},
+		{
+			name: "ErrorFormats",
+			inEnv: map[string]string{
+				"SECRET":                 "secret",
+				"GRPC_PORT":              "grpc",
+				"DB_HOST":                "db-app",
+				"DB_PORT":                "7070",
+				"TOKEN_HOST":             "token-app",
+				"TOKEN_PORT":             "9090",
+				"TOKEN_HEALTH_INTERVAL":  "0s",
+				"REQUIRE_VERIFIED_EMAIL": "maybe",
+			},
+			outErr: `invalid configuration: GRPC_PORT: invalid port "grpc"; ` +
+				`TOKEN_HEALTH_INTERVAL: must be positive, not "0s"; ` +
+				`REQUIRE_VERIFIED_EMAIL: invalid boolean "maybe"`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := config.Load(tt.inArgs, lookup(tt.inEnv))
+
+			if tt.outErr != "" {
+				assert.ErrorContains(t, err, tt.outErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.outCfg, cfg)
+			assert.Equal(t, "db-app:7070", cfg.DB.Addr())
+		})
+	}
+}
+
+func TestKeyValues(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{Secret: "s3cret", DB: config.Downstream{Host: "db-app"}}
+
+	assert.Subset(t, cfg.KeyValues(), []any{"SECRET", "[redacted]", "DB_HOST", "db-app", "TOKEN_HOST", ""})
+	assert.NotContains(t, cfg.KeyValues(), "s3cret")
+}
@@ -1,87 +1,400 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/cfabrica46/gokit-crud/app/config"
+	"github.com/cfabrica46/gokit-crud/app/pb"
 	"github.com/cfabrica46/gokit-crud/app/service"
+	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/cfabrica46/gokit-crud/platform/health"
+	"github.com/cfabrica46/gokit-crud/platform/logging"
+	"github.com/cfabrica46/gokit-crud/platform/metrics"
+	"github.com/cfabrica46/gokit-crud/platform/tracing"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/sd"
+	"github.com/go-kit/kit/sd/lb"
 	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/gorilla/mux"
-	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
 )
 
 func main() {
-	log.SetFlags(log.Lshortfile)
+	cfg, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+
+	logger := logging.NewLogger(os.Stderr, cfg.LogFormat, cfg.LogLevel)
+	logger = log.With(logger, "service", "app")
 
-	if err := godotenv.Load(".env"); err != nil {
-		log.Println(".env loaded")
+	if err != nil {
+		_ = level.Error(logger).Log("err", err)
+		os.Exit(1)
 	}
 
-	infServ := service.InfoServices{
-		DBHost:    os.Getenv("DB_HOST"),
-		DBPort:    os.Getenv("DB_PORT"),
-		TokenHost: os.Getenv("TOKEN_HOST"),
-		TokenPort: os.Getenv("TOKEN_PORT"),
-		Secret:    os.Getenv("SECRET"),
+	_ = level.Info(logger).Log(append([]any{"msg", "configuration"}, cfg.KeyValues()...)...)
+
+	stopTracing := tracing.Setup(logger, "app", cfg.TraceFile)
+	defer stopTracing(cfg.ShutdownTimeout)
+
+	runServer(logger, cfg)
+}
+
+// downstreamBalancers balance the calls to database-app and token-app over
+// their instances, keyed by the host:port the service calls.
+func downstreamBalancers(logger log.Logger, cfg *config.Config) map[string]lb.Balancer {
+	logger = log.With(logger, "component", "discovery")
+	balancers := map[string]lb.Balancer{}
+
+	for _, downstream := range []config.Downstream{cfg.DB, cfg.Token} {
+		instancer := downstreamInstancer(downstream)
+		if instancer == nil {
+			continue
+		}
+
+		instancer = service.NewHealthInstancer(
+			instancer,
+			service.HTTPProbe(&http.Client{Timeout: time.Second}, "/readyz"),
+			downstream.HealthInterval,
+			log.With(logger, "downstream", downstream.Addr()),
+		)
+
+		balancers[downstream.Addr()] = service.NewInstanceBalancer(instancer, logger)
 	}
 
-	runServer(
-		os.Getenv("PORT"),
-		&infServ,
+	return balancers
+}
+
+// newDownstreamClient makes the balanced calls to database-app and token-app
+// through a ResilientClient.
+func newDownstreamClient(cfg *config.Config, balancers map[string]lb.Balancer) service.HTTPClient {
+	return service.NewResilientClient(
+		service.NewBalancedClient(&http.Client{}, balancers),
+		map[string]service.ClientPolicy{
+			cfg.DB.Addr():    clientPolicy(cfg.DB.Timeout),
+			cfg.Token.Addr(): clientPolicy(cfg.Token.Timeout),
+		},
 	)
 }
 
-func runServer(port string, infServ *service.InfoServices) {
-	svc := service.NewService(
-		&http.Client{},
-		infServ,
+// downstreamInstancer reads the instances of a downstream from its
+// INSTANCES, a static list, or its INSTANCES_FILE, a file watched for
+// changes. Without either the downstream is called at its host and port.
+func downstreamInstancer(downstream config.Downstream) sd.Instancer {
+	if downstream.InstancesFile != "" {
+		return service.NewFileInstancer(downstream.InstancesFile, downstream.InstancesInterval)
+	}
+
+	if downstream.Instances != "" {
+		return sd.FixedInstancer(service.ParseInstances(downstream.Instances))
+	}
+
+	return nil
+}
+
+// clientPolicy is the default policy with the timeout of the downstream.
+func clientPolicy(timeout time.Duration) service.ClientPolicy {
+	policy := service.DefaultClientPolicy()
+	policy.Timeout = timeout
+
+	return policy
+}
+
+// idempotencyStore keeps the idempotency keys in token-app unless
+// IDEMPOTENCY_STORE is "memory", which only works with a single gateway.
+func idempotencyStore(client service.HTTPClient, cfg *config.Config, infServ *service.InfoServices) service.IdempotencyStore {
+	if cfg.IdempotencyStore == "memory" {
+		return service.NewMemoryIdempotencyStore()
+	}
+
+	return service.NewTokenIdempotencyStore(client, infServ)
+}
+
+// serveGRPC serves the endpoints over gRPC on GRPC_PORT until ctx is done, it
+// is off without it.
+func serveGRPC(ctx context.Context, logger log.Logger, cfg *config.Config, endpoints service.GRPCEndpoints) {
+	if cfg.GRPCPort == "" {
+		return
+	}
+
+	listener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		_ = level.Error(logger).Log("err", err)
+
+		return
+	}
+
+	server := grpc.NewServer()
+	pb.RegisterAppServer(server, service.NewGRPCServer(endpoints, logger))
+
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		<-ctx.Done()
+
+		// GracefulStop waits for the calls in flight, those left after the
+		// timeout are cancelled.
+		timer := time.AfterFunc(cfg.ShutdownTimeout, server.Stop)
+		defer timer.Stop()
+
+		server.GracefulStop()
+	}()
+
+	_ = level.Info(logger).Log("msg", "listening", "addr", ":"+cfg.GRPCPort)
+
+	if err = server.Serve(listener); err != nil {
+		_ = level.Error(logger).Log("err", err)
+	}
+
+	<-stopped
+}
+
+// runServer serves HTTP and gRPC until SIGINT or SIGTERM, then it stops being
+// ready, waits DRAIN_DELAY for the traffic to move away and up to
+// SHUTDOWN_TIMEOUT for the requests in flight.
+func runServer(logger log.Logger, cfg *config.Config) {
+	infServ := &service.InfoServices{
+		DBHost:               cfg.DB.Host,
+		DBPort:               cfg.DB.Port,
+		TokenHost:            cfg.Token.Host,
+		TokenPort:            cfg.Token.Port,
+		Secret:               cfg.Secret,
+		RequireVerifiedEmail: cfg.RequireVerifiedEmail,
+	}
+
+	reg := prometheus.DefaultRegisterer
+
+	balancers := downstreamBalancers(logger, cfg)
+
+	client := service.NewInstrumentedClient(newDownstreamClient(cfg, balancers), service.NewDownstreamMetrics(reg))
+	svc := service.NewService(client, service.NewOutboxSender(cfg.MailOutbox), infServ)
+
+	// The gateway is ready while both downstreams are. The probes skip the
+	// retries and circuit breakers of client, they would hide the state of
+	// the downstreams and count as their failures.
+	probeClient := service.NewBalancedClient(&http.Client{Timeout: time.Second}, balancers)
+	readiness := health.NewReadiness(logger, 2*time.Second, map[string]health.Check{
+		"database-app": service.DownstreamCheck(probeClient, "http://"+cfg.DB.Addr()+"/readyz"),
+		"token-app":    service.DownstreamCheck(probeClient, "http://"+cfg.Token.Addr()+"/readyz"),
+	})
+
+	chat := service.NewChatHub(svc.ChatUsername, cfg.ChatPingInterval, log.With(logger, "component", "chat"))
+
+	idempotent := service.Idempotency(
+		idempotencyStore(client, cfg, infServ),
+		cfg.IdempotencyTTL,
+		log.With(logger, "component", "idempotency"),
 	)
 
+	router, endpoints := newRouter(logger, svc, cfg, readiness, chat, idempotent, reg)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	grpcStopped := make(chan struct{})
+
+	go func() {
+		defer close(grpcStopped)
+
+		serveGRPC(ctx, log.With(logger, "transport", "grpc"), cfg, endpoints)
+	}()
+
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: router, ReadHeaderTimeout: 10 * time.Second}
+
+	// Shutdown does not wait for the WebSocket connections, they are closed.
+	srv.RegisterOnShutdown(chat.Close)
+
+	_ = level.Info(logger).Log("msg", "listening", "addr", srv.Addr)
+
+	if err := health.Serve(ctx, srv, readiness, cfg.DrainDelay, cfg.ShutdownTimeout); err != nil {
+		_ = level.Error(logger).Log("err", err)
+	}
+
+	stop()
+	<-grpcStopped
+
+	_ = level.Info(logger).Log("msg", "stopped")
+}
+
+// newRouter routes the endpoints of svc, the probes and the chat, it returns
+// the endpoints served over gRPC too. idempotent wraps the routes that are
+// safe to replay, not the ones that answer with tokens: their responses
+// would be stored and handed to whoever sends the same key again.
+func newRouter(
+	logger log.Logger,
+	svc *service.Service,
+	cfg *config.Config,
+	readiness *health.Readiness,
+	chat *service.ChatHub,
+	idempotent func(http.Handler) http.Handler,
+	reg prometheus.Registerer,
+) (*mux.Router, service.GRPCEndpoints) {
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(service.EncodeError),
+	}
+	options = append(options, tracing.ServerTracing()...)
+	options = append(options, service.ServerLogging(logger)...)
+
+	endpointMetrics := metrics.NewEndpointMetrics(reg, service.MetricsNamespace)
+	instrument := func(method string) endpoint.Middleware {
+		return endpoint.Chain(
+			service.LoggingMiddleware(logger, method),
+			metrics.InstrumentingMiddleware(endpointMetrics, method),
+		)
+	}
+
+	requireAdmin := service.MakeRequireRoleMiddleware(svc, dbapp.RoleAdmin)
+
+	// endpoints are served over gRPC too.
+	endpoints := service.GRPCEndpoints{
+		SignUp:        instrument("SignUp")(service.MakeSignUpEndpoint(svc)),
+		SignIn:        instrument("SignIn")(service.MakeSignInEndpoint(svc)),
+		LogOut:        instrument("LogOut")(service.MakeLogOutEndpoint(svc)),
+		GetAllUsers:   instrument("GetAllUsers")(requireAdmin(service.MakeGetAllUsersEndpoint(svc))),
+		Profile:       instrument("Profile")(service.MakeProfileEndpoint(svc)),
+		DeleteAccount: instrument("DeleteAccount")(service.MakeDeleteAccountEndpoint(svc)),
+	}
+
 	getSignUpHandler := httptransport.NewServer(
-		service.MakeSignUpEndpoint(svc),
+		endpoints.SignUp,
 		service.DecodeRequestWithBody(service.UsernamePasswordEmailRequest{}),
 		service.EncodeResponse,
+		options...,
 	)
 
 	getSignInHandler := httptransport.NewServer(
-		service.MakeSignInEndpoint(svc),
+		endpoints.SignIn,
 		service.DecodeRequestWithBody(service.UsernamePasswordRequest{}),
 		service.EncodeResponse,
+		append(options, httptransport.ServerBefore(service.ClientIPToContext(cfg.TrustProxy)))...,
 	)
 
 	getLogOutHandler := httptransport.NewServer(
-		service.MakeLogOutEndpoint(svc),
+		endpoints.LogOut,
 		service.DecodeRequestWithHeader(service.TokenRequest{}),
 		service.EncodeResponse,
+		options...,
 	)
 
+	adminOptions := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(service.EncodeError),
+		httptransport.ServerBefore(service.TokenToContext()),
+	}
+	adminOptions = append(adminOptions, tracing.ServerTracing()...)
+	adminOptions = append(adminOptions, service.ServerLogging(logger)...)
+
 	getAllUsersHandler := httptransport.NewServer(
-		service.MakeGetAllUsersEndpoint(svc),
-		service.DecodeRequestWithoutBody(),
+		endpoints.GetAllUsers,
+		service.DecodeRequestWithFields(service.UsersQueryRequest{}),
 		service.EncodeResponse,
+		adminOptions...,
 	)
 
 	getProfileHandler := httptransport.NewServer(
-		service.MakeProfileEndpoint(svc),
-		service.DecodeRequestWithHeader(service.TokenRequest{}),
+		endpoints.Profile,
+		service.DecodeRequestWithFields(service.TokenFieldsRequest{}),
 		service.EncodeResponse,
+		options...,
 	)
 
 	getDeleteAccountHandler := httptransport.NewServer(
-		service.MakeDeleteAccountEndpoint(svc),
+		endpoints.DeleteAccount,
 		service.DecodeRequestWithHeader(service.TokenRequest{}),
 		service.EncodeResponse,
+		options...,
+	)
+
+	getUpdateProfileHandler := httptransport.NewServer(
+		instrument("UpdateProfile")(service.MakeUpdateProfileEndpoint(svc)),
+		service.DecodeRequestWithHeaderAndBody(service.TokenUsernameEmailRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	getChangePasswordHandler := httptransport.NewServer(
+		instrument("ChangePassword")(service.MakeChangePasswordEndpoint(svc)),
+		service.DecodeRequestWithHeaderAndBody(service.TokenOldNewPasswordRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	getForgotPasswordHandler := httptransport.NewServer(
+		instrument("ForgotPassword")(service.MakeForgotPasswordEndpoint(svc)),
+		service.DecodeRequestWithBody(service.EmailRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	getResetPasswordHandler := httptransport.NewServer(
+		instrument("ResetPassword")(service.MakeResetPasswordEndpoint(svc)),
+		service.DecodeRequestWithBody(service.TokenPasswordRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	getVerifyEmailHandler := httptransport.NewServer(
+		instrument("VerifyEmail")(service.MakeVerifyEmailEndpoint(svc)),
+		service.DecodeRequestWithQuery(service.TokenRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	getRefreshTokenHandler := httptransport.NewServer(
+		instrument("RefreshToken")(service.MakeRefreshTokenEndpoint(svc)),
+		service.DecodeRequestWithBody(service.RefreshTokenRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	getDeleteUserHandler := httptransport.NewServer(
+		instrument("DeleteUser")(requireAdmin(service.MakeDeleteUserEndpoint(svc))),
+		service.DecodeRequestWithBody(service.IDRequest{}),
+		service.EncodeResponse,
+		adminOptions...,
+	)
+
+	getSuspendUserHandler := httptransport.NewServer(
+		instrument("SuspendUser")(requireAdmin(service.MakeSuspendUserEndpoint(svc))),
+		service.DecodeRequestWithBody(service.IDSuspendedRequest{}),
+		service.EncodeResponse,
+		adminOptions...,
 	)
 
 	router := mux.NewRouter()
-	router.Methods(http.MethodPost).Path("/signup").Handler(getSignUpHandler)
+	router.Methods(http.MethodGet).Path("/healthz").Handler(health.LivenessHandler())
+	router.Methods(http.MethodGet).Path("/readyz").Handler(readiness)
+	router.Methods(http.MethodGet).Path("/metrics").Handler(promhttp.Handler())
+	router.Methods(http.MethodGet).Path("/openapi.json").Handler(service.OpenAPIHandler())
+	router.Methods(http.MethodPost).Path("/signup").Handler(idempotent(getSignUpHandler))
 	router.Methods(http.MethodPost).Path("/signin").Handler(getSignInHandler)
 	router.Methods(http.MethodPost).Path("/logout").Handler(getLogOutHandler)
 	router.Methods(http.MethodGet).Path("/users").Handler(getAllUsersHandler)
 	router.Methods(http.MethodPost).Path("/profile").Handler(getProfileHandler)
-	router.Methods(http.MethodDelete).Path("/profile").Handler(getDeleteAccountHandler)
+	router.Methods(http.MethodDelete).Path("/profile").Handler(idempotent(getDeleteAccountHandler))
+	router.Methods(http.MethodPut).Path("/profile").Handler(getUpdateProfileHandler)
+	router.Methods(http.MethodPost).Path("/profile/password").Handler(getChangePasswordHandler)
+	router.Methods(http.MethodPost).Path("/password/forgot").Handler(idempotent(getForgotPasswordHandler))
+	router.Methods(http.MethodPost).Path("/password/reset").Handler(getResetPasswordHandler)
+	router.Methods(http.MethodGet).Path("/verify-email").Handler(getVerifyEmailHandler)
+	router.Methods(http.MethodPost).Path("/token/refresh").Handler(getRefreshTokenHandler)
+	router.Methods(http.MethodDelete).Path("/users").Handler(idempotent(getDeleteUserHandler))
+	router.Methods(http.MethodPatch).Path("/users/suspended").Handler(idempotent(getSuspendUserHandler))
+	router.Methods(http.MethodGet).Path("/api/v1/chat").Handler(chat)
 
-	log.Println("ListenAndServe on localhost:" + os.Getenv("PORT"))
-	log.Println(http.ListenAndServe(":"+port, router))
+	return router, endpoints
 }
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/app/config"
+	"github.com/cfabrica46/gokit-crud/app/service"
+	"github.com/cfabrica46/gokit-crud/platform/health"
+	"github.com/cfabrica46/gokit-crud/platform/logging"
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRoutesMatchOpenAPI fails when a route is added, moved or removed
+// without documenting it in service.Operations.
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	svc := service.NewService(service.NewMockClient(nil), nil, &service.InfoServices{})
+
+	router, _ := newRouter(
+		log.NewNopLogger(),
+		svc,
+		&config.Config{},
+		health.NewReadiness(log.NewNopLogger(), time.Second, nil),
+		service.NewChatHub(svc.ChatUsername, time.Second, log.NewNopLogger()),
+		service.Idempotency(service.NewMemoryIdempotencyStore(), time.Hour, log.NewNopLogger()),
+		prometheus.NewRegistry(),
+	)
+
+	var routes []string
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+
+		// the chat is a WebSocket, OpenAPI can't describe it.
+		switch path {
+		case "/healthz", "/readyz", "/metrics", "/openapi.json", "/api/v1/chat":
+			return nil
+		}
+
+		for _, method := range methods {
+			routes = append(routes, method+" "+path)
+		}
+
+		return nil
+	})
+	assert.NoError(t, err)
+
+	documented := make([]string, 0, len(service.Operations))
+	for _, op := range service.Operations {
+		documented = append(documented, op.Method+" "+op.Path)
+	}
+
+	sort.Strings(routes)
+	sort.Strings(documented)
+
+	assert.Equal(t, documented, routes)
+}
+
+// TestAdminRoutesAreLogged checks that the admin routes echo the request ID
+// and are logged like the others.
+func TestAdminRoutesAreLogged(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		method, path, body string
+	}{
+		{http.MethodGet, "/users", ""},
+		{http.MethodDelete, "/users", `{"id":1}`},
+		{http.MethodPatch, "/users/suspended", `{"id":1,"suspended":true}`},
+	} {
+		tt := tt
+		t.Run(tt.method+tt.path, func(t *testing.T) {
+			t.Parallel()
+
+			var logs bytes.Buffer
+
+			svc := service.NewService(service.NewMockClient(nil), nil, &service.InfoServices{})
+			router, _ := newRouter(
+				log.NewLogfmtLogger(&logs),
+				svc,
+				&config.Config{},
+				health.NewReadiness(log.NewNopLogger(), time.Second, nil),
+				service.NewChatHub(svc.ChatUsername, time.Second, log.NewNopLogger()),
+				service.Idempotency(service.NewMemoryIdempotencyStore(), time.Hour, log.NewNopLogger()),
+				prometheus.NewRegistry(),
+			)
+
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			r.Header.Set(logging.RequestIDHeader, "request")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, "request", w.Header().Get(logging.RequestIDHeader))
+			assert.Contains(t, logs.String(), "transport=http method="+tt.method+" path="+tt.path+" code=401")
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/kit/sd"
+	"github.com/go-kit/kit/sd/lb"
+	"github.com/go-kit/log"
+)
+
+// BalancedClient is an HTTPClient that sends the calls to a downstream
+// host:port to one of its instances.
+type BalancedClient struct {
+	next      HTTPClient
+	balancers map[string]lb.Balancer
+}
+
+// NewBalancedClient wraps next, balancers are keyed by the host:port the
+// service calls, other hosts are called as they are.
+func NewBalancedClient(next HTTPClient, balancers map[string]lb.Balancer) *BalancedClient {
+	return &BalancedClient{next: next, balancers: balancers}
+}
+
+// NewInstanceBalancer round robins over the instances of instancer.
+func NewInstanceBalancer(instancer sd.Instancer, logger log.Logger) lb.Balancer {
+	return lb.NewRoundRobin(sd.NewEndpointer(instancer, instanceFactory, logger))
+}
+
+// instanceFactory makes endpoints that only return their instance, the call
+// itself is still made by the HTTPClient.
+func instanceFactory(instance string) (endpoint.Endpoint, io.Closer, error) {
+	return func(context.Context, any) (any, error) {
+		return instance, nil
+	}, nil, nil
+}
+
+// Do ...
+func (c *BalancedClient) Do(req *http.Request) (*http.Response, error) {
+	balancer, ok := c.balancers[req.URL.Host]
+	if !ok {
+		return c.next.Do(req)
+	}
+
+	e, err := balancer.Endpoint()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", req.URL.Host, err)
+	}
+
+	instance, err := e(req.Context(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	balanced := req.Clone(req.Context())
+	balanced.URL.Host, _ = instance.(string)
+	balanced.Host = ""
+
+	return c.next.Do(balanced)
+}
@@ -0,0 +1,112 @@
+package service_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	"github.com/go-kit/kit/sd"
+	"github.com/go-kit/kit/sd/lb"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBalancedClient(t *testing.T) {
+	t.Parallel()
+
+	var hosts []string
+
+	mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
+		hosts = append(hosts, req.URL.Host)
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{}`)),
+		}, nil
+	})
+
+	client := service.NewBalancedClient(mock, map[string]lb.Balancer{
+		downstreamHost: lb.NewRoundRobin(sd.FixedEndpointer{}),
+	})
+
+	for _, tt := range []struct {
+		name    string
+		inHost  string
+		outHost string
+		outErr  error
+	}{
+		{name: "NotBalanced", inHost: "token-app:9090", outHost: "token-app:9090"},
+		{name: "NoInstances", inHost: downstreamHost, outErr: lb.ErrNoEndpoints},
+	} {
+		hosts = nil
+
+		req, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, "http://"+tt.inHost+"/users", nil)
+		assert.NoError(t, err, tt.name)
+
+		resp, err := client.Do(req)
+		if tt.outErr != nil {
+			assert.ErrorIs(t, err, tt.outErr, tt.name)
+			assert.Empty(t, hosts, tt.name)
+
+			continue
+		}
+
+		assert.NoError(t, err, tt.name)
+		assert.NoError(t, resp.Body.Close(), tt.name)
+		assert.Equal(t, []string{tt.outHost}, hosts, tt.name)
+		assert.Equal(t, tt.inHost, req.URL.Host, tt.name)
+	}
+}
+
+func TestBalancedClientRoundRobin(t *testing.T) {
+	t.Parallel()
+
+	var hosts []string
+
+	mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
+		hosts = append(hosts, req.URL.Host)
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{}`)),
+		}, nil
+	})
+
+	client := service.NewBalancedClient(mock, map[string]lb.Balancer{
+		downstreamHost: service.NewInstanceBalancer(
+			sd.FixedInstancer{"db-1:7070", "db-2:7070"},
+			log.NewNopLogger(),
+		),
+	})
+
+	do := func() error {
+		req, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, "http://"+downstreamHost+"/users", nil)
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+
+		return resp.Body.Close()
+	}
+
+	// the endpointer picks the instances up in the background.
+	assert.Eventually(t, func() bool {
+		return do() == nil
+	}, time.Second, time.Millisecond)
+
+	assert.NoError(t, do())
+	assert.NoError(t, do())
+
+	assert.Len(t, hosts, 3)
+	assert.NotEqual(t, hosts[0], hosts[1])
+	assert.Equal(t, hosts[0], hosts[2])
+	assert.ElementsMatch(t, []string{"db-1:7070", "db-2:7070"}, hosts[:2])
+}
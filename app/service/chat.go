@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"golang.org/x/net/websocket"
+)
+
+// Bodies of the status messages, the chat client reacts to them.
+const (
+	ChatJoined = "has joined the chat"
+	ChatLeft   = "has gone out to the chat"
+
+	chatMaxMessageBytes = 4 << 10
+	chatSendBuffer      = 32
+	chatAuthTimeout     = 10 * time.Second
+	chatWriteTimeout    = 10 * time.Second
+)
+
+// ChatMessage is sent by the clients, every message carries the token of its
+// owner and the first one only authenticates the connection.
+type ChatMessage struct {
+	Token string `json:"token"`
+	Body  string `json:"body"`
+}
+
+// ChatBody ...
+type ChatBody struct {
+	Body string `json:"body"`
+}
+
+// ChatEvent is sent to the clients: a message of Owner, a status message
+// about Owner or the users connected.
+type ChatEvent struct {
+	Msg             *ChatBody `json:"msg,omitempty"`
+	Owner           string    `json:"owner,omitempty"`
+	UsersConnected  []string  `json:"usersConnected,omitempty"`
+	IsStatusMessage bool      `json:"isStatusMessage,omitempty"`
+}
+
+// ChatAuthenticator returns the username of the owner of token.
+type ChatAuthenticator func(ctx context.Context, token string) (string, error)
+
+// chatClient is a connection to the hub, its events are written by its own
+// goroutine so that a slow client does not hold the others back.
+type chatClient struct {
+	conn     *websocket.Conn
+	events   chan ChatEvent
+	username string
+}
+
+// ChatHub is a chat room over WebSocket: it broadcasts the messages of the
+// clients to all of them, tells them who joins and leaves with the users
+// connected and sends them a ping frame every interval, which keeps idle
+// connections open and closes the ones that can no longer be written.
+type ChatHub struct {
+	authenticate ChatAuthenticator
+	logger       log.Logger
+	clients      map[*chatClient]struct{}
+	interval     time.Duration
+	mu           sync.Mutex
+}
+
+// NewChatHub ...
+func NewChatHub(authenticate ChatAuthenticator, interval time.Duration, logger log.Logger) *ChatHub {
+	return &ChatHub{
+		authenticate: authenticate,
+		logger:       logger,
+		clients:      map[*chatClient]struct{}{},
+		interval:     interval,
+	}
+}
+
+// ServeHTTP upgrades the request to WebSocket. Any origin is accepted, the
+// clients authenticate with the token they send and not with cookies.
+func (h *ChatHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	websocket.Server{Handler: h.serve}.ServeHTTP(w, r)
+}
+
+// Close disconnects every client.
+func (h *ChatHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.clients {
+		_ = client.conn.Close()
+	}
+}
+
+func (h *ChatHub) serve(conn *websocket.Conn) {
+	conn.MaxPayloadBytes = chatMaxMessageBytes
+
+	username, err := h.handshake(conn)
+	if err != nil {
+		_ = level.Debug(h.logger).Log("msg", "chat connection rejected", "err", err)
+
+		return
+	}
+
+	client := &chatClient{conn: conn, events: make(chan ChatEvent, chatSendBuffer), username: username}
+
+	written := make(chan struct{})
+
+	go func() {
+		defer close(written)
+
+		h.write(client)
+	}()
+
+	h.join(client)
+
+	for {
+		var message ChatMessage
+
+		if err = websocket.JSON.Receive(conn, &message); err != nil {
+			break
+		}
+
+		if message.Body == "" {
+			continue
+		}
+
+		h.broadcast(ChatEvent{Owner: username, Msg: &ChatBody{Body: message.Body}})
+	}
+
+	h.leave(client)
+	<-written
+}
+
+// handshake authenticates the connection with the token of its first
+// message.
+func (h *ChatHub) handshake(conn *websocket.Conn) (string, error) {
+	if err := conn.SetReadDeadline(time.Now().Add(chatAuthTimeout)); err != nil {
+		return "", err
+	}
+
+	var message ChatMessage
+
+	if err := websocket.JSON.Receive(conn, &message); err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(conn.Request().Context(), chatAuthTimeout)
+	defer cancel()
+
+	username, err := h.authenticate(ctx, message.Token)
+	if err != nil {
+		return "", err
+	}
+
+	if err = conn.SetReadDeadline(time.Time{}); err != nil {
+		return "", err
+	}
+
+	return username, nil
+}
+
+// write sends the events of client and the pings until its events are
+// closed, a failed write closes the connection.
+func (h *ChatHub) write(client *chatClient) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	failed := false
+
+	for {
+		var err error
+
+		select {
+		case event, ok := <-client.events:
+			if !ok {
+				return
+			}
+
+			if !failed {
+				err = sendChatEvent(client.conn, event)
+			}
+		case <-ticker.C:
+			if !failed {
+				err = pingChat(client.conn)
+			}
+		}
+
+		if err != nil {
+			failed = true
+			_ = client.conn.Close()
+		}
+	}
+}
+
+func sendChatEvent(conn *websocket.Conn, event ChatEvent) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(chatWriteTimeout)); err != nil {
+		return err
+	}
+
+	return websocket.JSON.Send(conn, event)
+}
+
+// pingChat sends a ping frame, the browsers answer it with a pong that the
+// connection discards.
+func pingChat(conn *websocket.Conn) error {
+	if err := conn.SetWriteDeadline(time.Now().Add(chatWriteTimeout)); err != nil {
+		return err
+	}
+
+	// Write sends a frame of PayloadType, which only write uses.
+	conn.PayloadType = websocket.PingFrame
+	_, err := conn.Write(nil)
+
+	return err
+}
+
+// join tells the others that client joined and then every client the users
+// connected, the list sent last is the one the clients keep.
+func (h *ChatHub) join(client *chatClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.clients[client] = struct{}{}
+
+	h.broadcastLocked(ChatEvent{Owner: client.username, IsStatusMessage: true, Msg: &ChatBody{Body: ChatJoined}}, client)
+	h.broadcastLocked(ChatEvent{UsersConnected: h.usersLocked()}, nil)
+
+	_ = level.Info(h.logger).Log("msg", "joined the chat", "username", client.username, "connected", len(h.clients))
+}
+
+// leave removes client and tells the others it left and the users still
+// connected.
+func (h *ChatHub) leave(client *chatClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.clients, client)
+	close(client.events)
+
+	h.broadcastLocked(ChatEvent{Owner: client.username, IsStatusMessage: true, Msg: &ChatBody{Body: ChatLeft}}, nil)
+
+	h.broadcastLocked(ChatEvent{UsersConnected: h.usersLocked()}, nil)
+
+	_ = level.Info(h.logger).Log("msg", "left the chat", "username", client.username, "connected", len(h.clients))
+}
+
+// usersLocked are the usernames of the clients, sorted.
+func (h *ChatHub) usersLocked() []string {
+	users := make([]string, 0, len(h.clients))
+	for client := range h.clients {
+		users = append(users, client.username)
+	}
+
+	sort.Strings(users)
+
+	return users
+}
+
+// broadcast sends event to every client.
+func (h *ChatHub) broadcast(event ChatEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.broadcastLocked(event, nil)
+}
+
+// broadcastLocked sends event to every client but except.
+func (h *ChatHub) broadcastLocked(event ChatEvent, except *chatClient) {
+	for client := range h.clients {
+		if client != except {
+			h.deliver(client, event)
+		}
+	}
+}
+
+// deliver queues event for client, a client too slow to keep up with the
+// chat is disconnected.
+func (h *ChatHub) deliver(client *chatClient, event ChatEvent) {
+	select {
+	case client.events <- event:
+	default:
+		_ = level.Warn(h.logger).Log("msg", "chat client too slow", "username", client.username)
+		_ = client.conn.Close()
+	}
+}
+
+// ChatUsername authenticates the chat clients: it returns the username of
+// token while the token is active.
+func (s *Service) ChatUsername(ctx context.Context, token string) (string, error) {
+	claims, err := s.checkAndExtractToken(ctx, token)
+	if err != nil {
+		return "", err
+	}
+
+	return claims.Username, nil
+}
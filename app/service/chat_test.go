@@ -0,0 +1,235 @@
+package service_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+func newChat(t *testing.T, interval time.Duration) *httptest.Server {
+	t.Helper()
+
+	hub := service.NewChatHub(
+		func(_ context.Context, token string) (string, error) {
+			if !strings.HasSuffix(token, "-token") {
+				return "", service.ErrTokenNotValid
+			}
+
+			return strings.TrimSuffix(token, "-token"), nil
+		},
+		interval,
+		log.NewNopLogger(),
+	)
+
+	server := httptest.NewServer(hub)
+
+	t.Cleanup(func() {
+		hub.Close()
+		server.Close()
+	})
+
+	return server
+}
+
+func dialChat(t *testing.T, server *httptest.Server, token string) *websocket.Conn {
+	t.Helper()
+
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), "", server.URL)
+	assert.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	assert.NoError(t, websocket.JSON.Send(conn, service.ChatMessage{Token: token}))
+
+	return conn
+}
+
+func receiveChat(t *testing.T, conn *websocket.Conn) service.ChatEvent {
+	t.Helper()
+
+	var event service.ChatEvent
+
+	assert.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	assert.NoError(t, websocket.JSON.Receive(conn, &event))
+
+	return event
+}
+
+func TestChatHub(t *testing.T) {
+	t.Parallel()
+
+	server := newChat(t, time.Hour)
+
+	alice := dialChat(t, server, "alice-token")
+	assert.Equal(t, service.ChatEvent{UsersConnected: []string{"alice"}}, receiveChat(t, alice))
+
+	bob := dialChat(t, server, "bob-token")
+	assert.Equal(t, service.ChatEvent{UsersConnected: []string{"alice", "bob"}}, receiveChat(t, bob))
+	assert.Equal(t, service.ChatEvent{
+		Owner:           "bob",
+		IsStatusMessage: true,
+		Msg:             &service.ChatBody{Body: service.ChatJoined},
+	}, receiveChat(t, alice))
+	assert.Equal(t, service.ChatEvent{UsersConnected: []string{"alice", "bob"}}, receiveChat(t, alice))
+
+	assert.NoError(t, websocket.JSON.Send(alice, service.ChatMessage{Token: "alice-token", Body: "hi"}))
+
+	for _, conn := range []*websocket.Conn{alice, bob} {
+		assert.Equal(t, service.ChatEvent{Owner: "alice", Msg: &service.ChatBody{Body: "hi"}}, receiveChat(t, conn))
+	}
+
+	assert.NoError(t, bob.Close())
+	assert.Equal(t, service.ChatEvent{
+		Owner:           "bob",
+		IsStatusMessage: true,
+		Msg:             &service.ChatBody{Body: service.ChatLeft},
+	}, receiveChat(t, alice))
+	assert.Equal(t, service.ChatEvent{UsersConnected: []string{"alice"}}, receiveChat(t, alice))
+}
+
+// readFrame reads the opcode and the payload of a frame sent by the server,
+// which does not mask them.
+func readFrame(t *testing.T, r *bufio.Reader) (byte, []byte) {
+	t.Helper()
+
+	header := make([]byte, 2)
+	_, err := io.ReadFull(r, header)
+	assert.NoError(t, err)
+
+	length := int(header[1] & 0x7f)
+	if length == 126 {
+		extended := make([]byte, 2)
+		_, err = io.ReadFull(r, extended)
+		assert.NoError(t, err)
+
+		length = int(binary.BigEndian.Uint16(extended))
+	}
+
+	payload := make([]byte, length)
+	_, err = io.ReadFull(r, payload)
+	assert.NoError(t, err)
+
+	return header[0] & 0x0f, payload
+}
+
+func TestChatHubPing(t *testing.T) {
+	t.Parallel()
+
+	server := newChat(t, 10*time.Millisecond)
+
+	// websocket.Conn answers the pings on its own, the frames are read raw.
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	assert.NoError(t, err)
+
+	t.Cleanup(func() { _ = conn.Close() })
+
+	assert.NoError(t, conn.SetDeadline(time.Now().Add(time.Second)))
+
+	_, err = fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\nOrigin: %s\r\n\r\n",
+		server.Listener.Addr(), server.URL)
+	assert.NoError(t, err)
+
+	r := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(r, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	// A masked text frame, with a zero mask the payload is sent as is.
+	token := []byte(`{"token":"alice-token"}`)
+	_, err = conn.Write(append([]byte{0x81, 0x80 | byte(len(token)), 0, 0, 0, 0}, token...))
+	assert.NoError(t, err)
+
+	opcode, payload := readFrame(t, r)
+	assert.Equal(t, byte(websocket.TextFrame), opcode)
+	assert.JSONEq(t, `{"usersConnected":["alice"]}`, string(payload))
+
+	opcode, payload = readFrame(t, r)
+	assert.Equal(t, byte(websocket.PingFrame), opcode)
+	assert.Empty(t, payload)
+}
+
+func TestChatHubErrorToken(t *testing.T) {
+	t.Parallel()
+
+	server := newChat(t, time.Hour)
+
+	conn := dialChat(t, server, "invalid")
+
+	var event service.ChatEvent
+
+	assert.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	assert.Error(t, websocket.JSON.Receive(conn, &event))
+}
+
+func TestChatUsername(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name        string
+		inCheck     bool
+		outUsername string
+		outErr      error
+	}{
+		{
+			name:        "NoError",
+			inCheck:     true,
+			outUsername: "username",
+		},
+		{
+			name:   "ErrorTokenNotValid",
+			outErr: service.ErrTokenNotValid,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var paths []string
+
+			mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
+				paths = append(paths, req.URL.Path)
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body: io.NopCloser(strings.NewReader(fmt.Sprintf(
+						`{"id":1,"username":"username","email":"email@email.com","check":%t}`,
+						tt.inCheck,
+					))),
+				}, nil
+			})
+
+			svc := service.NewService(mock, service.NewMockMailSender(nil), &service.InfoServices{
+				TokenHost: tokenHostTest,
+				TokenPort: portTest,
+				Secret:    secretTest,
+			})
+
+			username, err := svc.ChatUsername(context.TODO(), "token")
+
+			assert.Equal(t, tt.outUsername, username)
+			assert.ErrorIs(t, err, tt.outErr)
+
+			if tt.outErr == nil {
+				assert.Equal(t, []string{"/check", "/extract"}, paths)
+			}
+		})
+	}
+}
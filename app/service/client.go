@@ -0,0 +1,267 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/sony/gobreaker"
+)
+
+// maxDrainBytes is how much of an unread body is drained so that its
+// connection goes back to the pool, longer bodies close the connection.
+const maxDrainBytes int64 = 64 << 10
+
+var errDownstreamFailure = errors.New("downstream failure")
+
+// ClientPolicy is how the calls to one downstream service are made.
+type ClientPolicy struct {
+	// Timeout bounds every attempt, including reading the response body.
+	Timeout time.Duration
+
+	// Retries is how many more attempts idempotent calls get after a
+	// network error or a 502, 503 or 504.
+	Retries int
+
+	// Backoff is the base of the exponential backoff between retries, every
+	// wait is a random duration up to Backoff * 2^(attempt-1).
+	Backoff time.Duration
+
+	// BreakerFailures consecutive failures open the circuit breaker.
+	BreakerFailures uint32
+
+	// BreakerCooldown is how long the breaker stays open before a probe call
+	// is let through.
+	BreakerCooldown time.Duration
+}
+
+// DefaultClientPolicy ...
+func DefaultClientPolicy() ClientPolicy {
+	return ClientPolicy{
+		Timeout:         5 * time.Second,
+		Retries:         2,
+		Backoff:         100 * time.Millisecond,
+		BreakerFailures: 5,
+		BreakerCooldown: 30 * time.Second,
+	}
+}
+
+// ResilientClient is an HTTPClient that applies a ClientPolicy per
+// downstream host: a timeout per attempt, jittered retries of idempotent
+// calls and a circuit breaker.
+type ResilientClient struct {
+	next        HTTPClient
+	fallback    ClientPolicy
+	downstreams map[string]*downstream
+	mu          sync.Mutex
+}
+
+type downstream struct {
+	breaker *gobreaker.CircuitBreaker
+	policy  ClientPolicy
+}
+
+// NewResilientClient wraps next, policies are keyed by the host:port of the
+// downstream URLs and hosts without one get DefaultClientPolicy.
+func NewResilientClient(next HTTPClient, policies map[string]ClientPolicy) *ResilientClient {
+	c := &ResilientClient{
+		next:        next,
+		fallback:    DefaultClientPolicy(),
+		downstreams: make(map[string]*downstream, len(policies)),
+	}
+
+	for host, policy := range policies {
+		c.downstreams[host] = newDownstream(host, policy)
+	}
+
+	return c
+}
+
+// Do ...
+func (c *ResilientClient) Do(req *http.Request) (*http.Response, error) {
+	d := c.downstream(req.URL.Host)
+
+	attempts := 1
+	if isIdempotent(req.Method) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil) {
+		attempts += d.policy.Retries
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := d.do(c.next, req)
+		if attempt == attempts || !isRetryable(resp, err) {
+			return resp, err
+		}
+
+		drainAndClose(resp)
+
+		if err = sleep(req.Context(), backoff(d.policy.Backoff, attempt)); err != nil {
+			return nil, err
+		}
+
+		if req, err = rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *ResilientClient) downstream(host string) *downstream {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	d, ok := c.downstreams[host]
+	if !ok {
+		d = newDownstream(host, c.fallback)
+		c.downstreams[host] = d
+	}
+
+	return d
+}
+
+func newDownstream(host string, policy ClientPolicy) *downstream {
+	return &downstream{
+		policy: policy,
+		breaker: gobreaker.NewCircuitBreaker(gobreaker.Settings{
+			Name:    host,
+			Timeout: policy.BreakerCooldown,
+			ReadyToTrip: func(counts gobreaker.Counts) bool {
+				return counts.ConsecutiveFailures >= policy.BreakerFailures
+			},
+		}),
+	}
+}
+
+// do makes one attempt through the breaker. Network errors and 5xx count as
+// failures, a caller that gives up doesn't.
+func (d *downstream) do(next HTTPClient, req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), d.policy.Timeout)
+
+	var (
+		resp    *http.Response
+		callErr error
+	)
+
+	_, err := d.breaker.Execute(func() (any, error) {
+		resp, callErr = next.Do(req.WithContext(ctx))
+
+		switch {
+		case callErr != nil && req.Context().Err() != nil:
+			return nil, nil
+		case callErr != nil:
+			return nil, callErr
+		case resp.StatusCode >= http.StatusInternalServerError:
+			return nil, errDownstreamFailure
+		}
+
+		return nil, nil
+	})
+
+	switch {
+	case callErr != nil:
+		cancel()
+
+		return nil, callErr
+	case resp == nil:
+		// rejected by the open breaker.
+		cancel()
+
+		return nil, err
+	case resp.Body == nil:
+		cancel()
+	default:
+		resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	}
+
+	return resp, nil
+}
+
+// cancelOnClose keeps the attempt context alive until the body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close ...
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+
+	return b.ReadCloser.Close()
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func isRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, gobreaker.ErrOpenState) &&
+			!errors.Is(err, gobreaker.ErrTooManyRequests) &&
+			!errors.Is(err, context.Canceled)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// backoff is a random duration up to base * 2^(attempt-1), the full jitter
+// keeps retrying callers from hitting the downstream in lockstep.
+func backoff(base time.Duration, attempt int) time.Duration {
+	ceiling := int64(base) << (attempt - 1)
+	if ceiling <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(ceiling) + 1) //nolint:gosec // jitter needs no secure source
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rewind returns a copy of req whose body can be sent again.
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.GetBody == nil {
+		return req, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+
+	rewound := req.Clone(req.Context())
+	rewound.Body = body
+
+	return rewound, nil
+}
+
+// drainAndClose reads what is left of the body so that the connection can
+// be reused, then closes it.
+func drainAndClose(resp *http.Response) {
+	if resp == nil || resp.Body == nil {
+		return
+	}
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainBytes))
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,282 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/sony/gobreaker"
+	"github.com/stretchr/testify/assert"
+)
+
+const downstreamHost = "db-app:7070"
+
+var errConnRefused = errors.New("connection refused")
+
+func testClientPolicy() service.ClientPolicy {
+	return service.ClientPolicy{
+		Timeout:         time.Second,
+		Retries:         2,
+		Backoff:         time.Millisecond,
+		BreakerFailures: 3,
+		BreakerCooldown: time.Minute,
+	}
+}
+
+func statusResponse(status int) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(strings.NewReader(`{}`)),
+	}
+}
+
+func TestResilientClientRetry(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name        string
+		inMethod    string
+		inResults   []int
+		outStatus   int
+		outAttempts int32
+	}{
+		{
+			name:        "RetryThenSuccess",
+			inMethod:    http.MethodGet,
+			inResults:   []int{http.StatusServiceUnavailable, http.StatusOK},
+			outStatus:   http.StatusOK,
+			outAttempts: 2,
+		},
+		{
+			name:        "RetriesExhausted",
+			inMethod:    http.MethodGet,
+			inResults:   []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			outStatus:   http.StatusBadGateway,
+			outAttempts: 3,
+		},
+		{
+			name:        "NetworkErrorThenSuccess",
+			inMethod:    http.MethodDelete,
+			inResults:   []int{0, http.StatusOK},
+			outStatus:   http.StatusOK,
+			outAttempts: 2,
+		},
+		{
+			name:        "NoRetryPost",
+			inMethod:    http.MethodPost,
+			inResults:   []int{http.StatusServiceUnavailable, http.StatusOK},
+			outStatus:   http.StatusServiceUnavailable,
+			outAttempts: 1,
+		},
+		{
+			name:        "NoRetryClientError",
+			inMethod:    http.MethodGet,
+			inResults:   []int{http.StatusNotFound, http.StatusOK},
+			outStatus:   http.StatusNotFound,
+			outAttempts: 1,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var attempts int32
+
+			mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
+				attempt := atomic.AddInt32(&attempts, 1)
+
+				body, err := io.ReadAll(req.Body)
+				if err != nil || string(body) != `{"id":1}` {
+					t.Errorf("attempt %d got body %q: %v", attempt, body, err)
+				}
+
+				status := tt.inResults[attempt-1]
+				if status == 0 {
+					return nil, errConnRefused
+				}
+
+				return statusResponse(status), nil
+			})
+
+			client := service.NewResilientClient(mock, map[string]service.ClientPolicy{
+				downstreamHost: testClientPolicy(),
+			})
+
+			req, err := http.NewRequestWithContext(
+				context.TODO(),
+				tt.inMethod,
+				"http://"+downstreamHost+"/user",
+				strings.NewReader(`{"id":1}`),
+			)
+			assert.NoError(t, err)
+
+			resp, err := client.Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.outStatus, resp.StatusCode)
+			assert.Equal(t, tt.outAttempts, atomic.LoadInt32(&attempts))
+			assert.NoError(t, resp.Body.Close())
+		})
+	}
+}
+
+func TestResilientClientBreaker(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+
+	mock := service.NewMockClient(func(_ *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&attempts, 1)
+
+		return nil, errConnRefused
+	})
+
+	policy := testClientPolicy()
+	policy.Retries = 0
+
+	client := service.NewResilientClient(mock, map[string]service.ClientPolicy{
+		downstreamHost: policy,
+	})
+
+	for i := 0; i < int(policy.BreakerFailures); i++ {
+		req, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, "http://"+downstreamHost+"/users", nil)
+		assert.NoError(t, err)
+
+		_, err = client.Do(req)
+		assert.ErrorIs(t, err, errConnRefused)
+	}
+
+	req, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, "http://"+downstreamHost+"/users", nil)
+	assert.NoError(t, err)
+
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, gobreaker.ErrOpenState)
+	assert.Equal(t, int32(policy.BreakerFailures), atomic.LoadInt32(&attempts))
+
+	// other downstreams keep their own breaker.
+	req, err = http.NewRequestWithContext(context.TODO(), http.MethodGet, "http://token-app:9090/token", nil)
+	assert.NoError(t, err)
+
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, errConnRefused)
+}
+
+func TestResilientClientCallerCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
+		return nil, req.Context().Err()
+	})
+
+	policy := testClientPolicy()
+	policy.BreakerFailures = 1
+
+	client := service.NewResilientClient(mock, map[string]service.ClientPolicy{
+		downstreamHost: policy,
+	})
+
+	for i := 0; i < 2; i++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+downstreamHost+"/users", nil)
+		assert.NoError(t, err)
+
+		_, err = client.Do(req)
+		assert.ErrorIs(t, err, context.Canceled)
+	}
+}
+
+func TestResilientClientTimeout(t *testing.T) {
+	t.Parallel()
+
+	var attempts int32
+
+	mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&attempts, 1)
+
+		<-req.Context().Done()
+
+		return nil, req.Context().Err()
+	})
+
+	policy := testClientPolicy()
+	policy.Timeout = 10 * time.Millisecond
+	policy.Retries = 1
+
+	client := service.NewResilientClient(mock, map[string]service.ClientPolicy{
+		downstreamHost: policy,
+	})
+
+	req, err := http.NewRequestWithContext(context.TODO(), http.MethodGet, "http://"+downstreamHost+"/users", nil)
+	assert.NoError(t, err)
+
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+}
+
+type trackedBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackedBody) Close() error {
+	b.closed = true
+
+	return nil
+}
+
+func TestRequestFuncClosesBody(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name     string
+		inBody   string
+		inStatus int
+	}{
+		{
+			name:     "Success",
+			inBody:   `{"id": 1} trailing`,
+			inStatus: http.StatusOK,
+		},
+		{
+			name:     "Problem",
+			inBody:   `{"detail": "user not found"}`,
+			inStatus: http.StatusNotFound,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			body := &trackedBody{Reader: strings.NewReader(tt.inBody)}
+
+			mock := service.NewMockClient(func(_ *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: tt.inStatus, Body: body}, nil
+			})
+
+			var response dbapp.IDErrorResponse
+
+			_ = service.RequestFunc(
+				context.TODO(),
+				mock,
+				nil,
+				service.NewHTTPComponents("localhost:8080", http.MethodGet),
+				&response,
+			)
+
+			assert.True(t, body.closed)
+
+			rest, err := io.ReadAll(body.Reader)
+			assert.NoError(t, err)
+			assert.Empty(t, rest)
+		})
+	}
+}
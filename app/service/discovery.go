@@ -0,0 +1,259 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/kit/sd"
+	"github.com/go-kit/log"
+)
+
+// Probe reports whether an instance, as host:port, is healthy.
+type Probe func(ctx context.Context, instance string) error
+
+// HTTPProbe is a Probe that checks the instance answers 200 at path, its
+// readiness probe.
+func HTTPProbe(client HTTPClient, path string) Probe {
+	return func(ctx context.Context, instance string) error {
+		return getReady(ctx, client, "http://"+instance+path)
+	}
+}
+
+// ParseInstances reads a list of host:port instances separated by commas,
+// spaces or new lines, everything after a # is a comment.
+func ParseInstances(list string) []string {
+	instances := []string{}
+
+	for _, line := range strings.Split(list, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+
+		instances = append(instances, strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\r'
+		})...)
+	}
+
+	return instances
+}
+
+// instanceCache keeps the last sd.Event and broadcasts the changes to the
+// registered channels, like the cache the go-kit instancers use.
+type instanceCache struct {
+	subscribers map[chan<- sd.Event]struct{}
+	state       sd.Event
+	mu          sync.Mutex
+}
+
+func newInstanceCache() *instanceCache {
+	return &instanceCache{subscribers: map[chan<- sd.Event]struct{}{}}
+}
+
+func (c *instanceCache) update(event sd.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sort.Strings(event.Instances)
+
+	if reflect.DeepEqual(c.state, event) {
+		return
+	}
+
+	c.state = event
+
+	for ch := range c.subscribers {
+		ch <- copyEvent(event)
+	}
+}
+
+// Register ...
+func (c *instanceCache) Register(ch chan<- sd.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.subscribers[ch] = struct{}{}
+	ch <- copyEvent(c.state)
+}
+
+// Deregister ...
+func (c *instanceCache) Deregister(ch chan<- sd.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.subscribers, ch)
+}
+
+func copyEvent(event sd.Event) sd.Event {
+	if event.Instances != nil {
+		event.Instances = append([]string{}, event.Instances...)
+	}
+
+	return event
+}
+
+// FileInstancer is an sd.Instancer that reads the instances from a file and
+// watches it for changes.
+type FileInstancer struct {
+	*instanceCache
+	quit chan struct{}
+	path string
+}
+
+// NewFileInstancer reads path now and again every interval.
+func NewFileInstancer(path string, interval time.Duration) *FileInstancer {
+	f := &FileInstancer{
+		instanceCache: newInstanceCache(),
+		quit:          make(chan struct{}),
+		path:          path,
+	}
+
+	f.read()
+
+	go f.watch(interval)
+
+	return f
+}
+
+// Stop ...
+func (f *FileInstancer) Stop() {
+	close(f.quit)
+}
+
+func (f *FileInstancer) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			f.read()
+		case <-f.quit:
+			return
+		}
+	}
+}
+
+func (f *FileInstancer) read() {
+	content, err := os.ReadFile(f.path)
+	if err != nil {
+		f.update(sd.Event{Err: fmt.Errorf("failed to read instances: %w", err)})
+
+		return
+	}
+
+	f.update(sd.Event{Instances: ParseInstances(string(content))})
+}
+
+// HealthInstancer is an sd.Instancer that passes on the instances of another
+// one, ejecting the ones that fail the probe until they pass it again.
+type HealthInstancer struct {
+	*instanceCache
+	upstream sd.Instancer
+	probe    Probe
+	logger   log.Logger
+	events   chan sd.Event
+	quit     chan struct{}
+	healthy  map[string]bool
+}
+
+// NewHealthInstancer probes the instances of upstream as soon as they are
+// known and again every interval.
+func NewHealthInstancer(upstream sd.Instancer, probe Probe, interval time.Duration, logger log.Logger) *HealthInstancer {
+	h := &HealthInstancer{
+		instanceCache: newInstanceCache(),
+		upstream:      upstream,
+		probe:         probe,
+		logger:        logger,
+		events:        make(chan sd.Event),
+		quit:          make(chan struct{}),
+		healthy:       map[string]bool{},
+	}
+
+	go h.watch(interval)
+
+	upstream.Register(h.events)
+
+	return h
+}
+
+// Stop ...
+func (h *HealthInstancer) Stop() {
+	h.upstream.Deregister(h.events)
+	close(h.quit)
+}
+
+func (h *HealthInstancer) watch(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var instances []string
+
+	for {
+		select {
+		case event := <-h.events:
+			if event.Err != nil {
+				h.update(event)
+
+				continue
+			}
+
+			instances = event.Instances
+			h.check(instances)
+		case <-ticker.C:
+			if instances != nil {
+				h.check(instances)
+			}
+		case <-h.quit:
+			return
+		}
+	}
+}
+
+func (h *HealthInstancer) check(instances []string) {
+	errs := make([]error, len(instances))
+
+	var wg sync.WaitGroup
+
+	for i, instance := range instances {
+		wg.Add(1)
+
+		go func(i int, instance string) {
+			defer wg.Done()
+
+			errs[i] = h.probe(context.Background(), instance)
+		}(i, instance)
+	}
+
+	wg.Wait()
+
+	healthy := make(map[string]bool, len(instances))
+	passing := []string{}
+
+	for i, instance := range instances {
+		wasHealthy, known := h.healthy[instance]
+
+		if errs[i] != nil {
+			if wasHealthy || !known {
+				_ = h.logger.Log("instance", instance, "health", "ejected", "err", errs[i])
+			}
+
+			healthy[instance] = false
+
+			continue
+		}
+
+		if known && !wasHealthy {
+			_ = h.logger.Log("instance", instance, "health", "restored")
+		}
+
+		healthy[instance] = true
+		passing = append(passing, instance)
+	}
+
+	h.healthy = healthy
+	h.update(sd.Event{Instances: passing})
+}
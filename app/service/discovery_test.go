@@ -0,0 +1,171 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	"github.com/cfabrica46/gokit-crud/platform/health"
+	"github.com/go-kit/kit/sd"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+var errUnhealthy = errors.New("unhealthy")
+
+// lastEvent registers to instancer and returns a func with the last event
+// it has broadcast.
+func lastEvent(t *testing.T, instancer sd.Instancer) func() sd.Event {
+	t.Helper()
+
+	var (
+		mu   sync.Mutex
+		last sd.Event
+	)
+
+	ch := make(chan sd.Event)
+
+	go func() {
+		for event := range ch {
+			mu.Lock()
+			last = event
+			mu.Unlock()
+		}
+	}()
+
+	instancer.Register(ch)
+	t.Cleanup(func() {
+		instancer.Deregister(ch)
+		close(ch)
+	})
+
+	return func() sd.Event {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return last
+	}
+}
+
+func TestParseInstances(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name string
+		in   string
+		out  []string
+	}{
+		{
+			name: "Commas",
+			in:   "db-1:7070,db-2:7070, db-3:7070",
+			out:  []string{"db-1:7070", "db-2:7070", "db-3:7070"},
+		},
+		{
+			name: "File",
+			in:   "# database-app\ndb-1:7070\n\ndb-2:7070 # second\n",
+			out:  []string{"db-1:7070", "db-2:7070"},
+		},
+		{
+			name: "Empty",
+			in:   "",
+			out:  []string{},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.out, service.ParseInstances(tt.in))
+		})
+	}
+}
+
+func TestFileInstancer(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "instances")
+	assert.NoError(t, os.WriteFile(path, []byte("db-2:7070\ndb-1:7070\n"), 0o600))
+
+	instancer := service.NewFileInstancer(path, 5*time.Millisecond)
+	defer instancer.Stop()
+
+	last := lastEvent(t, instancer)
+
+	assert.Equal(t, []string{"db-1:7070", "db-2:7070"}, last().Instances)
+
+	assert.NoError(t, os.WriteFile(path, []byte("db-3:7070\n"), 0o600))
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"db-3:7070"}, last().Instances)
+	}, time.Second, 5*time.Millisecond)
+
+	assert.NoError(t, os.Remove(path))
+	assert.Eventually(t, func() bool {
+		return last().Err != nil
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestHealthInstancer(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu        sync.Mutex
+		unhealthy = map[string]bool{"db-2:7070": true}
+	)
+
+	probe := func(_ context.Context, instance string) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if unhealthy[instance] {
+			return errUnhealthy
+		}
+
+		return nil
+	}
+
+	instancer := service.NewHealthInstancer(
+		sd.FixedInstancer{"db-1:7070", "db-2:7070"},
+		probe,
+		5*time.Millisecond,
+		log.NewNopLogger(),
+	)
+	defer instancer.Stop()
+
+	last := lastEvent(t, instancer)
+
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"db-1:7070"}, last().Instances)
+	}, time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	unhealthy = map[string]bool{"db-1:7070": true}
+	mu.Unlock()
+
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"db-2:7070"}, last().Instances)
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestHTTPProbe(t *testing.T) {
+	t.Parallel()
+
+	ready := health.NewReadiness(log.NewNopLogger(), time.Second, nil)
+	server := httptest.NewServer(ready)
+
+	t.Cleanup(server.Close)
+
+	probe := service.HTTPProbe(server.Client(), "/readyz")
+	instance := strings.TrimPrefix(server.URL, "http://")
+
+	assert.NoError(t, probe(context.TODO(), instance))
+
+	ready.Drain()
+	assert.ErrorContains(t, probe(context.TODO(), instance), "shutting down")
+}
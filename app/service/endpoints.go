@@ -12,109 +12,248 @@ var ErrRequest = errors.New("error to request")
 
 // MakeSignUpEndpoint ...
 func MakeSignUpEndpoint(svc serviceInterface) endpoint.Endpoint {
-	return func(_ context.Context, request any) (any, error) {
-		var errMessage string
-
+	return func(ctx context.Context, request any) (any, error) {
 		req, ok := request.(UsernamePasswordEmailRequest)
 		if !ok {
 			return nil, fmt.Errorf("%w: isn't of type GenerateTokenRequest", ErrRequest)
 		}
 
-		token, err := svc.SignUp(req.Username, req.Password, req.Email)
+		token, refreshToken, err := svc.SignUp(ctx, req.Username, req.Password, req.Email)
 		if err != nil {
-			errMessage = err.Error()
+			return nil, err
 		}
 
-		return TokenErrorResponse{Token: token, Err: errMessage}, nil
+		return TokenErrorResponse{Token: token, RefreshToken: refreshToken}, nil
 	}
 }
 
 // MakeSignInEndpoint ...
 func MakeSignInEndpoint(svc serviceInterface) endpoint.Endpoint {
-	return func(_ context.Context, request any) (any, error) {
-		var errMessage string
-
+	return func(ctx context.Context, request any) (any, error) {
 		req, ok := request.(UsernamePasswordRequest)
 		if !ok {
 			return nil, fmt.Errorf("%w: isn't of type GenerateTokenRequest", ErrRequest)
 		}
 
-		token, err := svc.SignIn(req.Username, req.Password)
+		token, refreshToken, err := svc.SignIn(ctx, req.Username, req.Password)
 		if err != nil {
-			errMessage = err.Error()
+			return nil, err
 		}
 
-		return TokenErrorResponse{Token: token, Err: errMessage}, nil
+		return TokenErrorResponse{Token: token, RefreshToken: refreshToken}, nil
 	}
 }
 
 // MakeLogOutEndpoint ...
 func MakeLogOutEndpoint(svc serviceInterface) endpoint.Endpoint {
-	return func(_ context.Context, request any) (any, error) {
-		var errMessage string
-
+	return func(ctx context.Context, request any) (any, error) {
 		req, ok := request.(TokenRequest)
 		if !ok {
 			return nil, fmt.Errorf("%w: isn't of type GenerateTokenRequest", ErrRequest)
 		}
 
-		err := svc.LogOut(req.Token)
+		err := svc.LogOut(ctx, req.Token)
 		if err != nil {
-			errMessage = err.Error()
+			return nil, err
 		}
 
-		return ErrorResponse{Err: errMessage}, nil
+		return ErrorResponse{}, nil
 	}
 }
 
 // MakeGetAllUsersEndpoint ...
 func MakeGetAllUsersEndpoint(svc serviceInterface) endpoint.Endpoint {
-	return func(_ context.Context, _ any) (any, error) {
-		var errMessage string
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(UsersQueryRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type UsersQueryRequest", ErrRequest)
+		}
 
-		users, err := svc.GetAllUsers()
+		users, total, next, err := svc.GetAllUsers(ctx, req.Query)
 		if err != nil {
-			errMessage = err.Error()
+			return nil, err
 		}
 
-		return UsersErrorResponse{Users: users, Err: errMessage}, nil
+		views, err := NewUserViews(users, req.Fields)
+		if err != nil {
+			return nil, err
+		}
+
+		return UsersErrorResponse{Users: views, Total: total, Next: next}, nil
 	}
 }
 
 // MakeProfileEndpoint ...
 func MakeProfileEndpoint(svc serviceInterface) endpoint.Endpoint {
-	return func(_ context.Context, request any) (any, error) {
-		var errMessage string
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(TokenFieldsRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type TokenFieldsRequest", ErrRequest)
+		}
+
+		user, err := svc.Profile(ctx, req.Token)
+		if err != nil {
+			return nil, err
+		}
+
+		view, err := NewUserView(user, req.Fields)
+		if err != nil {
+			return nil, err
+		}
 
+		return UserErrorResponse{User: view}, nil
+	}
+}
+
+// MakeDeleteAccountEndpoint ...
+func MakeDeleteAccountEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
 		req, ok := request.(TokenRequest)
 		if !ok {
 			return nil, fmt.Errorf("%w: isn't of type GenerateTokenRequest", ErrRequest)
 		}
 
-		user, err := svc.Profile(req.Token)
+		err := svc.DeleteAccount(ctx, req.Token)
 		if err != nil {
-			errMessage = err.Error()
+			return nil, err
 		}
 
-		return UserErrorResponse{User: user, Err: errMessage}, nil
+		return ErrorResponse{}, nil
 	}
 }
 
-// MakeDeleteAccountEndpoint ...
-func MakeDeleteAccountEndpoint(svc serviceInterface) endpoint.Endpoint {
-	return func(_ context.Context, request any) (any, error) {
-		var errMessage string
+// MakeUpdateProfileEndpoint ...
+func MakeUpdateProfileEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(TokenUsernameEmailRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type TokenUsernameEmailRequest", ErrRequest)
+		}
+
+		token, refreshToken, err := svc.UpdateProfile(ctx, req.Token, req.Username, req.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		return TokenErrorResponse{Token: token, RefreshToken: refreshToken}, nil
+	}
+}
+
+// MakeChangePasswordEndpoint ...
+func MakeChangePasswordEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(TokenOldNewPasswordRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type TokenOldNewPasswordRequest", ErrRequest)
+		}
+
+		err := svc.ChangePassword(ctx, req.Token, req.OldPassword, req.NewPassword)
+		if err != nil {
+			return nil, err
+		}
+
+		return ErrorResponse{}, nil
+	}
+}
+
+// MakeForgotPasswordEndpoint ...
+func MakeForgotPasswordEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(EmailRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type EmailRequest", ErrRequest)
+		}
 
+		err := svc.ForgotPassword(ctx, req.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		return ErrorResponse{}, nil
+	}
+}
+
+// MakeResetPasswordEndpoint ...
+func MakeResetPasswordEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(TokenPasswordRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type TokenPasswordRequest", ErrRequest)
+		}
+
+		err := svc.ResetPassword(ctx, req.Token, req.Password)
+		if err != nil {
+			return nil, err
+		}
+
+		return ErrorResponse{}, nil
+	}
+}
+
+// MakeVerifyEmailEndpoint ...
+func MakeVerifyEmailEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
 		req, ok := request.(TokenRequest)
 		if !ok {
-			return nil, fmt.Errorf("%w: isn't of type GenerateTokenRequest", ErrRequest)
+			return nil, fmt.Errorf("%w: isn't of type TokenRequest", ErrRequest)
+		}
+
+		err := svc.VerifyEmail(ctx, req.Token)
+		if err != nil {
+			return nil, err
+		}
+
+		return ErrorResponse{}, nil
+	}
+}
+
+// MakeRefreshTokenEndpoint ...
+func MakeRefreshTokenEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(RefreshTokenRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type RefreshTokenRequest", ErrRequest)
+		}
+
+		token, refreshToken, err := svc.RefreshToken(ctx, req.RefreshToken)
+		if err != nil {
+			return nil, err
+		}
+
+		return TokenErrorResponse{Token: token, RefreshToken: refreshToken}, nil
+	}
+}
+
+// MakeDeleteUserEndpoint ...
+func MakeDeleteUserEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(IDRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type IDRequest", ErrRequest)
+		}
+
+		err := svc.DeleteUser(ctx, req.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return ErrorResponse{}, nil
+	}
+}
+
+// MakeSuspendUserEndpoint ...
+func MakeSuspendUserEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(IDSuspendedRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type IDSuspendedRequest", ErrRequest)
 		}
 
-		err := svc.DeleteAccount(req.Token)
+		err := svc.SuspendUser(ctx, req.ID, req.Suspended)
 		if err != nil {
-			errMessage = err.Error()
+			return nil, err
 		}
 
-		return ErrorResponse{Err: errMessage}, nil
+		return ErrorResponse{}, nil
 	}
 }
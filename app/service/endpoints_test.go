@@ -70,12 +70,10 @@ func TestSignUpEndpoint(t *testing.T) {
 
 			testResp := struct {
 				Token string `json:"token"`
-				Err   string `json:"err"`
 				ID    int    `json:"id"`
 			}{
 				ID:    idTest,
 				Token: tt.outToken,
-				Err:   tt.outErr,
 			}
 
 			jsonData, err := json.Marshal(testResp)
@@ -83,15 +81,11 @@ func TestSignUpEndpoint(t *testing.T) {
 				assert.Error(t, err)
 			}
 
-			mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
-				return &http.Response{
-					StatusCode: http.StatusOK,
-					Body:       ioutil.NopCloser(bytes.NewReader(jsonData)),
-				}, nil
-			})
+			mock := service.NewMockClient(getEndpointMock(jsonData, tt.outErr))
 
 			svc := service.NewService(
 				mock,
+				service.NewMockMailSender(nil),
 				&infoServiceTest,
 			)
 
@@ -107,12 +101,8 @@ func TestSignUpEndpoint(t *testing.T) {
 				}
 			}
 
-			if result.Err != "" {
-				resultErr = result.Err
-			}
-
 			if tt.name == nameNoError {
-				assert.Empty(t, result.Err)
+				assert.Empty(t, resultErr)
 			} else {
 				assert.Contains(t, resultErr, tt.outErr)
 			}
@@ -170,17 +160,14 @@ func TestSignInEndpoint(t *testing.T) {
 
 			testResp := struct {
 				Token string `json:"token"`
-				Err   string `json:"err"`
 				User  dbapp.User
 			}{
 				User: dbapp.User{
 					ID:       idTest,
 					Username: usernameTest,
-					Password: passwordTest,
 					Email:    emailTest,
 				},
 				Token: tt.outToken,
-				Err:   tt.outErr,
 			}
 
 			jsonData, err := json.Marshal(testResp)
@@ -188,15 +175,11 @@ func TestSignInEndpoint(t *testing.T) {
 				assert.Error(t, err)
 			}
 
-			mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
-				return &http.Response{
-					StatusCode: http.StatusOK,
-					Body:       ioutil.NopCloser(bytes.NewReader(jsonData)),
-				}, nil
-			})
+			mock := service.NewMockClient(getEndpointMock(jsonData, tt.outErr))
 
 			svc := service.NewService(
 				mock,
+				service.NewMockMailSender(nil),
 				&infoServiceTest,
 			)
 
@@ -212,12 +195,8 @@ func TestSignInEndpoint(t *testing.T) {
 				}
 			}
 
-			if result.Err != "" {
-				resultErr = result.Err
-			}
-
 			if tt.name == nameNoError {
-				assert.Empty(t, result.Err)
+				assert.Empty(t, resultErr)
 			} else {
 				assert.Contains(t, resultErr, tt.outErr)
 			}
@@ -270,11 +249,9 @@ func TestLogOutEndpoint(t *testing.T) {
 			var resultErr string
 
 			testResp := struct {
-				Err   string `json:"err"`
-				Check bool   `json:"check"`
+				Check bool `json:"check"`
 			}{
 				Check: true,
-				Err:   tt.outErr,
 			}
 
 			jsonData, err := json.Marshal(testResp)
@@ -282,15 +259,11 @@ func TestLogOutEndpoint(t *testing.T) {
 				assert.Error(t, err)
 			}
 
-			mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
-				return &http.Response{
-					StatusCode: http.StatusOK,
-					Body:       ioutil.NopCloser(bytes.NewReader(jsonData)),
-				}, nil
-			})
+			mock := service.NewMockClient(getEndpointMock(jsonData, tt.outErr))
 
 			svc := service.NewService(
 				mock,
+				service.NewMockMailSender(nil),
 				&infoServiceTest,
 			)
 
@@ -299,19 +272,15 @@ func TestLogOutEndpoint(t *testing.T) {
 				resultErr = err.Error()
 			}
 
-			result, ok := r.(service.ErrorResponse)
+			_, ok := r.(service.ErrorResponse)
 			if !ok {
 				if tt.name != nameErrorRequest {
 					assert.Error(t, errNotTypeIndicated)
 				}
 			}
 
-			if result.Err != "" {
-				resultErr = result.Err
-			}
-
 			if tt.name == nameNoError {
-				assert.Empty(t, result.Err)
+				assert.Empty(t, resultErr)
 			} else {
 				assert.Contains(t, resultErr, tt.outErr)
 			}
@@ -331,38 +300,81 @@ func TestGetAllUsersEndpoint(t *testing.T) {
 	}
 
 	for _, tt := range []struct {
+		in       any
 		name     string
 		outErr   string
-		outUsers []dbapp.User
+		outUsers []service.UserView
 	}{
 		{
 			name: nameNoError,
-			outUsers: []dbapp.User{
+			in:   service.UsersQueryRequest{},
+			outUsers: []service.UserView{
 				{
-					ID:       idTest,
-					Username: usernameTest,
-					Password: passwordTest,
-					Email:    emailTest,
+					"id":            idTest,
+					"username":      usernameTest,
+					"email":         emailTest,
+					"emailVerified": false,
+					"role":          dbapp.RoleUser,
+					"suspended":     false,
 				},
 			},
 			outErr: "",
 		},
 		{
-			name:     "ErrorWebService",
-			outUsers: nil,
-			outErr:   errWebServer.Error(),
+			name: nameNoError + "Fields",
+			in:   service.UsersQueryRequest{Fields: []string{"id", "username"}},
+			outUsers: []service.UserView{
+				{
+					"id":       idTest,
+					"username": usernameTest,
+				},
+			},
+			outErr: "",
+		},
+		{
+			name:   "ErrorUnknownField",
+			in:     service.UsersQueryRequest{Fields: []string{"password"}},
+			outErr: service.ErrUnknownField.Error(),
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:   "ErrorWebService",
+			in:     service.UsersQueryRequest{},
+			outErr: errWebServer.Error(),
 		},
 	} {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
+			var resultErr string
+
+			var dbErr string
+			if tt.name == "ErrorWebService" {
+				dbErr = tt.outErr
+			}
+
 			testResp := struct {
-				Err   string       `json:"err"`
+				Next  string       `json:"next"`
 				Users []dbapp.User `json:"users"`
+				Total int          `json:"total"`
 			}{
-				Users: tt.outUsers,
-				Err:   tt.outErr,
+				Next:  "MQ",
+				Total: 2,
+				Users: []dbapp.User{
+					{
+						ID:       idTest,
+						Username: usernameTest,
+						Email:    emailTest,
+						Role:     dbapp.RoleUser,
+					},
+				},
 			}
 
 			jsonData, err := json.Marshal(testResp)
@@ -370,35 +382,38 @@ func TestGetAllUsersEndpoint(t *testing.T) {
 				assert.Error(t, err)
 			}
 
-			mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
-				return &http.Response{
-					StatusCode: http.StatusOK,
-					Body:       ioutil.NopCloser(bytes.NewReader(jsonData)),
-				}, nil
-			})
+			mock := service.NewMockClient(getEndpointMock(jsonData, dbErr))
 
 			svc := service.NewService(
 				mock,
+				service.NewMockMailSender(nil),
 				&infoServiceTest,
 			)
 
-			r, err := service.MakeGetAllUsersEndpoint(svc)(context.TODO(), nil)
+			r, err := service.MakeGetAllUsersEndpoint(svc)(context.TODO(), tt.in)
 			if err != nil {
-				assert.Error(t, err)
+				resultErr = err.Error()
 			}
 
 			result, ok := r.(service.UsersErrorResponse)
 			if !ok {
-				assert.Error(t, errNotTypeIndicated)
+				if tt.name != nameErrorRequest {
+					assert.Error(t, errNotTypeIndicated)
+				}
 			}
 
-			if tt.name == nameNoError {
-				assert.Empty(t, result.Err)
+			if tt.outErr == "" {
+				assert.Empty(t, resultErr)
 			} else {
-				assert.Contains(t, result.Err, tt.outErr)
+				assert.Contains(t, resultErr, tt.outErr)
 			}
 
 			assert.Equal(t, tt.outUsers, result.Users)
+
+			if tt.outErr == "" {
+				assert.Equal(t, 2, result.Total)
+				assert.Equal(t, "MQ", result.Next)
+			}
 		})
 	}
 }
@@ -419,18 +434,23 @@ func TestProfileEndpoint(t *testing.T) {
 		name    string
 		outErr  string
 		outUser dbapp.User
+		outView service.UserView
 	}{
 		{
 			name: nameNoError,
-			in: service.TokenRequest{
-				Token: tokenTest,
+			in: service.TokenFieldsRequest{
+				Token:  tokenTest,
+				Fields: []string{"id", "email"},
 			},
 			outUser: dbapp.User{
 				ID:       idTest,
 				Username: usernameTest,
-				Password: passwordTest,
 				Email:    emailTest,
 			},
+			outView: service.UserView{
+				"id":    idTest,
+				"email": emailTest,
+			},
 			outErr: "",
 		},
 		{
@@ -442,7 +462,7 @@ func TestProfileEndpoint(t *testing.T) {
 		},
 		{
 			name:    "ErrorWebService",
-			in:      service.TokenRequest{},
+			in:      service.TokenFieldsRequest{},
 			outUser: dbapp.User{},
 			outErr:  errWebServer.Error(),
 		},
@@ -456,7 +476,6 @@ func TestProfileEndpoint(t *testing.T) {
 			testResp := struct {
 				Username string     `json:"username"`
 				Email    string     `json:"email"`
-				Err      string     `json:"err"`
 				User     dbapp.User `json:"user"`
 				ID       int        `json:"id"`
 				Check    bool       `json:"check"`
@@ -466,7 +485,6 @@ func TestProfileEndpoint(t *testing.T) {
 				Username: tt.outUser.Username,
 				Email:    tt.outUser.Email,
 				Check:    true,
-				Err:      tt.outErr,
 			}
 
 			jsonData, err := json.Marshal(testResp)
@@ -474,15 +492,11 @@ func TestProfileEndpoint(t *testing.T) {
 				assert.Error(t, err)
 			}
 
-			mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
-				return &http.Response{
-					StatusCode: http.StatusOK,
-					Body:       ioutil.NopCloser(bytes.NewReader(jsonData)),
-				}, nil
-			})
+			mock := service.NewMockClient(getEndpointMock(jsonData, tt.outErr))
 
 			svc := service.NewService(
 				mock,
+				service.NewMockMailSender(nil),
 				&infoServiceTest,
 			)
 
@@ -498,17 +512,13 @@ func TestProfileEndpoint(t *testing.T) {
 				}
 			}
 
-			if result.Err != "" {
-				resultErr = result.Err
-			}
-
 			if tt.name == nameNoError {
-				assert.Empty(t, result.Err)
+				assert.Empty(t, resultErr)
 			} else {
 				assert.Contains(t, resultErr, tt.outErr)
 			}
 
-			assert.Equal(t, tt.outUser, result.User)
+			assert.Equal(t, tt.outView, result.User)
 		})
 	}
 }
@@ -558,7 +568,6 @@ func TestDeleteAccountEndpoint(t *testing.T) {
 			testResp := struct {
 				Username string `json:"username"`
 				Email    string `json:"email"`
-				Err      string `json:"err"`
 				ID       int    `json:"id"`
 				Check    bool   `json:"check"`
 			}{
@@ -566,7 +575,6 @@ func TestDeleteAccountEndpoint(t *testing.T) {
 				Username: usernameTest,
 				Email:    emailTest,
 				Check:    true,
-				Err:      tt.outErr,
 			}
 
 			jsonData, err := json.Marshal(testResp)
@@ -574,15 +582,11 @@ func TestDeleteAccountEndpoint(t *testing.T) {
 				assert.Error(t, err)
 			}
 
-			mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
-				return &http.Response{
-					StatusCode: http.StatusOK,
-					Body:       ioutil.NopCloser(bytes.NewReader(jsonData)),
-				}, nil
-			})
+			mock := service.NewMockClient(getEndpointMock(jsonData, tt.outErr))
 
 			svc := service.NewService(
 				mock,
+				service.NewMockMailSender(nil),
 				&infoServiceTest,
 			)
 
@@ -591,22 +595,734 @@ func TestDeleteAccountEndpoint(t *testing.T) {
 				resultErr = err.Error()
 			}
 
-			result, ok := r.(service.ErrorResponse)
+			_, ok := r.(service.ErrorResponse)
+			if !ok {
+				if tt.name != nameErrorRequest {
+					assert.Error(t, errNotTypeIndicated)
+				}
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestUpdateProfileEndpoint(t *testing.T) {
+	t.Parallel()
+
+	infoServiceTest := service.InfoServices{
+		DBHost:    dbHostTest,
+		DBPort:    portTest,
+		TokenHost: tokenHostTest,
+		TokenPort: portTest,
+		Secret:    secretTest,
+	}
+
+	for _, tt := range []struct {
+		name     string
+		in       any
+		outToken string
+		outErr   string
+	}{
+		{
+			name: nameNoError,
+			in: service.TokenUsernameEmailRequest{
+				Token:    tokenTest,
+				Username: usernameTest,
+				Email:    emailTest,
+			},
+			outToken: tokenTest,
+			outErr:   "",
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:     "ErrorWebService",
+			in:       service.TokenUsernameEmailRequest{},
+			outToken: "",
+			outErr:   errWebServer.Error(),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			testResp := struct {
+				Username     string `json:"username"`
+				Email        string `json:"email"`
+				ID           int    `json:"id"`
+				RowsAffected int    `json:"rowsAffected"`
+				Check        bool   `json:"check"`
+			}{
+				ID:           idTest,
+				Username:     usernameTest,
+				Email:        emailTest,
+				RowsAffected: 1,
+				Check:        true,
+			}
+
+			jsonData, err := json.Marshal(testResp)
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			mock := service.NewMockClient(getEndpointMock(jsonData, tt.outErr))
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			r, err := service.MakeUpdateProfileEndpoint(svc)(context.TODO(), tt.in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			result, ok := r.(service.TokenErrorResponse)
 			if !ok {
 				if tt.name != nameErrorRequest {
 					assert.Error(t, errNotTypeIndicated)
 				}
 			}
 
-			if result.Err != "" {
-				resultErr = result.Err
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+
+			assert.Equal(t, tt.outToken, result.Token)
+		})
+	}
+}
+
+func TestChangePasswordEndpoint(t *testing.T) {
+	t.Parallel()
+
+	infoServiceTest := service.InfoServices{
+		DBHost:    dbHostTest,
+		DBPort:    portTest,
+		TokenHost: tokenHostTest,
+		TokenPort: portTest,
+		Secret:    secretTest,
+	}
+
+	for _, tt := range []struct {
+		name   string
+		in     any
+		outErr string
+	}{
+		{
+			name: nameNoError,
+			in: service.TokenOldNewPasswordRequest{
+				Token:       tokenTest,
+				OldPassword: passwordTest,
+				NewPassword: "newpassword",
+			},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:   "ErrorWebService",
+			in:     service.TokenOldNewPasswordRequest{NewPassword: "newpassword"},
+			outErr: errWebServer.Error(),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			testResp := struct {
+				User         dbapp.User `json:"user"`
+				Username     string     `json:"username"`
+				ID           int        `json:"id"`
+				RowsAffected int        `json:"rowsAffected"`
+				Check        bool       `json:"check"`
+			}{
+				User:         dbapp.User{ID: idTest, Username: usernameTest},
+				ID:           idTest,
+				Username:     usernameTest,
+				RowsAffected: 1,
+				Check:        true,
+			}
+
+			jsonData, err := json.Marshal(testResp)
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			mock := service.NewMockClient(getEndpointMock(jsonData, tt.outErr))
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			r, err := service.MakeChangePasswordEndpoint(svc)(context.TODO(), tt.in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			_, ok := r.(service.ErrorResponse)
+			if !ok {
+				if tt.name != nameErrorRequest {
+					assert.Error(t, errNotTypeIndicated)
+				}
 			}
 
 			if tt.name == nameNoError {
-				assert.Empty(t, result.Err)
+				assert.Empty(t, resultErr)
 			} else {
 				assert.Contains(t, resultErr, tt.outErr)
 			}
 		})
 	}
 }
+
+func TestForgotPasswordEndpoint(t *testing.T) {
+	t.Parallel()
+
+	infoServiceTest := service.InfoServices{
+		DBHost:    dbHostTest,
+		DBPort:    portTest,
+		TokenHost: tokenHostTest,
+		TokenPort: portTest,
+		Secret:    secretTest,
+	}
+
+	for _, tt := range []struct {
+		name   string
+		in     any
+		outErr string
+	}{
+		{
+			name:   nameNoError,
+			in:     service.EmailRequest{Email: emailTest},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:   "ErrorWebService",
+			in:     service.EmailRequest{},
+			outErr: errWebServer.Error(),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			testResp := struct {
+				Token string `json:"token"`
+				ID    int    `json:"id"`
+			}{
+				Token: tokenTest,
+				ID:    idTest,
+			}
+
+			jsonData, err := json.Marshal(testResp)
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			mock := service.NewMockClient(getEndpointMock(jsonData, tt.outErr))
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			r, err := service.MakeForgotPasswordEndpoint(svc)(context.TODO(), tt.in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			_, ok := r.(service.ErrorResponse)
+			if !ok {
+				if tt.name != nameErrorRequest {
+					assert.Error(t, errNotTypeIndicated)
+				}
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestResetPasswordEndpoint(t *testing.T) {
+	t.Parallel()
+
+	infoServiceTest := service.InfoServices{
+		DBHost:    dbHostTest,
+		DBPort:    portTest,
+		TokenHost: tokenHostTest,
+		TokenPort: portTest,
+		Secret:    secretTest,
+	}
+
+	for _, tt := range []struct {
+		name   string
+		in     any
+		outErr string
+	}{
+		{
+			name:   nameNoError,
+			in:     service.TokenPasswordRequest{Token: tokenTest, Password: passwordTest},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:   "ErrorWebService",
+			in:     service.TokenPasswordRequest{Password: passwordTest},
+			outErr: errWebServer.Error(),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			testResp := struct {
+				ID           int `json:"id"`
+				RowsAffected int `json:"rowsAffected"`
+			}{
+				ID:           idTest,
+				RowsAffected: 1,
+			}
+
+			jsonData, err := json.Marshal(testResp)
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			mock := service.NewMockClient(getEndpointMock(jsonData, tt.outErr))
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			r, err := service.MakeResetPasswordEndpoint(svc)(context.TODO(), tt.in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			_, ok := r.(service.ErrorResponse)
+			if !ok {
+				if tt.name != nameErrorRequest {
+					assert.Error(t, errNotTypeIndicated)
+				}
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestVerifyEmailEndpoint(t *testing.T) {
+	t.Parallel()
+
+	infoServiceTest := service.InfoServices{
+		DBHost:    dbHostTest,
+		DBPort:    portTest,
+		TokenHost: tokenHostTest,
+		TokenPort: portTest,
+		Secret:    secretTest,
+	}
+
+	for _, tt := range []struct {
+		name   string
+		in     any
+		outErr string
+	}{
+		{
+			name:   nameNoError,
+			in:     service.TokenRequest{Token: tokenTest},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:   "ErrorWebService",
+			in:     service.TokenRequest{},
+			outErr: errWebServer.Error(),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			testResp := struct {
+				ID           int `json:"id"`
+				RowsAffected int `json:"rowsAffected"`
+			}{
+				ID:           idTest,
+				RowsAffected: 1,
+			}
+
+			jsonData, err := json.Marshal(testResp)
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			mock := service.NewMockClient(getEndpointMock(jsonData, tt.outErr))
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			r, err := service.MakeVerifyEmailEndpoint(svc)(context.TODO(), tt.in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			_, ok := r.(service.ErrorResponse)
+			if !ok {
+				if tt.name != nameErrorRequest {
+					assert.Error(t, errNotTypeIndicated)
+				}
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenEndpoint(t *testing.T) {
+	t.Parallel()
+
+	infoServiceTest := service.InfoServices{
+		DBHost:    dbHostTest,
+		DBPort:    portTest,
+		TokenHost: tokenHostTest,
+		TokenPort: portTest,
+		Secret:    secretTest,
+	}
+
+	for _, tt := range []struct {
+		name   string
+		in     any
+		outErr string
+	}{
+		{
+			name:   nameNoError,
+			in:     service.RefreshTokenRequest{RefreshToken: refreshTokenTest},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:   "ErrorWebService",
+			in:     service.RefreshTokenRequest{},
+			outErr: errWebServer.Error(),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			testResp := struct {
+				Token        string `json:"token"`
+				RefreshToken string `json:"refreshToken"`
+				User         struct {
+					ID int `json:"id"`
+				} `json:"user"`
+			}{
+				Token:        tokenTest,
+				RefreshToken: refreshTokenTest,
+			}
+
+			testResp.User.ID = idTest
+
+			jsonData, err := json.Marshal(testResp)
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			mock := service.NewMockClient(getEndpointMock(jsonData, tt.outErr))
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			r, err := service.MakeRefreshTokenEndpoint(svc)(context.TODO(), tt.in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			result, ok := r.(service.TokenErrorResponse)
+			if !ok {
+				if tt.name != nameErrorRequest {
+					assert.Error(t, errNotTypeIndicated)
+				}
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+				assert.Equal(t, tokenTest, result.Token)
+				assert.Equal(t, refreshTokenTest, result.RefreshToken)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestDeleteUserEndpoint(t *testing.T) {
+	t.Parallel()
+
+	infoServiceTest := service.InfoServices{
+		DBHost:    dbHostTest,
+		DBPort:    portTest,
+		TokenHost: tokenHostTest,
+		TokenPort: portTest,
+		Secret:    secretTest,
+	}
+
+	for _, tt := range []struct {
+		name   string
+		in     any
+		outErr string
+	}{
+		{
+			name:   nameNoError,
+			in:     service.IDRequest{ID: idTest},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:   "ErrorWebService",
+			in:     service.IDRequest{},
+			outErr: errWebServer.Error(),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			testResp := struct {
+				ID           int `json:"id"`
+				RowsAffected int `json:"rowsAffected"`
+			}{
+				ID:           idTest,
+				RowsAffected: 1,
+			}
+
+			jsonData, err := json.Marshal(testResp)
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			mock := service.NewMockClient(getEndpointMock(jsonData, tt.outErr))
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			r, err := service.MakeDeleteUserEndpoint(svc)(context.TODO(), tt.in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			_, ok := r.(service.ErrorResponse)
+			if !ok {
+				if tt.name != nameErrorRequest {
+					assert.Error(t, errNotTypeIndicated)
+				}
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestSuspendUserEndpoint(t *testing.T) {
+	t.Parallel()
+
+	infoServiceTest := service.InfoServices{
+		DBHost:    dbHostTest,
+		DBPort:    portTest,
+		TokenHost: tokenHostTest,
+		TokenPort: portTest,
+		Secret:    secretTest,
+	}
+
+	for _, tt := range []struct {
+		name   string
+		in     any
+		outErr string
+	}{
+		{
+			name:   nameNoError,
+			in:     service.IDSuspendedRequest{ID: idTest, Suspended: true},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:   "ErrorWebService",
+			in:     service.IDSuspendedRequest{},
+			outErr: errWebServer.Error(),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			testResp := struct {
+				ID           int `json:"id"`
+				RowsAffected int `json:"rowsAffected"`
+			}{
+				ID:           idTest,
+				RowsAffected: 1,
+			}
+
+			jsonData, err := json.Marshal(testResp)
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			mock := service.NewMockClient(getEndpointMock(jsonData, tt.outErr))
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			r, err := service.MakeSuspendUserEndpoint(svc)(context.TODO(), tt.in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			_, ok := r.(service.ErrorResponse)
+			if !ok {
+				if tt.name != nameErrorRequest {
+					assert.Error(t, errNotTypeIndicated)
+				}
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+// getEndpointMock answers every downstream call with jsonData, or with a
+// problem document when errMessage isn't empty.
+func getEndpointMock(jsonData []byte, errMessage string) func(*http.Request) (*http.Response, error) {
+	return func(_ *http.Request) (*http.Response, error) {
+		if errMessage == "" {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       ioutil.NopCloser(bytes.NewReader(jsonData)),
+			}, nil
+		}
+
+		problem, err := json.Marshal(service.Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Detail: errMessage,
+			Status: http.StatusInternalServerError,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Body:       ioutil.NopCloser(bytes.NewReader(problem)),
+		}, nil
+	}
+}
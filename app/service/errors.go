@@ -0,0 +1,100 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Kinds of errors, each kind is reported with its own HTTP status.
+// ErrForbidden and ErrWebServer are kinds too.
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrValidation      = errors.New("validation failed")
+	ErrTooManyRequests = errors.New("too many requests")
+)
+
+// Error gives Err a kind, the kind decides the HTTP status it is reported
+// with while the message and the wrapped chain stay those of Err.
+type Error struct {
+	Kind error
+	Err  error
+
+	// RetryAfter, if set, is how long the client has to wait before trying
+	// again, it is reported in the Retry-After header.
+	RetryAfter time.Duration
+}
+
+// Error ...
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap ...
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the kind of the error.
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+// StatusCode returns the HTTP status err is reported with.
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrTooManyRequests):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrWebServer):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorKind is the kind a downstream service meant with the status.
+func errorKind(status int) error {
+	switch status {
+	case http.StatusBadRequest:
+		return ErrValidation
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	case http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	default:
+		return ErrWebServer
+	}
+}
+
+// RetryAfter returns how long the client has to wait before retrying err, 0
+// when it doesn't have to.
+func RetryAfter(err error) time.Duration {
+	var e *Error
+	for errors.As(err, &e) {
+		if e.RetryAfter > 0 {
+			return e.RetryAfter
+		}
+
+		err = e.Err
+	}
+
+	return 0
+}
@@ -0,0 +1,69 @@
+package service_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusCode(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		in   error
+		name string
+		out  int
+	}{
+		{
+			name: "Validation",
+			in:   fmt.Errorf("%w: password", service.ErrUnknownField),
+			out:  http.StatusBadRequest,
+		},
+		{
+			name: "Unauthorized",
+			in:   service.ErrWrongPassword,
+			out:  http.StatusUnauthorized,
+		},
+		{
+			name: "Forbidden",
+			in:   service.ErrUserSuspended,
+			out:  http.StatusForbidden,
+		},
+		{
+			name: "NotFound",
+			in:   service.ErrUserNotFound,
+			out:  http.StatusNotFound,
+		},
+		{
+			name: "Conflict",
+			in:   fmt.Errorf("%w: username or email already in use", service.ErrConflict),
+			out:  http.StatusConflict,
+		},
+		{
+			name: "TooManyRequests",
+			in:   fmt.Errorf("%w: too many sign in attempts", service.ErrTooManyRequests),
+			out:  http.StatusTooManyRequests,
+		},
+		{
+			name: "BadGateway",
+			in:   fmt.Errorf("%w: error", service.ErrWebServer),
+			out:  http.StatusBadGateway,
+		},
+		{
+			name: "Internal",
+			in:   errors.New("error"),
+			out:  http.StatusInternalServerError,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.out, service.StatusCode(tt.in))
+		})
+	}
+}
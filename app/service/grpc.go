@@ -0,0 +1,344 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/cfabrica46/gokit-crud/app/pb"
+	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/cfabrica46/gokit-crud/platform/logging"
+	"github.com/go-kit/kit/endpoint"
+	grpctransport "github.com/go-kit/kit/transport/grpc"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// grpcAuthorizationKey is the metadata the token travels in, gRPC lowercases
+// the keys.
+const grpcAuthorizationKey = "authorization"
+
+var errGRPCResponse = errors.New("unexpected response")
+
+// GRPCEndpoints are the endpoints served over gRPC, the same ones the HTTP
+// routes serve.
+type GRPCEndpoints struct {
+	SignUp        endpoint.Endpoint
+	SignIn        endpoint.Endpoint
+	LogOut        endpoint.Endpoint
+	GetAllUsers   endpoint.Endpoint
+	Profile       endpoint.Endpoint
+	DeleteAccount endpoint.Endpoint
+}
+
+type grpcServer struct {
+	pb.UnimplementedAppServer
+	signUp        grpctransport.Handler
+	signIn        grpctransport.Handler
+	logOut        grpctransport.Handler
+	getAllUsers   grpctransport.Handler
+	profile       grpctransport.Handler
+	deleteAccount grpctransport.Handler
+}
+
+// NewGRPCServer serves the endpoints as the App service of app.proto.
+func NewGRPCServer(endpoints GRPCEndpoints, logger log.Logger) pb.AppServer {
+	options := GRPCServerLogging(logger)
+
+	return &grpcServer{
+		signUp:        grpctransport.NewServer(endpoints.SignUp, decodeGRPCSignUpRequest, encodeGRPCTokenResponse, options...),
+		signIn:        grpctransport.NewServer(endpoints.SignIn, decodeGRPCSignInRequest, encodeGRPCTokenResponse, options...),
+		logOut:        grpctransport.NewServer(endpoints.LogOut, decodeGRPCTokenRequest, encodeGRPCEmptyResponse, options...),
+		getAllUsers:   grpctransport.NewServer(endpoints.GetAllUsers, decodeGRPCUsersRequest, encodeGRPCUsersResponse, options...),
+		profile:       grpctransport.NewServer(endpoints.Profile, decodeGRPCProfileRequest, encodeGRPCUserResponse, options...),
+		deleteAccount: grpctransport.NewServer(endpoints.DeleteAccount, decodeGRPCTokenRequest, encodeGRPCEmptyResponse, options...),
+	}
+}
+
+// SignUp ...
+func (s *grpcServer) SignUp(ctx context.Context, req *pb.SignUpRequest) (*pb.TokenReply, error) {
+	_, response, err := s.signUp.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+
+	return response.(*pb.TokenReply), nil
+}
+
+// SignIn ...
+func (s *grpcServer) SignIn(ctx context.Context, req *pb.SignInRequest) (*pb.TokenReply, error) {
+	_, response, err := s.signIn.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+
+	return response.(*pb.TokenReply), nil
+}
+
+// LogOut ...
+func (s *grpcServer) LogOut(ctx context.Context, req *pb.Empty) (*pb.Empty, error) {
+	_, response, err := s.logOut.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+
+	return response.(*pb.Empty), nil
+}
+
+// GetAllUsers ...
+func (s *grpcServer) GetAllUsers(ctx context.Context, req *pb.UsersRequest) (*pb.UsersReply, error) {
+	_, response, err := s.getAllUsers.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+
+	return response.(*pb.UsersReply), nil
+}
+
+// Profile ...
+func (s *grpcServer) Profile(ctx context.Context, req *pb.ProfileRequest) (*pb.UserReply, error) {
+	_, response, err := s.profile.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+
+	return response.(*pb.UserReply), nil
+}
+
+// DeleteAccount ...
+func (s *grpcServer) DeleteAccount(ctx context.Context, req *pb.Empty) (*pb.Empty, error) {
+	_, response, err := s.deleteAccount.ServeGRPC(ctx, req)
+	if err != nil {
+		return nil, GRPCError(err)
+	}
+
+	return response.(*pb.Empty), nil
+}
+
+// GRPCServerLogging puts the token, the caller IP and the request and user
+// IDs of the metadata in the context, as TokenToContext, ClientIPToContext
+// and ServerLogging do for HTTP, and logs every call once it is answered,
+// the failures GRPCError hides the detail of as errors.
+func GRPCServerLogging(logger log.Logger) []grpctransport.ServerOption {
+	return []grpctransport.ServerOption{
+		grpctransport.ServerBefore(grpcRequestToContext),
+		grpctransport.ServerAfter(func(ctx context.Context, header *metadata.MD, _ *metadata.MD) context.Context {
+			*header = metadata.Join(*header, metadata.Pairs(logging.RequestIDHeader, logging.RequestID(ctx)))
+
+			return ctx
+		}),
+		grpctransport.ServerFinalizer(func(ctx context.Context, err error) {
+			method, _ := ctx.Value(grpctransport.ContextKeyRequestMethod).(string)
+
+			keyvals := []any{
+				"transport", "grpc",
+				"method", method,
+				"code", status.Code(GRPCError(err)),
+				"duration", logging.Since(ctx),
+				"request_id", logging.RequestID(ctx),
+				"user_id", logging.UserID(ctx),
+			}
+
+			if _, ok := status.FromError(err); !ok && StatusCode(err) >= http.StatusInternalServerError {
+				_ = level.Error(logger).Log(append(keyvals, "err", err)...)
+
+				return
+			}
+
+			_ = level.Info(logger).Log(keyvals...)
+		}),
+	}
+}
+
+func grpcRequestToContext(ctx context.Context, md metadata.MD) context.Context {
+	token := grpcMetadata(md, grpcAuthorizationKey)
+
+	if p, ok := peer.FromContext(ctx); ok {
+		ip := p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+
+		ctx = context.WithValue(ctx, clientIPContextKey, ip)
+	}
+
+	ctx = logging.NewContext(ctx, grpcMetadata(md, logging.RequestIDHeader), userIDFromToken(token))
+
+	return context.WithValue(ctx, tokenContextKey, token)
+}
+
+// grpcMetadata is the first value of key, metadata.MD.Get lowercases it.
+func grpcMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// GRPCError reports err with the gRPC code matching its HTTP status. The
+// message of a failure of the service is the status text, as EncodeError
+// leaves out its detail, GRPCServerLogging logs it instead.
+func GRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	code := codes.Internal
+	statusCode := StatusCode(err)
+
+	switch statusCode {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusBadGateway:
+		code = codes.Unavailable
+	}
+
+	if statusCode >= http.StatusInternalServerError {
+		return status.Error(code, http.StatusText(statusCode))
+	}
+
+	return status.Error(code, err.Error())
+}
+
+func grpcToken(ctx context.Context) (string, error) {
+	token, _ := ctx.Value(tokenContextKey).(string)
+	if token == "" {
+		return "", errFailedGetHeader
+	}
+
+	return token, nil
+}
+
+func decodeGRPCSignUpRequest(_ context.Context, request any) (any, error) {
+	req, ok := request.(*pb.SignUpRequest)
+	if !ok {
+		return nil, fmt.Errorf("%w: isn't of type *pb.SignUpRequest", ErrRequest)
+	}
+
+	return UsernamePasswordEmailRequest{Username: req.Username, Password: req.Password, Email: req.Email}, nil
+}
+
+func decodeGRPCSignInRequest(_ context.Context, request any) (any, error) {
+	req, ok := request.(*pb.SignInRequest)
+	if !ok {
+		return nil, fmt.Errorf("%w: isn't of type *pb.SignInRequest", ErrRequest)
+	}
+
+	return UsernamePasswordRequest{Username: req.Username, Password: req.Password}, nil
+}
+
+func decodeGRPCTokenRequest(ctx context.Context, _ any) (any, error) {
+	token, err := grpcToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return TokenRequest{Token: token}, nil
+}
+
+func decodeGRPCUsersRequest(_ context.Context, request any) (any, error) {
+	req, ok := request.(*pb.UsersRequest)
+	if !ok {
+		return nil, fmt.Errorf("%w: isn't of type *pb.UsersRequest", ErrRequest)
+	}
+
+	return UsersQueryRequest{
+		Query: dbapp.UsersQuery{
+			Cursor:         req.Cursor,
+			UsernamePrefix: req.Username,
+			EmailPrefix:    req.Email,
+			Sort:           req.Sort,
+			Limit:          int(req.Limit),
+		},
+		Fields: req.Fields,
+	}, nil
+}
+
+func decodeGRPCProfileRequest(ctx context.Context, request any) (any, error) {
+	req, ok := request.(*pb.ProfileRequest)
+	if !ok {
+		return nil, fmt.Errorf("%w: isn't of type *pb.ProfileRequest", ErrRequest)
+	}
+
+	token, err := grpcToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return TokenFieldsRequest{Token: token, Fields: req.Fields}, nil
+}
+
+func encodeGRPCTokenResponse(_ context.Context, response any) (any, error) {
+	resp, ok := response.(TokenErrorResponse)
+	if !ok {
+		return nil, fmt.Errorf("%w: isn't of type TokenErrorResponse", errGRPCResponse)
+	}
+
+	return &pb.TokenReply{Token: resp.Token, RefreshToken: resp.RefreshToken}, nil
+}
+
+func encodeGRPCEmptyResponse(_ context.Context, _ any) (any, error) {
+	return &pb.Empty{}, nil
+}
+
+func encodeGRPCUsersResponse(_ context.Context, response any) (any, error) {
+	resp, ok := response.(UsersErrorResponse)
+	if !ok {
+		return nil, fmt.Errorf("%w: isn't of type UsersErrorResponse", errGRPCResponse)
+	}
+
+	users := make([]*pb.User, 0, len(resp.Users))
+	for _, view := range resp.Users {
+		users = append(users, grpcUser(view))
+	}
+
+	return &pb.UsersReply{Users: users, Total: int32(resp.Total), Next: resp.Next}, nil
+}
+
+func encodeGRPCUserResponse(_ context.Context, response any) (any, error) {
+	resp, ok := response.(UserErrorResponse)
+	if !ok {
+		return nil, fmt.Errorf("%w: isn't of type UserErrorResponse", errGRPCResponse)
+	}
+
+	return &pb.UserReply{User: grpcUser(resp.User)}, nil
+}
+
+// grpcUser copies the projected fields of view, the rest keep their zero
+// value.
+func grpcUser(view UserView) *pb.User {
+	user := &pb.User{}
+
+	if id, ok := view["id"].(int); ok {
+		user.Id = int64(id)
+	}
+
+	user.Username, _ = view["username"].(string)
+	user.Email, _ = view["email"].(string)
+	user.EmailVerified, _ = view["emailVerified"].(bool)
+	user.Role, _ = view["role"].(string)
+	user.Suspended, _ = view["suspended"].(bool)
+
+	return user
+}
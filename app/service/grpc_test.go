@@ -0,0 +1,198 @@
+package service_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/cfabrica46/gokit-crud/app/pb"
+	"github.com/cfabrica46/gokit-crud/app/service"
+	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/cfabrica46/gokit-crud/platform/logging"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCClient serves endpoints over an in-memory connection.
+func newGRPCClient(t *testing.T, endpoints service.GRPCEndpoints) pb.AppClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pb.RegisterAppServer(server, service.NewGRPCServer(endpoints, log.NewNopLogger()))
+
+	go func() { _ = server.Serve(listener) }()
+
+	conn, err := grpc.Dial(
+		"bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+
+	t.Cleanup(func() {
+		conn.Close()
+		server.Stop()
+	})
+
+	return pb.NewAppClient(conn)
+}
+
+func TestGRPCServer(t *testing.T) {
+	t.Parallel()
+
+	var requests []any
+
+	record := func(response any) func(context.Context, any) (any, error) {
+		return func(_ context.Context, request any) (any, error) {
+			requests = append(requests, request)
+
+			return response, nil
+		}
+	}
+
+	client := newGRPCClient(t, service.GRPCEndpoints{
+		SignUp:      record(service.TokenErrorResponse{Token: tokenTest, RefreshToken: refreshTokenTest}),
+		SignIn:      record(service.TokenErrorResponse{Token: tokenTest}),
+		LogOut:      record(service.ErrorResponse{}),
+		GetAllUsers: record(service.UsersErrorResponse{Users: []service.UserView{{"id": idTest, "username": usernameTest}}, Total: 1, Next: "next"}),
+		Profile: record(service.UserErrorResponse{User: service.UserView{
+			"id":            idTest,
+			"username":      usernameTest,
+			"email":         emailTest,
+			"emailVerified": true,
+			"role":          "user",
+			"suspended":     false,
+		}}),
+		DeleteAccount: record(service.ErrorResponse{}),
+	})
+
+	ctx := metadata.AppendToOutgoingContext(context.TODO(), "authorization", tokenTest)
+
+	var header metadata.MD
+
+	token, err := client.SignUp(
+		metadata.AppendToOutgoingContext(ctx, logging.RequestIDHeader, "request"),
+		&pb.SignUpRequest{Username: usernameTest, Password: passwordTest, Email: emailTest},
+		grpc.Header(&header),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"request"}, header.Get(logging.RequestIDHeader))
+	assert.Equal(t, tokenTest, token.Token)
+	assert.Equal(t, refreshTokenTest, token.RefreshToken)
+
+	token, err = client.SignIn(ctx, &pb.SignInRequest{Username: usernameTest, Password: passwordTest})
+	assert.NoError(t, err)
+	assert.Equal(t, tokenTest, token.Token)
+
+	_, err = client.LogOut(ctx, &pb.Empty{})
+	assert.NoError(t, err)
+
+	users, err := client.GetAllUsers(ctx, &pb.UsersRequest{Limit: 1, Username: "user", Fields: []string{"id", "username"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), users.Total)
+	assert.Equal(t, "next", users.Next)
+	assert.Equal(t, int64(idTest), users.Users[0].Id)
+	assert.Equal(t, usernameTest, users.Users[0].Username)
+	assert.Empty(t, users.Users[0].Email)
+
+	user, err := client.Profile(ctx, &pb.ProfileRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, emailTest, user.User.Email)
+	assert.True(t, user.User.EmailVerified)
+	assert.Equal(t, "user", user.User.Role)
+
+	_, err = client.DeleteAccount(ctx, &pb.Empty{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []any{
+		service.UsernamePasswordEmailRequest{Username: usernameTest, Password: passwordTest, Email: emailTest},
+		service.UsernamePasswordRequest{Username: usernameTest, Password: passwordTest},
+		service.TokenRequest{Token: tokenTest},
+		service.UsersQueryRequest{Query: dbapp.UsersQuery{UsernamePrefix: "user", Limit: 1}, Fields: []string{"id", "username"}},
+		service.TokenFieldsRequest{Token: tokenTest},
+		service.TokenRequest{Token: tokenTest},
+	}, requests)
+}
+
+func TestGRPCServerErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		inErr      error
+		name       string
+		inToken    string
+		outMessage string
+		outCode    codes.Code
+	}{
+		{
+			name:       "ErrorNotFound",
+			inToken:    tokenTest,
+			inErr:      &service.Error{Kind: service.ErrNotFound, Err: service.ErrRequest},
+			outMessage: service.ErrRequest.Error(),
+			outCode:    codes.NotFound,
+		},
+		{
+			name:       "ErrorTooManyRequests",
+			inToken:    tokenTest,
+			inErr:      &service.Error{Kind: service.ErrTooManyRequests, Err: service.ErrRequest},
+			outMessage: service.ErrRequest.Error(),
+			outCode:    codes.ResourceExhausted,
+		},
+		{
+			name:       "ErrorWebServer",
+			inToken:    tokenTest,
+			inErr:      &service.Error{Kind: service.ErrWebServer, Err: service.ErrRequest},
+			outMessage: "Bad Gateway",
+			outCode:    codes.Unavailable,
+		},
+		{
+			name:       "ErrorUnknown",
+			inToken:    tokenTest,
+			inErr:      service.ErrRequest,
+			outMessage: "Internal Server Error",
+			outCode:    codes.Internal,
+		},
+		{
+			name:       "ErrorNoToken",
+			outMessage: "failed to get header",
+			outCode:    codes.Unauthenticated,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client := newGRPCClient(t, service.GRPCEndpoints{
+				Profile: func(context.Context, any) (any, error) {
+					return nil, tt.inErr
+				},
+			})
+
+			ctx := context.TODO()
+			if tt.inToken != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tt.inToken)
+			}
+
+			_, err := client.Profile(ctx, &pb.ProfileRequest{})
+			assert.Equal(t, tt.outCode, status.Code(err))
+			assert.Equal(t, tt.outMessage, status.Convert(err).Message())
+		})
+	}
+}
+
+func TestGRPCError(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, service.GRPCError(nil))
+	assert.Equal(t, codes.InvalidArgument, status.Code(service.GRPCError(service.ErrUnknownField)))
+
+	err := status.Error(codes.Canceled, "canceled")
+	assert.Equal(t, err, service.GRPCError(err))
+}
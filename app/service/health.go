@@ -0,0 +1,46 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/cfabrica46/gokit-crud/platform/health"
+)
+
+// DownstreamCheck is a health.Check that a downstream service answers its
+// readiness probe at url. client should make a single attempt with a short
+// timeout, without a circuit breaker.
+func DownstreamCheck(client HTTPClient, url string) health.Check {
+	return func(ctx context.Context) error {
+		return getReady(ctx, client, url)
+	}
+}
+
+// getReady fails unless url answers 200, with the status the service gave.
+func getReady(ctx context.Context, client HTTPClient, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to probe: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body health.Health
+
+		if err = json.NewDecoder(resp.Body).Decode(&body); err != nil || body.Status == "" {
+			return fmt.Errorf("%w: %s", ErrWebServer, resp.Status)
+		}
+
+		return fmt.Errorf("%w: %s", ErrWebServer, body.Failures())
+	}
+
+	return nil
+}
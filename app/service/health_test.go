@@ -0,0 +1,93 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownstreamCheck(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name       string
+		inStatus   int
+		inBody     string
+		inErr      error
+		outErr     string
+		outWebKind bool
+	}{
+		{
+			name:     "NoError",
+			inStatus: http.StatusOK,
+			inBody:   `{"status":"ok","checks":{"postgres":"ok"}}`,
+		},
+		{
+			name:       "ErrorUnavailable",
+			inStatus:   http.StatusServiceUnavailable,
+			inBody:     `{"status":"shutting down"}`,
+			outErr:     "shutting down",
+			outWebKind: true,
+		},
+		{
+			name:       "ErrorChecks",
+			inStatus:   http.StatusServiceUnavailable,
+			inBody:     `{"status":"unavailable","checks":{"redis":"failed","postgres":"ok"}}`,
+			outErr:     "redis: failed",
+			outWebKind: true,
+		},
+		{
+			name:       "ErrorNotFound",
+			inStatus:   http.StatusNotFound,
+			inBody:     `404 page not found`,
+			outErr:     "404 Not Found",
+			outWebKind: true,
+		},
+		{
+			name:   "ErrorRequest",
+			inErr:  errors.New("connection refused"),
+			outErr: "connection refused",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var url string
+
+			client := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
+				url = req.URL.String()
+
+				if tt.inErr != nil {
+					return nil, tt.inErr
+				}
+
+				return &http.Response{
+					StatusCode: tt.inStatus,
+					Status:     fmt.Sprintf("%d %s", tt.inStatus, http.StatusText(tt.inStatus)),
+					Body:       io.NopCloser(strings.NewReader(tt.inBody)),
+				}, nil
+			})
+
+			err := service.DownstreamCheck(client, "http://db-app:7070/readyz")(context.TODO())
+
+			assert.Equal(t, "http://db-app:7070/readyz", url)
+
+			if tt.outErr == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.ErrorContains(t, err, tt.outErr)
+			assert.Equal(t, tt.outWebKind, errors.Is(err, service.ErrWebServer))
+		})
+	}
+}
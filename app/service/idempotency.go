@@ -0,0 +1,345 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/platform/logging"
+	tokenapp "github.com/cfabrica46/gokit-crud/token-app/service"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+const (
+	// IdempotencyKeyHeader carries the key a client retries a mutating
+	// request with, the retries get the response of the first request.
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// IdempotentReplayedHeader is set on the responses that are replayed.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+
+	// idempotencyReservation is how long a key stays reserved without a
+	// response, so that the key is not lost when a gateway dies while
+	// serving it.
+	idempotencyReservation = time.Minute
+)
+
+var (
+	ErrIdempotencyKeyReused = &Error{
+		Kind: ErrConflict,
+		Err:  errors.New("idempotency key already used with another request"),
+	}
+	ErrIdempotencyKeyInProgress = &Error{
+		Kind: ErrConflict,
+		Err:  errors.New("a request with the idempotency key is still in progress"),
+	}
+	errInvalidIdempotencyKey = &Error{Kind: ErrValidation, Err: errors.New("invalid idempotency key")}
+)
+
+// IdempotencyStore keeps the idempotency keys of the requests.
+type IdempotencyStore interface {
+	// Reserve claims key for the request of record until ttl passes, when
+	// the key is already taken it returns the record stored under it.
+	Reserve(
+		ctx context.Context,
+		key string,
+		record tokenapp.IdempotencyRecord,
+		ttl time.Duration,
+	) (tokenapp.IdempotencyRecord, bool, error)
+	// Save stores the response of the request that reserved key.
+	Save(ctx context.Context, key string, record tokenapp.IdempotencyRecord, ttl time.Duration) error
+	// Release frees key so that the request can be tried again.
+	Release(ctx context.Context, key string) error
+}
+
+// Idempotency replays the response of the first mutating request sent with
+// an Idempotency-Key to the requests sent again with the same key until ttl
+// passes. Reusing a key for another request is a conflict, and so it is
+// while the first request is still in progress. Transient failures, of the
+// gateway, of the downstream services or of a rate limit, are not stored,
+// the request can be retried.
+func Idempotency(store IdempotencyStore, ttl time.Duration, logger log.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || !isMutating(r.Method) {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			ctx := r.Context()
+
+			if len(key) > maxIdempotencyKeyLength {
+				EncodeError(ctx, errInvalidIdempotencyKey, w)
+
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				EncodeError(ctx, &Error{Kind: ErrValidation, Err: fmt.Errorf("failed to read request: %w", err)}, w)
+
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			key = idempotencyStoreKey(r, key)
+			fingerprint := requestFingerprint(r, body)
+
+			reservation := idempotencyReservation
+			if ttl < reservation {
+				reservation = ttl
+			}
+
+			stored, reserved, err := store.Reserve(ctx, key, tokenapp.IdempotencyRecord{Fingerprint: fingerprint}, reservation)
+			if err != nil {
+				_ = level.Error(logger).Log("msg", "failed to reserve idempotency key", "err", err)
+
+				EncodeError(ctx, err, w)
+
+				return
+			}
+
+			if !reserved {
+				replay(ctx, w, stored, fingerprint)
+
+				return
+			}
+
+			capture := &responseCapture{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(capture, r)
+
+			if transientStatus(capture.status) {
+				if err = store.Release(withoutCancel(ctx), key); err != nil {
+					_ = level.Warn(logger).Log("msg", "failed to release idempotency key", "err", err)
+				}
+
+				return
+			}
+
+			header := capture.Header().Clone()
+			header.Del(logging.RequestIDHeader)
+
+			if err = store.Save(withoutCancel(ctx), key, tokenapp.IdempotencyRecord{
+				Fingerprint: fingerprint,
+				Header:      header,
+				Body:        capture.body.Bytes(),
+				Status:      capture.status,
+			}, ttl); err != nil {
+				_ = level.Warn(logger).Log("msg", "failed to save idempotent response", "err", err)
+			}
+		})
+	}
+}
+
+// replay writes the response stored for a request sent again.
+func replay(ctx context.Context, w http.ResponseWriter, stored tokenapp.IdempotencyRecord, fingerprint string) {
+	switch {
+	case stored.Fingerprint != fingerprint:
+		EncodeError(ctx, ErrIdempotencyKeyReused, w)
+	case stored.Status == 0:
+		EncodeError(ctx, ErrIdempotencyKeyInProgress, w)
+	default:
+		for name, values := range stored.Header {
+			w.Header()[name] = values
+		}
+
+		w.Header().Set(IdempotentReplayedHeader, "true")
+		w.WriteHeader(stored.Status)
+		_, _ = w.Write(stored.Body)
+	}
+}
+
+// transientStatus tells the responses that may change when the request is
+// sent again later.
+func transientStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	default:
+		return status >= http.StatusInternalServerError
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// idempotencyStoreKey scopes the key to the caller, so that nobody can get
+// the response of someone else by sending the same key.
+func idempotencyStoreKey(r *http.Request, key string) string {
+	sum := sha256.Sum256([]byte(r.Header.Get("Authorization") + "\x00" + key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// requestFingerprint identifies the request a key was first used with.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	_, _ = io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\x00")
+	_, _ = hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseCapture keeps a copy of the response written through it.
+type responseCapture struct {
+	http.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+// WriteHeader ...
+func (c *responseCapture) WriteHeader(status int) {
+	c.status = status
+	c.ResponseWriter.WriteHeader(status)
+}
+
+// Write ...
+func (c *responseCapture) Write(b []byte) (int, error) {
+	c.body.Write(b)
+
+	return c.ResponseWriter.Write(b)
+}
+
+// TokenIdempotencyStore keeps the idempotency keys in token-app, shared by
+// every gateway replica.
+type TokenIdempotencyStore struct {
+	client    HTTPClient
+	tokenHost string
+}
+
+// NewTokenIdempotencyStore ...
+func NewTokenIdempotencyStore(client HTTPClient, is *InfoServices) *TokenIdempotencyStore {
+	return &TokenIdempotencyStore{client, "http://" + is.TokenHost + ":" + is.TokenPort}
+}
+
+// Reserve ...
+func (s *TokenIdempotencyStore) Reserve(
+	ctx context.Context,
+	key string,
+	record tokenapp.IdempotencyRecord,
+	ttl time.Duration,
+) (tokenapp.IdempotencyRecord, bool, error) {
+	var response tokenapp.IdempotencyErrResponse
+
+	if err := RequestFunc(
+		ctx,
+		s.client,
+		tokenapp.IdempotencyRequest{Key: key, Record: record, TTL: ttl},
+		NewHTTPComponents(s.tokenHost+"/idempotency", http.MethodPost),
+		&response,
+	); err != nil {
+		return tokenapp.IdempotencyRecord{}, false, err
+	}
+
+	return response.Record, response.Reserved, nil
+}
+
+// Save ...
+func (s *TokenIdempotencyStore) Save(ctx context.Context, key string, record tokenapp.IdempotencyRecord, ttl time.Duration) error {
+	var response tokenapp.ErrorResponse
+
+	return RequestFunc(
+		ctx,
+		s.client,
+		tokenapp.IdempotencyRequest{Key: key, Record: record, TTL: ttl},
+		NewHTTPComponents(s.tokenHost+"/idempotency", http.MethodPut),
+		&response,
+	)
+}
+
+// Release ...
+func (s *TokenIdempotencyStore) Release(ctx context.Context, key string) error {
+	var response tokenapp.ErrorResponse
+
+	return RequestFunc(
+		ctx,
+		s.client,
+		tokenapp.IdempotencyRequest{Key: key},
+		NewHTTPComponents(s.tokenHost+"/idempotency", http.MethodDelete),
+		&response,
+	)
+}
+
+// MemoryIdempotencyStore keeps the idempotency keys in memory, for a single
+// gateway.
+type MemoryIdempotencyStore struct {
+	records map[string]memoryIdempotencyRecord
+	mtx     sync.Mutex
+}
+
+type memoryIdempotencyRecord struct {
+	expires time.Time
+	record  tokenapp.IdempotencyRecord
+}
+
+// NewMemoryIdempotencyStore ...
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{records: map[string]memoryIdempotencyRecord{}}
+}
+
+// Reserve ...
+func (s *MemoryIdempotencyStore) Reserve(
+	_ context.Context,
+	key string,
+	record tokenapp.IdempotencyRecord,
+	ttl time.Duration,
+) (tokenapp.IdempotencyRecord, bool, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	now := time.Now()
+
+	// expired keys are dropped on the next reservation.
+	for k, stored := range s.records {
+		if now.After(stored.expires) {
+			delete(s.records, k)
+		}
+	}
+
+	if stored, ok := s.records[key]; ok {
+		return stored.record, false, nil
+	}
+
+	s.records[key] = memoryIdempotencyRecord{expires: now.Add(ttl), record: record}
+
+	return record, true, nil
+}
+
+// Save ...
+func (s *MemoryIdempotencyStore) Save(_ context.Context, key string, record tokenapp.IdempotencyRecord, ttl time.Duration) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.records[key] = memoryIdempotencyRecord{expires: time.Now().Add(ttl), record: record}
+
+	return nil
+}
+
+// Release ...
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	delete(s.records, key)
+
+	return nil
+}
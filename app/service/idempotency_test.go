@@ -0,0 +1,261 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	tokenapp "github.com/cfabrica46/gokit-crud/token-app/service"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+const idempotencyKeyTest string = "key"
+
+type idempotentRequest struct {
+	method, body, key, token string
+}
+
+func TestIdempotency(t *testing.T) {
+	t.Parallel()
+
+	signUp := idempotentRequest{method: http.MethodPost, body: `{"username":"username"}`, key: idempotencyKeyTest}
+
+	for _, tt := range []struct {
+		name        string
+		inStatus    int
+		inRequests  []idempotentRequest
+		outStatuses []int
+		outCalls    int
+		outReplayed bool
+	}{
+		{
+			name:        "Replayed",
+			inStatus:    http.StatusCreated,
+			inRequests:  []idempotentRequest{signUp, signUp},
+			outStatuses: []int{http.StatusCreated, http.StatusCreated},
+			outCalls:    1,
+			outReplayed: true,
+		},
+		{
+			name:        "ClientErrorReplayed",
+			inStatus:    http.StatusConflict,
+			inRequests:  []idempotentRequest{signUp, signUp},
+			outStatuses: []int{http.StatusConflict, http.StatusConflict},
+			outCalls:    1,
+			outReplayed: true,
+		},
+		{
+			name:        "ServerErrorRetried",
+			inStatus:    http.StatusBadGateway,
+			inRequests:  []idempotentRequest{signUp, signUp},
+			outStatuses: []int{http.StatusBadGateway, http.StatusBadGateway},
+			outCalls:    2,
+		},
+		{
+			name:        "TooManyRequestsRetried",
+			inStatus:    http.StatusTooManyRequests,
+			inRequests:  []idempotentRequest{signUp, signUp},
+			outStatuses: []int{http.StatusTooManyRequests, http.StatusTooManyRequests},
+			outCalls:    2,
+		},
+		{
+			name:     "ErrorKeyReused",
+			inStatus: http.StatusCreated,
+			inRequests: []idempotentRequest{
+				signUp,
+				{method: http.MethodPost, body: `{"username":"other"}`, key: idempotencyKeyTest},
+			},
+			outStatuses: []int{http.StatusCreated, http.StatusConflict},
+			outCalls:    1,
+		},
+		{
+			name:     "OtherCaller",
+			inStatus: http.StatusCreated,
+			inRequests: []idempotentRequest{
+				signUp,
+				{method: http.MethodPost, body: signUp.body, key: idempotencyKeyTest, token: tokenTest},
+			},
+			outStatuses: []int{http.StatusCreated, http.StatusCreated},
+			outCalls:    2,
+		},
+		{
+			name:     "WithoutKey",
+			inStatus: http.StatusCreated,
+			inRequests: []idempotentRequest{
+				{method: http.MethodPost, body: signUp.body},
+				{method: http.MethodPost, body: signUp.body},
+			},
+			outStatuses: []int{http.StatusCreated, http.StatusCreated},
+			outCalls:    2,
+		},
+		{
+			name:     "NotMutating",
+			inStatus: http.StatusOK,
+			inRequests: []idempotentRequest{
+				{method: http.MethodGet, key: idempotencyKeyTest},
+				{method: http.MethodGet, key: idempotencyKeyTest},
+			},
+			outStatuses: []int{http.StatusOK, http.StatusOK},
+			outCalls:    2,
+		},
+		{
+			name:     "ErrorKeyTooLong",
+			inStatus: http.StatusCreated,
+			inRequests: []idempotentRequest{
+				{method: http.MethodPost, body: signUp.body, key: strings.Repeat("k", 256)},
+			},
+			outStatuses: []int{http.StatusBadRequest},
+			outCalls:    0,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls int
+
+			handler := service.Idempotency(
+				service.NewMemoryIdempotencyStore(),
+				time.Hour,
+				log.NewNopLogger(),
+			)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+
+				body, _ := io.ReadAll(r.Body)
+				assert.Equal(t, tt.inRequests[0].body, string(body))
+
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.inStatus)
+				_, _ = w.Write([]byte(`{"token":"token"}`))
+			}))
+
+			var last *httptest.ResponseRecorder
+
+			for i, in := range tt.inRequests {
+				req := httptest.NewRequest(in.method, "/signup", strings.NewReader(in.body))
+				if in.key != "" {
+					req.Header.Set(service.IdempotencyKeyHeader, in.key)
+				}
+
+				if in.token != "" {
+					req.Header.Set("Authorization", in.token)
+				}
+
+				last = httptest.NewRecorder()
+				handler.ServeHTTP(last, req)
+
+				assert.Equal(t, tt.outStatuses[i], last.Code)
+			}
+
+			assert.Equal(t, tt.outCalls, calls)
+
+			if tt.outReplayed {
+				assert.Equal(t, "true", last.Header().Get(service.IdempotentReplayedHeader))
+				assert.Equal(t, "application/json", last.Header().Get("Content-Type"))
+				assert.Equal(t, `{"token":"token"}`, last.Body.String())
+			} else {
+				assert.Empty(t, last.Header().Get(service.IdempotentReplayedHeader))
+			}
+		})
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	t.Parallel()
+
+	store := service.NewMemoryIdempotencyStore()
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	handler := service.Idempotency(store, time.Hour, log.NewNopLogger())(
+		http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			close(started)
+			<-release
+			w.WriteHeader(http.StatusCreated)
+		}),
+	)
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodDelete, "/profile", nil)
+		req.Header.Set(service.IdempotencyKeyHeader, idempotencyKeyTest)
+		req.Header.Set("Authorization", tokenTest)
+
+		return req
+	}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), newRequest())
+	}()
+
+	<-started
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequest())
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	close(release)
+	<-done
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, newRequest())
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "true", w.Header().Get(service.IdempotentReplayedHeader))
+}
+
+func TestTokenIdempotencyStore(t *testing.T) {
+	t.Parallel()
+
+	stored := tokenapp.IdempotencyRecord{Fingerprint: "fingerprint", Status: http.StatusCreated}
+
+	var calls []string
+
+	mock := service.NewMockClient(func(r *http.Request) (*http.Response, error) {
+		calls = append(calls, r.Method+" "+r.URL.String())
+
+		var req tokenapp.IdempotencyRequest
+
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, idempotencyKeyTest, req.Key)
+
+		body := `{}`
+		if r.Method == http.MethodPost {
+			assert.Equal(t, time.Minute, req.TTL)
+
+			response, _ := json.Marshal(tokenapp.IdempotencyErrResponse{Record: stored})
+			body = string(response)
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	})
+
+	store := service.NewTokenIdempotencyStore(mock, &service.InfoServices{TokenHost: tokenHostTest, TokenPort: portTest})
+
+	record, reserved, err := store.Reserve(context.TODO(), idempotencyKeyTest, tokenapp.IdempotencyRecord{}, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, stored, record)
+
+	assert.NoError(t, store.Save(context.TODO(), idempotencyKeyTest, stored, time.Hour))
+	assert.NoError(t, store.Release(context.TODO(), idempotencyKeyTest))
+
+	assert.Equal(t, []string{
+		"POST http://token:8080/idempotency",
+		"PUT http://token:8080/idempotency",
+		"DELETE http://token:8080/idempotency",
+	}, calls)
+}
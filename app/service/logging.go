@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cfabrica46/gokit-crud/platform/logging"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/go-kit/log"
+	"github.com/golang-jwt/jwt"
+)
+
+// ServerLogging puts the request ID and the ID of the user of the token in
+// the context, echoes the request ID, EncodeError does it for failures, and
+// logs every request once it is answered.
+func ServerLogging(logger log.Logger) []httptransport.ServerOption {
+	return logging.ServerOptions(logger, func(r *http.Request) string {
+		return userIDFromToken(r.Header.Get("Authorization"))
+	})
+}
+
+// userIDFromToken reads the user ID of a token without verifying it, it is
+// only used to correlate the logs.
+func userIDFromToken(token string) string {
+	if token == "" {
+		return ""
+	}
+
+	t, _, err := new(jwt.Parser).ParseUnverified(strings.TrimPrefix(token, "Bearer "), jwt.MapClaims{})
+	if err != nil {
+		return ""
+	}
+
+	claims, _ := t.Claims.(jwt.MapClaims)
+
+	id, ok := claims["id"].(float64)
+	if !ok {
+		return ""
+	}
+
+	return strconv.Itoa(int(id))
+}
+
+// forwardRequestIDs passes the request and user IDs of ctx on to a
+// downstream call.
+func forwardRequestIDs(ctx context.Context, header http.Header) {
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		header.Set(logging.RequestIDHeader, requestID)
+	}
+
+	if id := logging.UserID(ctx); id != "" {
+		header.Set(logging.UserIDHeader, id)
+	}
+}
+
+// LoggingMiddleware logs the calls of the endpoint of method, the failures
+// with a 5xx StatusCode as errors.
+func LoggingMiddleware(logger log.Logger, method string) endpoint.Middleware {
+	return logging.Middleware(logger, method, StatusCode)
+}
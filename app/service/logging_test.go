@@ -0,0 +1,231 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/cfabrica46/gokit-crud/platform/logging"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+var errEndpoint = errors.New("endpoint error")
+
+// signedToken is a token of the user id, the gateway reads it unverified.
+func signedToken(t *testing.T, id int) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": id}).SignedString([]byte("secret"))
+	assert.NoError(t, err)
+
+	return token
+}
+
+// logLines decodes the JSON lines written by a logger.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var lines []map[string]any
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var decoded map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(line), &decoded))
+
+		lines = append(lines, decoded)
+	}
+
+	return lines
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		inErr    error
+		name     string
+		inLevel  string
+		outLevel string
+		outLines int
+	}{
+		{
+			name:     nameNoError,
+			inLevel:  "debug",
+			outLevel: "debug",
+			outLines: 1,
+		},
+		{
+			name:     "NoErrorInfo",
+			inLevel:  "info",
+			outLines: 0,
+		},
+		{
+			name:     "ErrorUnauthorized",
+			inLevel:  "info",
+			inErr:    service.ErrWrongPassword,
+			outLevel: "warn",
+			outLines: 1,
+		},
+		{
+			name:     "ErrorInternal",
+			inLevel:  "error",
+			inErr:    errEndpoint,
+			outLevel: "error",
+			outLines: 1,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			logger := logging.NewLogger(&buf, "json", tt.inLevel)
+
+			e := service.LoggingMiddleware(logger, "SignIn")(func(context.Context, any) (any, error) {
+				return nil, tt.inErr
+			})
+
+			_, _ = e(context.TODO(), service.UsernamePasswordRequest{Password: "hunter2"})
+
+			lines := logLines(t, &buf)
+			if !assert.Len(t, lines, tt.outLines) || tt.outLines == 0 {
+				return
+			}
+
+			assert.Equal(t, tt.outLevel, lines[0]["level"])
+			assert.Equal(t, "SignIn", lines[0]["endpoint"])
+			assert.NotContains(t, buf.String(), "hunter2")
+		})
+	}
+}
+
+func TestServerLogging(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		inErr        error
+		name         string
+		inRequestID  string
+		outRequestID string
+		outCode      float64
+	}{
+		{
+			name:         nameNoError,
+			inRequestID:  "request-1",
+			outRequestID: "request-1",
+			outCode:      http.StatusOK,
+		},
+		{
+			name:         "ErrorUnauthorized",
+			inRequestID:  "request-2",
+			inErr:        service.ErrWrongPassword,
+			outRequestID: "request-2",
+			outCode:      http.StatusUnauthorized,
+		},
+		{
+			name:    "GeneratedID",
+			outCode: http.StatusOK,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			handler := httptransport.NewServer(
+				func(context.Context, any) (any, error) { return service.ErrorResponse{}, tt.inErr },
+				func(context.Context, *http.Request) (any, error) { return nil, nil },
+				service.EncodeResponse,
+				append(
+					[]httptransport.ServerOption{httptransport.ServerErrorEncoder(service.EncodeError)},
+					service.ServerLogging(logging.NewLogger(&buf, "json", "info"))...,
+				)...,
+			)
+
+			req := httptest.NewRequest(http.MethodPost, "/profile", nil)
+			req.Header.Set("Authorization", signedToken(t, 1))
+
+			if tt.inRequestID != "" {
+				req.Header.Set(logging.RequestIDHeader, tt.inRequestID)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			requestID := w.Header().Get(logging.RequestIDHeader)
+			if tt.outRequestID != "" {
+				assert.Equal(t, tt.outRequestID, requestID)
+			} else {
+				assert.Len(t, requestID, 32)
+			}
+
+			lines := logLines(t, &buf)
+			if !assert.Len(t, lines, 1) {
+				return
+			}
+
+			assert.Equal(t, "/profile", lines[0]["path"])
+			assert.Equal(t, tt.outCode, lines[0]["code"])
+			assert.Equal(t, requestID, lines[0]["request_id"])
+			assert.Equal(t, "1", lines[0]["user_id"])
+		})
+	}
+}
+
+func TestRequestFuncForwardsIDs(t *testing.T) {
+	t.Parallel()
+
+	var header http.Header
+
+	mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
+		header = req.Header
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"id": 1}`)),
+		}, nil
+	})
+
+	handler := httptransport.NewServer(
+		func(ctx context.Context, _ any) (any, error) {
+			var response dbapp.IDErrorResponse
+
+			err := service.RequestFunc(
+				ctx,
+				mock,
+				nil,
+				service.NewHTTPComponents("http://db-app:7070/id/username", http.MethodGet),
+				&response,
+			)
+
+			return response, err
+		},
+		func(context.Context, *http.Request) (any, error) { return nil, nil },
+		service.EncodeResponse,
+		service.ServerLogging(logging.NewLogger(io.Discard, "json", "info"))...,
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/profile", nil)
+	req.Header.Set(logging.RequestIDHeader, "request-1")
+	req.Header.Set("Authorization", signedToken(t, 7))
+
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "request-1", header.Get(logging.RequestIDHeader))
+	assert.Equal(t, "7", header.Get(logging.UserIDHeader))
+}
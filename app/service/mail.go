@@ -0,0 +1,56 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const outboxPermissions = 0o750
+
+// MailSender delivers emails to the users.
+type MailSender interface {
+	Send(to, subject, body string) error
+}
+
+// OutboxSender writes every email as a file inside a directory, it's meant
+// for local development where there isn't a mail server.
+type OutboxSender struct {
+	dir string
+}
+
+// NewOutboxSender ...
+func NewOutboxSender(dir string) *OutboxSender {
+	return &OutboxSender{dir: dir}
+}
+
+// Send ...
+func (o *OutboxSender) Send(to, subject, body string) (err error) {
+	if err = os.MkdirAll(o.dir, outboxPermissions); err != nil {
+		return fmt.Errorf("error to create outbox: %w", err)
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), sanitizeFileName(to))
+	message := fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s\r\n", to, subject, body)
+
+	if err = os.WriteFile(filepath.Join(o.dir, name), []byte(message), 0o600); err != nil {
+		return fmt.Errorf("error to write email: %w", err)
+	}
+
+	return nil
+}
+
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '@', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -0,0 +1,71 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxSender(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name      string
+		inTo      string
+		inSubject string
+		inBody    string
+		outErr    string
+		isBadDir  bool
+	}{
+		{
+			name:      nameNoError,
+			inTo:      emailTest,
+			inSubject: "subject",
+			inBody:    "body",
+			outErr:    "",
+		},
+		{
+			name:      "ErrorBadDir",
+			inTo:      emailTest,
+			inSubject: "subject",
+			inBody:    "body",
+			isBadDir:  true,
+			outErr:    "error to create outbox",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := filepath.Join(t.TempDir(), "outbox")
+
+			if tt.isBadDir {
+				err := os.WriteFile(dir, []byte{}, 0o600)
+				assert.Nil(t, err)
+			}
+
+			err := service.NewOutboxSender(dir).Send(tt.inTo, tt.inSubject, tt.inBody)
+
+			if tt.outErr != "" {
+				assert.ErrorContains(t, err, tt.outErr)
+
+				return
+			}
+
+			assert.Nil(t, err)
+
+			files, err := os.ReadDir(dir)
+			assert.Nil(t, err)
+			assert.Len(t, files, 1)
+
+			content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+			assert.Nil(t, err)
+			assert.Contains(t, string(content), "To: "+tt.inTo)
+			assert.Contains(t, string(content), "Subject: "+tt.inSubject)
+			assert.Contains(t, string(content), tt.inBody)
+		})
+	}
+}
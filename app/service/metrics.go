@@ -0,0 +1,78 @@
+package service
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricsNamespace prefixes the metrics of the service.
+const MetricsNamespace = "app"
+
+// DownstreamMetrics are the instruments of the calls to database-app and
+// token-app, labelled by host, method and path.
+type DownstreamMetrics struct {
+	Requests metrics.Counter
+	Latency  metrics.Histogram
+}
+
+// NewDownstreamMetrics registers the downstream instruments in reg.
+func NewDownstreamMetrics(reg prometheus.Registerer) *DownstreamMetrics {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: "downstream",
+		Name:      "requests_total",
+		Help:      "Number of calls made to database-app and token-app.",
+	}, []string{"host", "method", "path", "code"})
+
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: "downstream",
+		Name:      "request_duration_seconds",
+		Help:      "Time spent in the calls to database-app and token-app.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"host", "method", "path"})
+
+	reg.MustRegister(requests, latency)
+
+	return &DownstreamMetrics{
+		Requests: kitprometheus.NewCounter(requests),
+		Latency:  kitprometheus.NewHistogram(latency),
+	}
+}
+
+// InstrumentedClient is an HTTPClient that counts the calls made through it
+// and observes how long they take.
+type InstrumentedClient struct {
+	next    HTTPClient
+	metrics *DownstreamMetrics
+}
+
+// NewInstrumentedClient wraps next, it goes outside of the other clients so
+// that the calls are labelled with the host they were made to.
+func NewInstrumentedClient(next HTTPClient, m *DownstreamMetrics) *InstrumentedClient {
+	return &InstrumentedClient{next: next, metrics: m}
+}
+
+// Do counts calls that got no response with the code "error".
+func (c *InstrumentedClient) Do(req *http.Request) (resp *http.Response, err error) {
+	begin := time.Now()
+
+	resp, err = c.next.Do(req)
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+
+	labels := []string{"host", req.URL.Host, "method", req.Method, "path", req.URL.Path}
+
+	c.metrics.Requests.With(append(labels, "code", code)...).Add(1)
+	c.metrics.Latency.With(labels...).Observe(time.Since(begin).Seconds())
+
+	return resp, err
+}
@@ -0,0 +1,76 @@
+package service_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentedClient(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		inErr   error
+		name    string
+		outCode string
+	}{
+		{
+			name:    nameNoError,
+			outCode: "200",
+		},
+		{
+			name:    "ErrorService",
+			inErr:   errWebServer,
+			outCode: "error",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mock := service.NewMockClient(func(_ *http.Request) (*http.Response, error) {
+				if tt.inErr != nil {
+					return nil, tt.inErr
+				}
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{"id": 1}`)),
+				}, nil
+			})
+
+			reg := prometheus.NewRegistry()
+			client := service.NewInstrumentedClient(mock, service.NewDownstreamMetrics(reg))
+
+			var response dbapp.IDErrorResponse
+
+			for i := 0; i < 2; i++ {
+				_ = service.RequestFunc(
+					context.TODO(),
+					client,
+					nil,
+					service.NewHTTPComponents("http://db:7070/id/username", http.MethodGet),
+					&response,
+				)
+			}
+
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP app_downstream_requests_total Number of calls made to database-app and token-app.
+# TYPE app_downstream_requests_total counter
+app_downstream_requests_total{code="`+tt.outCode+`",host="db:7070",method="GET",path="/id/username"} 2
+`), "app_downstream_requests_total"))
+
+			count, err := testutil.GatherAndCount(reg, "app_downstream_request_duration_seconds")
+			assert.NoError(t, err)
+			assert.Equal(t, 1, count)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+type contextKey int
+
+const (
+	tokenContextKey contextKey = iota
+	clientIPContextKey
+)
+
+// MakeRequireRoleMiddleware only lets through callers whose token carries the
+// role, the token is read from the context filled by TokenToContext.
+func MakeRequireRoleMiddleware(svc serviceInterface, role string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request any) (any, error) {
+			token, _ := ctx.Value(tokenContextKey).(string)
+
+			if err := svc.Authorize(ctx, token, role); err != nil {
+				return nil, err
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
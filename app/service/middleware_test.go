@@ -0,0 +1,106 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMakeRequireRoleMiddleware(t *testing.T) {
+	t.Parallel()
+
+	infoServiceTest := service.InfoServices{
+		DBHost:    dbHostTest,
+		DBPort:    portTest,
+		TokenHost: tokenHostTest,
+		TokenPort: portTest,
+		Secret:    secretTest,
+	}
+
+	for _, tt := range []struct {
+		name       string
+		inHeader   string
+		inRole     string
+		outErr     string
+		outStatus  int
+		outReached bool
+	}{
+		{
+			name:       nameNoError,
+			inHeader:   tokenTest,
+			inRole:     dbapp.RoleAdmin,
+			outReached: true,
+		},
+		{
+			name:      "ErrorForbidden",
+			inHeader:  tokenTest,
+			inRole:    dbapp.RoleUser,
+			outErr:    service.ErrForbidden.Error(),
+			outStatus: http.StatusForbidden,
+		},
+		{
+			name:      "ErrorWithoutToken",
+			inHeader:  "",
+			inRole:    dbapp.RoleAdmin,
+			outErr:    service.ErrTokenNotValid.Error(),
+			outStatus: http.StatusUnauthorized,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var reached bool
+
+			mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body: ioutil.NopCloser(bytes.NewReader([]byte(`{
+						"check":true,
+						"id":1,
+						"role":"` + tt.inRole + `"
+					}`))),
+				}, nil
+			})
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			req, err := http.NewRequest(http.MethodGet, urlTest, nil)
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			req.Header.Set("Authorization", tt.inHeader)
+
+			ctx := service.TokenToContext()(context.TODO(), req)
+
+			next := func(_ context.Context, _ any) (any, error) {
+				reached = true
+
+				return service.ErrorResponse{}, nil
+			}
+
+			r, err := service.MakeRequireRoleMiddleware(svc, dbapp.RoleAdmin)(next)(ctx, service.EmptyRequest{})
+
+			if tt.outErr == "" {
+				assert.Nil(t, err)
+				assert.IsType(t, service.ErrorResponse{}, r)
+			} else {
+				assert.ErrorContains(t, err, tt.outErr)
+				assert.Equal(t, tt.outStatus, service.StatusCode(err))
+			}
+
+			assert.Equal(t, tt.outReached, reached)
+		})
+	}
+}
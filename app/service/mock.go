@@ -20,3 +20,24 @@ func NewMockClient(d myDoFunc) *MockClient {
 func (m *MockClient) Do(req *http.Request) (*http.Response, error) {
 	return m.doFunc(req)
 }
+
+type mySendFunc func(to, subject, body string) error
+
+// MockMailSender ...
+type MockMailSender struct {
+	sendFunc mySendFunc
+}
+
+// NewMockMailSender ...
+func NewMockMailSender(s mySendFunc) *MockMailSender {
+	return &MockMailSender{s}
+}
+
+// Send ...
+func (m *MockMailSender) Send(to, subject, body string) error {
+	if m.sendFunc == nil {
+		return nil
+	}
+
+	return m.sendFunc(to, subject, body)
+}
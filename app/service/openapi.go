@@ -0,0 +1,318 @@
+package service
+
+import (
+	_ "embed"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+//go:generate go test -run TestOpenAPI -update .
+
+// openAPIDocument is the spec served at /openapi.json, TestOpenAPI checks
+// that it is the one OpenAPI generates.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// Parameter is a query parameter of an operation.
+type Parameter struct {
+	Name, Type string
+	Required   bool
+}
+
+// Operation documents a route: where its request is read from and what it
+// answers with.
+type Operation struct {
+	// Body is the JSON body of the request, nil when it has none.
+	Body     any
+	Response any
+	Name     string
+	Method   string
+	Path     string
+	Summary  string
+	Query    []Parameter
+	// Authorized operations read the token from the Authorization header.
+	Authorized bool
+	// Idempotent operations replay their response to the requests sent
+	// again with the same Idempotency-Key.
+	Idempotent bool
+}
+
+// Operations are the routes of the gateway but the probes and the chat, a
+// WebSocket.
+var Operations = []Operation{
+	{
+		Name: "SignUp", Method: http.MethodPost, Path: "/signup",
+		Summary: "Create a user and sign it in.",
+		Body:    UsernamePasswordEmailRequest{}, Response: TokenErrorResponse{},
+		Idempotent: true,
+	},
+	{
+		Name: "SignIn", Method: http.MethodPost, Path: "/signin",
+		Summary: "Open a session.",
+		Body:    UsernamePasswordRequest{}, Response: TokenErrorResponse{},
+	},
+	{
+		Name: "LogOut", Method: http.MethodPost, Path: "/logout",
+		Summary:    "Close the session of the token.",
+		Authorized: true, Response: ErrorResponse{},
+	},
+	{
+		Name: "GetAllUsers", Method: http.MethodGet, Path: "/users",
+		Summary: "Page through the users, only for admins.",
+		Query: []Parameter{
+			{Name: "limit", Type: "integer"},
+			{Name: "cursor", Type: "string"},
+			{Name: "username", Type: "string"},
+			{Name: "email", Type: "string"},
+			{Name: "sort", Type: "string"},
+			{Name: "fields", Type: "string"},
+		},
+		Authorized: true, Response: UsersErrorResponse{},
+	},
+	{
+		Name: "Profile", Method: http.MethodPost, Path: "/profile",
+		Summary:    "Get the user of the token.",
+		Query:      []Parameter{{Name: "fields", Type: "string"}},
+		Authorized: true, Response: UserErrorResponse{},
+	},
+	{
+		Name: "DeleteAccount", Method: http.MethodDelete, Path: "/profile",
+		Summary:    "Delete the user of the token.",
+		Authorized: true, Response: ErrorResponse{},
+		Idempotent: true,
+	},
+	{
+		Name: "UpdateProfile", Method: http.MethodPut, Path: "/profile",
+		Summary:    "Change the username and email of the user of the token.",
+		Authorized: true, Body: TokenUsernameEmailRequest{}, Response: TokenErrorResponse{},
+	},
+	{
+		Name: "ChangePassword", Method: http.MethodPost, Path: "/profile/password",
+		Summary:    "Change the password of the user of the token.",
+		Authorized: true, Body: TokenOldNewPasswordRequest{}, Response: ErrorResponse{},
+	},
+	{
+		Name: "ForgotPassword", Method: http.MethodPost, Path: "/password/forgot",
+		Summary: "Mail a reset password token.",
+		Body:    EmailRequest{}, Response: ErrorResponse{},
+		Idempotent: true,
+	},
+	{
+		Name: "ResetPassword", Method: http.MethodPost, Path: "/password/reset",
+		Summary: "Set the password with a reset password token.",
+		Body:    TokenPasswordRequest{}, Response: ErrorResponse{},
+	},
+	{
+		Name: "VerifyEmail", Method: http.MethodGet, Path: "/verify-email",
+		Summary:  "Verify the email with the token of the verification mail.",
+		Query:    []Parameter{{Name: "token", Type: "string", Required: true}},
+		Response: ErrorResponse{},
+	},
+	{
+		Name: "RefreshToken", Method: http.MethodPost, Path: "/token/refresh",
+		Summary: "Trade a refresh token for a new session.",
+		Body:    RefreshTokenRequest{}, Response: TokenErrorResponse{},
+	},
+	{
+		Name: "DeleteUser", Method: http.MethodDelete, Path: "/users",
+		Summary:    "Delete any user, only for admins.",
+		Authorized: true, Body: IDRequest{}, Response: ErrorResponse{},
+		Idempotent: true,
+	},
+	{
+		Name: "SuspendUser", Method: http.MethodPatch, Path: "/users/suspended",
+		Summary:    "Suspend or reinstate any user, only for admins.",
+		Authorized: true, Body: IDSuspendedRequest{}, Response: ErrorResponse{},
+		Idempotent: true,
+	},
+}
+
+// OpenAPIHandler serves the OpenAPI document of the gateway.
+func OpenAPIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openAPIDocument)
+	})
+}
+
+// OpenAPI is the OpenAPI 3 document of the operations, their schemas are
+// read from the JSON names of the request and response structs.
+func OpenAPI(operations []Operation) map[string]any {
+	schemas := newSchemas()
+	paths := map[string]any{}
+
+	for _, op := range operations {
+		parameters := []any{}
+
+		for _, p := range op.Query {
+			parameters = append(parameters, map[string]any{
+				"name":     p.Name,
+				"in":       "query",
+				"required": p.Required,
+				"schema":   map[string]any{"type": p.Type},
+			})
+		}
+
+		if op.Idempotent {
+			parameters = append(parameters, map[string]any{
+				"name":        IdempotencyKeyHeader,
+				"in":          "header",
+				"description": "Replays the response of the first request sent with the key.",
+				"schema":      map[string]any{"type": "string", "maxLength": maxIdempotencyKeyLength},
+			})
+		}
+
+		operation := map[string]any{
+			"operationId": op.Name,
+			"summary":     op.Summary,
+			"responses": map[string]any{
+				"200": map[string]any{
+					"description": "OK",
+					"content":     jsonContent("application/json", schemas.of(reflect.TypeOf(op.Response))),
+				},
+				"default": map[string]any{
+					"description": "Problem",
+					"content":     jsonContent("application/problem+json", schemas.of(reflect.TypeOf(Problem{}))),
+				},
+			},
+		}
+
+		if len(parameters) > 0 {
+			operation["parameters"] = parameters
+		}
+
+		if op.Body != nil {
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content":  jsonContent("application/json", schemas.of(reflect.TypeOf(op.Body))),
+			}
+		}
+
+		if op.Authorized {
+			operation["security"] = []any{map[string]any{"token": []any{}}}
+		}
+
+		item, _ := paths[op.Path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[op.Path] = item
+		}
+
+		item[strings.ToLower(op.Method)] = operation
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info":    map[string]any{"title": "app", "version": "1.0.0"},
+		"paths":   paths,
+		"components": map[string]any{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]any{
+				"token": map[string]any{"type": "apiKey", "in": "header", "name": "Authorization"},
+			},
+		},
+	}
+}
+
+func jsonContent(mediaType string, schema map[string]any) map[string]any {
+	return map[string]any{mediaType: map[string]any{"schema": schema}}
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// schemas keeps the schemas of the named structs as components.
+type schemas struct {
+	components map[string]any
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: map[string]any{}}
+}
+
+// of is the schema of t, a reference for named structs.
+func (s *schemas) of(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]any{"type": "integer", "format": "int64", "description": "nanoseconds"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.of(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+
+		return map[string]any{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+
+		if _, ok := s.components[t.Name()]; !ok {
+			// reserved first, so that a struct can refer to itself.
+			s.components[t.Name()] = nil
+			s.components[t.Name()] = s.object(t)
+		}
+
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]any{}
+	}
+}
+
+// object is the schema of the JSON fields of a struct, those without
+// omitempty are required.
+func (s *schemas) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = s.of(field.Type)
+
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
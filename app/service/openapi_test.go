@@ -0,0 +1,64 @@
+package service_test
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "write the generated openapi.json")
+
+func TestOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	generated, err := json.MarshalIndent(service.OpenAPI(service.Operations), "", "  ")
+	assert.NoError(t, err)
+
+	generated = append(generated, '\n')
+
+	if *update {
+		assert.NoError(t, os.WriteFile("openapi.json", generated, 0o644))
+
+		return
+	}
+
+	w := httptest.NewRecorder()
+	service.OpenAPIHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, string(generated), w.Body.String(), "openapi.json is out of date, run go generate ./service")
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	t.Parallel()
+
+	document := service.OpenAPI([]service.Operation{{
+		Name:     "UsersQuery",
+		Method:   http.MethodGet,
+		Path:     "/users",
+		Body:     service.UsersQueryRequest{},
+		Response: service.UsersErrorResponse{},
+	}})
+
+	schemas := document["components"].(map[string]any)["schemas"].(map[string]any)
+
+	assert.Equal(t, map[string]any{
+		"type":       "object",
+		"properties": map[string]any{},
+	}, schemas["UsersQueryRequest"])
+	assert.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"next":  map[string]any{"type": "string"},
+			"users": map[string]any{"type": "array", "items": map[string]any{"type": "object", "additionalProperties": map[string]any{}}},
+			"total": map[string]any{"type": "integer"},
+		},
+		"required": []string{"users", "total"},
+	}, schemas["UsersErrorResponse"])
+}
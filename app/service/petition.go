@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
@@ -17,10 +18,15 @@ type MyResponse interface {
 		dbapp.IDErrorResponse |
 		dbapp.ErrorResponse |
 		dbapp.RowsErrorResponse |
+		dbapp.UsersErrorResponse |
 		tokenapp.Token |
+		tokenapp.TokenErrResponse |
+		tokenapp.TokenRefreshErrResponse |
+		tokenapp.IDEmailErrResponse |
 		tokenapp.IDUsernameEmailErrResponse |
 		tokenapp.ErrorResponse |
-		tokenapp.CheckErrResponse
+		tokenapp.CheckErrResponse |
+		tokenapp.IdempotencyErrResponse
 }
 
 type HTTPComponents struct {
@@ -32,6 +38,7 @@ func NewHTTPComponents(url, method string) HTTPComponents {
 }
 
 func RequestFunc[responseEntity MyResponse](
+	ctx context.Context,
 	client HTTPClient,
 	body any,
 	httpComponents HTTPComponents,
@@ -44,9 +51,6 @@ func RequestFunc[responseEntity MyResponse](
 		return err
 	}
 
-	ctx, ctxCancel := context.WithTimeout(context.TODO(), time.Minute)
-	defer ctxCancel()
-
 	req, err := http.NewRequestWithContext(ctx, httpComponents.method, httpComponents.url, bytes.NewBuffer(bodyJSON))
 	if err != nil {
 		err = fmt.Errorf("error to make petition: %w", err)
@@ -54,45 +58,50 @@ func RequestFunc[responseEntity MyResponse](
 		return err
 	}
 
-	resp, err := client.Do(req)
+	forwardRequestIDs(ctx, req.Header)
+
+	req, span := startClientSpan(req)
+
+	var resp *http.Response
+
+	defer func() {
+		endClientSpan(span, resp, err)
+	}()
+
+	resp, err = client.Do(req)
 	if err != nil {
-		err = fmt.Errorf("error to make petition: %w", err)
+		return &Error{Kind: ErrWebServer, Err: fmt.Errorf("error to make petition: %w", err)}
+	}
 
-		return err
+	defer drainAndClose(resp)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return ProblemError(resp)
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
-		return fmt.Errorf("failed to decode request: %w", err)
+		return &Error{Kind: ErrWebServer, Err: fmt.Errorf("failed to decode request: %w", err)}
 	}
 
 	return nil
 }
 
-func RequestFuncWithoutBody(
-	client HTTPClient,
-	httpComponents HTTPComponents,
-	response *dbapp.UsersErrorResponse,
-) (err error) {
-	ctx, ctxCancel := context.WithTimeout(context.TODO(), time.Minute)
-	defer ctxCancel()
+// ProblemError turns the problem document of a failed call, to a downstream
+// service or to the gateway itself, into the typed error of its status.
+func ProblemError(resp *http.Response) error {
+	detail := http.StatusText(resp.StatusCode)
 
-	req, err := http.NewRequestWithContext(ctx, httpComponents.method, httpComponents.url, bytes.NewBuffer(nil))
-	if err != nil {
-		err = fmt.Errorf("error to make petition: %w", err)
-
-		return err
+	var problem Problem
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err == nil && problem.Detail != "" {
+		detail = problem.Detail
 	}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		err = fmt.Errorf("error to make petition: %w", err)
+	err := fmt.Errorf("%w: %s", errorKind(resp.StatusCode), detail)
 
-		return err
+	// the client has to wait as long as the downstream service asked for.
+	if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && seconds > 0 {
+		return &Error{Kind: errorKind(resp.StatusCode), Err: err, RetryAfter: time.Duration(seconds) * time.Second}
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
-		return fmt.Errorf("failed to decode request: %w", err)
-	}
-
-	return nil
+	return err
 }
@@ -1,11 +1,13 @@
 package service_test
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cfabrica46/gokit-crud/app/service"
 	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
@@ -98,6 +100,7 @@ func TestRequestFunc(t *testing.T) {
 			t.Parallel()
 
 			err := service.RequestFunc(
+				context.TODO(),
 				tt.client,
 				tt.body,
 				service.NewHTTPComponents(
@@ -110,7 +113,6 @@ func TestRequestFunc(t *testing.T) {
 			if tt.name == "NoError" {
 				assert.Nil(t, err)
 				assert.Equal(t, idTest, tt.Response.ID)
-				assert.Zero(t, tt.Response.Err)
 			} else {
 				assert.NotNil(t, err)
 				assert.ErrorContains(t, err, tt.outErr)
@@ -119,93 +121,126 @@ func TestRequestFunc(t *testing.T) {
 	}
 }
 
-func TestRequestFuncWithoutBody(t *testing.T) {
+func TestRequestFuncContext(t *testing.T) {
 	t.Parallel()
 
-	mockOK := service.NewMockClient(func(_ *http.Request) (*http.Response, error) {
-		response := &http.Response{
-			StatusCode: http.StatusOK,
-			Body: io.NopCloser(strings.NewReader(`{
-				"users": [
-					{
-						"id":1,
-						"username":"username",
-						"password":"password",
-						"email":"email@email.com"
-					}
-				]
-			}`)),
+	mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
+		if err := req.Context().Err(); err != nil {
+			return nil, err
 		}
 
-		return response, nil
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"id": 1}`)),
+		}, nil
 	})
 
-	mockNotOK := service.NewMockClient(func(_ *http.Request) (*http.Response, error) {
-		return nil, fmt.Errorf("%w: error", errWebServer)
-	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var response dbapp.IDErrorResponse
+
+	err := service.RequestFunc(
+		ctx,
+		mock,
+		dbapp.UsernameRequest{Username: usernameTest},
+		service.NewHTTPComponents("localhost:8080", http.MethodPost),
+		&response,
+	)
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRequestFuncProblem(t *testing.T) {
+	t.Parallel()
 
 	for _, tt := range []struct {
-		Response *dbapp.UsersErrorResponse
-		client   service.HTTPClient
-		name     string
-		inURL    string
-		inMethod string
-		outErr   string
+		outKind       error
+		name          string
+		inBody        string
+		inRetryAfter  string
+		outErr        string
+		inStatus      int
+		outStatus     int
+		outRetryAfter time.Duration
 	}{
 		{
-			name:     "NoError",
-			client:   mockOK,
-			inURL:    "localhost:8080",
-			inMethod: http.MethodPost,
-			Response: &dbapp.UsersErrorResponse{},
-			outErr:   "",
+			name:      "NotFound",
+			inStatus:  http.StatusNotFound,
+			inBody:    `{"type":"about:blank","title":"Not Found","status":404,"detail":"user not found"}`,
+			outKind:   service.ErrNotFound,
+			outErr:    "not found: user not found",
+			outStatus: http.StatusNotFound,
 		},
 		{
-			name:     "ErrorURL",
-			client:   mockOK,
-			inURL:    "%%",
-			inMethod: http.MethodPost,
-			Response: &dbapp.UsersErrorResponse{},
-			outErr:   "error to make petition",
+			name:      "Conflict",
+			inStatus:  http.StatusConflict,
+			inBody:    `{"type":"about:blank","title":"Conflict","status":409,"detail":"username or email already in use"}`,
+			outKind:   service.ErrConflict,
+			outErr:    "username or email already in use",
+			outStatus: http.StatusConflict,
 		},
 		{
-			name:     "ErrorService",
-			client:   mockNotOK,
-			inURL:    "localhost:8080",
-			inMethod: http.MethodPost,
-			Response: &dbapp.UsersErrorResponse{},
-			outErr:   "error to make petition",
+			name:      "Unauthorized",
+			inStatus:  http.StatusUnauthorized,
+			inBody:    `{"type":"about:blank","title":"Unauthorized","status":401,"detail":"refresh token not valid"}`,
+			outKind:   service.ErrUnauthorized,
+			outErr:    "refresh token not valid",
+			outStatus: http.StatusUnauthorized,
 		},
 		{
-			name:     "ErrorDecode",
-			client:   mockOK,
-			inURL:    "localhost:8080",
-			inMethod: http.MethodPost,
-			Response: nil,
-			outErr:   "failed to decode request",
+			name:      "Validation",
+			inStatus:  http.StatusBadRequest,
+			inBody:    `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid cursor"}`,
+			outKind:   service.ErrValidation,
+			outErr:    "invalid cursor",
+			outStatus: http.StatusBadRequest,
+		},
+		{
+			name:          "TooManyRequests",
+			inStatus:      http.StatusTooManyRequests,
+			inBody:        `{"type":"about:blank","title":"Too Many Requests","status":429,"detail":"too many sign in attempts"}`,
+			inRetryAfter:  "30",
+			outKind:       service.ErrTooManyRequests,
+			outErr:        "too many sign in attempts",
+			outStatus:     http.StatusTooManyRequests,
+			outRetryAfter: 30 * time.Second,
+		},
+		{
+			name:      "InternalWithoutBody",
+			inStatus:  http.StatusInternalServerError,
+			inBody:    ``,
+			outKind:   service.ErrWebServer,
+			outErr:    "error from web server: Internal Server Error",
+			outStatus: http.StatusBadGateway,
 		},
 	} {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			err := service.RequestFuncWithoutBody(
-				tt.client,
-				service.NewHTTPComponents(
-					tt.inURL,
-					tt.inMethod,
-				),
-				tt.Response,
+			mock := service.NewMockClient(func(_ *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: tt.inStatus,
+					Header:     http.Header{"Retry-After": []string{tt.inRetryAfter}},
+					Body:       io.NopCloser(strings.NewReader(tt.inBody)),
+				}, nil
+			})
+
+			var response dbapp.IDErrorResponse
+
+			err := service.RequestFunc(
+				context.TODO(),
+				mock,
+				dbapp.UsernameRequest{Username: usernameTest},
+				service.NewHTTPComponents("localhost:8080", http.MethodPost),
+				&response,
 			)
 
-			if tt.name == "NoError" {
-				assert.Nil(t, err)
-				assert.NotNil(t, idTest, tt.Response.Users)
-				assert.Zero(t, tt.Response.Err)
-			} else {
-				assert.NotNil(t, err)
-				assert.ErrorContains(t, err, tt.outErr)
-			}
+			assert.ErrorIs(t, err, tt.outKind)
+			assert.ErrorContains(t, err, tt.outErr)
+			assert.Equal(t, tt.outStatus, service.StatusCode(err))
+			assert.Equal(t, tt.outRetryAfter, service.RetryAfter(err))
 		})
 	}
 }
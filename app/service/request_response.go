@@ -20,6 +20,61 @@ type TokenRequest struct {
 	Token string `json:"token"`
 }
 
+// TokenUsernameEmailRequest (string, string, string) (string, error).
+type TokenUsernameEmailRequest struct {
+	// Token is read from the Authorization header.
+	Token    string `json:"-"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// TokenOldNewPasswordRequest (string, string, string) error.
+type TokenOldNewPasswordRequest struct {
+	// Token is read from the Authorization header.
+	Token       string `json:"-"`
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+// EmailRequest (string) error.
+type EmailRequest struct {
+	Email string `json:"email"`
+}
+
+// TokenPasswordRequest (string, string) error.
+type TokenPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// RefreshTokenRequest (string) (string, string, error).
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// IDRequest (int) error.
+type IDRequest struct {
+	ID int `json:"id"`
+}
+
+// IDSuspendedRequest (int, bool) error.
+type IDSuspendedRequest struct {
+	ID        int  `json:"id"`
+	Suspended bool `json:"suspended"`
+}
+
+// UsersQueryRequest (dbapp.UsersQuery) ([]dbapp.User, int, string, error).
+type UsersQueryRequest struct {
+	Query  dbapp.UsersQuery `json:"-"`
+	Fields []string         `json:"-"`
+}
+
+// TokenFieldsRequest (string) (dbapp.User, error).
+type TokenFieldsRequest struct {
+	Token  string   `json:"token"`
+	Fields []string `json:"-"`
+}
+
 // EmptyRequest () ([]dbapp.User, error).
 type EmptyRequest struct{}
 
@@ -27,23 +82,31 @@ type EmptyRequest struct{}
 
 // TokenErrorResponse (string, string, string) (string, error).
 type TokenErrorResponse struct {
-	Token string `json:"token"`
-	Err   string `json:"err,omitempty"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken,omitempty"`
 }
 
-// UsersErrorResponse () ([]dbapp.User, error).
+// UsersErrorResponse (dbapp.UsersQuery) ([]dbapp.User, int, string, error).
 type UsersErrorResponse struct {
-	Err   string       `json:"err,omitempty"`
-	Users []dbapp.User `json:"users"`
+	Next  string     `json:"next,omitempty"`
+	Users []UserView `json:"users"`
+	Total int        `json:"total"`
 }
 
 // UserErrorResponse () (dbapp.User, error).
 type UserErrorResponse struct {
-	Err  string     `json:"err,omitempty"`
-	User dbapp.User `json:"user"`
+	User UserView `json:"user"`
 }
 
-// ErrorResponse (string, string, string) (string, error).
-type ErrorResponse struct {
-	Err string `json:"err,omitempty"`
+// ErrorResponse is the body of the calls that return nothing, failures are
+// reported as a Problem.
+type ErrorResponse struct{}
+
+// Problem is the RFC 7807 application/problem+json body errors are reported
+// with, downstream services report theirs the same way.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Detail string `json:"detail,omitempty"`
+	Status int    `json:"status"`
 }
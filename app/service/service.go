@@ -1,9 +1,11 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
 	tokenapp "github.com/cfabrica46/gokit-crud/token-app/service"
@@ -11,8 +13,17 @@ import (
 
 var (
 	ErrResponse      = errors.New("error to response")
-	ErrTokenNotValid = errors.New("token not validate")
+	ErrTokenNotValid = &Error{Kind: ErrUnauthorized, Err: errors.New("token not validate")}
 	ErrWebServer     = errors.New("error from web server")
+	ErrUserNotFound  = &Error{Kind: ErrNotFound, Err: errors.New("user not found")}
+	ErrWrongPassword = &Error{Kind: ErrUnauthorized, Err: errors.New("wrong password")}
+	ErrEmptyPassword = &Error{Kind: ErrValidation, Err: errors.New("password can't be empty")}
+
+	ErrVerificationNotValid = &Error{Kind: ErrUnauthorized, Err: errors.New("verification token not valid for the email")}
+
+	ErrForbidden        = errors.New("forbidden")
+	ErrEmailNotVerified = &Error{Kind: ErrForbidden, Err: errors.New("email not verified")}
+	ErrUserSuspended    = &Error{Kind: ErrForbidden, Err: errors.New("user suspended")}
 )
 
 type InfoServices struct {
@@ -21,15 +32,27 @@ type InfoServices struct {
 	TokenHost string
 	TokenPort string
 	Secret    string
+
+	// RequireVerifiedEmail blocks SignIn until the user confirms the email.
+	RequireVerifiedEmail bool
 }
 
 type serviceInterface interface {
-	SignUp(string, string, string) (string, error)
-	SignIn(string, string) (string, error)
-	LogOut(string) error
-	GetAllUsers() ([]dbapp.User, error)
-	Profile(string) (dbapp.User, error)
-	DeleteAccount(string) error
+	SignUp(context.Context, string, string, string) (string, string, error)
+	SignIn(context.Context, string, string) (string, string, error)
+	LogOut(context.Context, string) error
+	GetAllUsers(context.Context, dbapp.UsersQuery) ([]dbapp.User, int, string, error)
+	Profile(context.Context, string) (dbapp.User, error)
+	DeleteAccount(context.Context, string) error
+	UpdateProfile(context.Context, string, string, string) (string, string, error)
+	ChangePassword(context.Context, string, string, string) error
+	ForgotPassword(context.Context, string) error
+	ResetPassword(context.Context, string, string) error
+	VerifyEmail(context.Context, string) error
+	RefreshToken(context.Context, string) (string, string, error)
+	Authorize(context.Context, string, string) error
+	DeleteUser(context.Context, int) error
+	SuspendUser(context.Context, int, bool) error
 }
 
 type HTTPClient interface {
@@ -39,27 +62,29 @@ type HTTPClient interface {
 // Service ...
 type Service struct {
 	client                    HTTPClient
+	mailer                    MailSender
 	dbHost, tokenHost, secret string
+	requireVerifiedEmail      bool
 }
 
 // NewService ...
-func NewService(client HTTPClient, is *InfoServices) *Service {
+func NewService(client HTTPClient, mailer MailSender, is *InfoServices) *Service {
 	return &Service{
 		client,
+		mailer,
 		"http://" + is.DBHost + ":" + is.DBPort, "http://" + is.TokenHost + ":" + is.TokenPort, is.Secret,
+		is.RequireVerifiedEmail,
 	}
 }
 
-// SignUp ...
-func (s *Service) SignUp(username, password, email string) (token string, err error) {
-	var (
-		errorDBResponse    dbapp.ErrorResponse
-		idResponse         dbapp.IDErrorResponse
-		tokenResponse      tokenapp.Token
-		errorTokenResponse tokenapp.ErrorResponse
-	)
+// SignUp creates the user and its session as a saga: when a step after the
+// insert fails the user is deleted again, so that from the client's point of
+// view the sign up either happened or can be retried.
+func (s *Service) SignUp(ctx context.Context, username, password, email string) (token, refreshToken string, err error) {
+	var idResponse dbapp.IDErrorResponse
 
 	if err = RequestFunc(
+		ctx,
 		s.client,
 		dbapp.UsernamePasswordEmailRequest{
 			Username: username,
@@ -70,84 +95,328 @@ func (s *Service) SignUp(username, password, email string) (token string, err er
 			s.dbHost+"/user",
 			http.MethodPost,
 		),
-		&errorDBResponse,
+		&idResponse,
 	); err != nil {
-		return "", err
+		return "", "", err
+	}
+
+	defer func() {
+		if err != nil {
+			token, refreshToken = "", ""
+			err = s.compensateSignUp(ctx, idResponse.ID, err)
+		}
+	}()
+
+	// without a verified email the user can't have a session yet.
+	if !s.requireVerifiedEmail {
+		token, refreshToken, err = s.issueSession(ctx, idResponse.ID, username, email, dbapp.RoleUser)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	// the email goes out last, it is the only step that can't be undone.
+	if err = s.sendVerificationEmail(ctx, idResponse.ID, email); err != nil {
+		return "", "", err
+	}
+
+	return token, refreshToken, nil
+}
+
+// compensateSignUp undoes the sign up that failed with err, revoking the
+// tokens the user may have got and deleting it. It runs even if ctx was
+// canceled, err is returned with the failure of the compensation, if any.
+func (s *Service) compensateSignUp(ctx context.Context, id int, err error) error {
+	var rowsErrorResponse dbapp.RowsErrorResponse
+
+	ctx = withoutCancel(ctx)
+
+	revokeErr := s.revokeUserTokens(ctx, id)
+
+	deleteErr := RequestFunc(
+		ctx,
+		s.client,
+		dbapp.IDRequest{
+			ID: id,
+		},
+		NewHTTPComponents(
+			s.dbHost+"/user",
+			http.MethodDelete,
+		),
+		&rowsErrorResponse,
+	)
+
+	switch {
+	case deleteErr != nil:
+		return fmt.Errorf("%w (error to undo sign up: %v)", err, deleteErr)
+	case revokeErr != nil:
+		return fmt.Errorf("%w (error to undo sign up: %v)", err, revokeErr)
+	default:
+		return err
 	}
+}
 
-	if errorDBResponse.Err != "" {
-		return "", fmt.Errorf("%w:%s", ErrWebServer, errorDBResponse.Err)
+// SignIn checks the credentials once token-app lets the attempt through,
+// the attempts are limited by the IP ClientIPToContext put in ctx and by
+// username, and too many failures lock the account out for a while.
+func (s *Service) SignIn(ctx context.Context, username, password string) (token, refreshToken string, err error) {
+	var userErrorResponse dbapp.UserErrorResponse
+
+	if err = s.allowSignIn(ctx, username); err != nil {
+		return "", "", err
 	}
 
 	if err = RequestFunc(
+		ctx,
 		s.client,
-		dbapp.UsernameRequest{
+		dbapp.UsernamePasswordRequest{
 			Username: username,
+			Password: password,
 		},
 		NewHTTPComponents(
-			s.dbHost+"/id/username",
+			s.dbHost+"/user/username_password",
 			http.MethodGet,
 		),
-		&idResponse,
-	); err != nil {
-		return "", err
+		&userErrorResponse,
+	); err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrUnauthorized) {
+		return "", "", err
 	}
 
-	if idResponse.Err != "" {
-		return "", fmt.Errorf("%w:%s", ErrWebServer, idResponse.Err)
+	// no user matches the credentials: database-app answers 404, older
+	// versions answered 200 with an empty user.
+	if err != nil || userErrorResponse.User.ID == 0 {
+		if err = s.recordSignIn(ctx, username, false); err != nil {
+			return "", "", err
+		}
+
+		return "", "", ErrWrongPassword
 	}
 
-	if err = RequestFunc(
+	if err = s.recordSignIn(ctx, username, true); err != nil {
+		return "", "", err
+	}
+
+	if userErrorResponse.User.Suspended {
+		return "", "", ErrUserSuspended
+	}
+
+	if s.requireVerifiedEmail && !userErrorResponse.User.EmailVerified {
+		return "", "", ErrEmailNotVerified
+	}
+
+	return s.issueSession(
+		ctx,
+		userErrorResponse.User.ID,
+		userErrorResponse.User.Username,
+		userErrorResponse.User.Email,
+		userErrorResponse.User.Role,
+	)
+}
+
+// allowSignIn asks token-app for a sign in attempt of the caller.
+func (s *Service) allowSignIn(ctx context.Context, username string) (err error) {
+	var errorResponse tokenapp.ErrorResponse
+
+	ip, _ := ctx.Value(clientIPContextKey).(string)
+
+	return RequestFunc(
+		ctx,
 		s.client,
-		tokenapp.IDUsernameEmailSecretRequest{
-			ID:       idResponse.ID,
+		tokenapp.IPUsernameRequest{
+			IP:       ip,
 			Username: username,
-			Email:    email,
-			Secret:   s.secret,
 		},
 		NewHTTPComponents(
-			s.tokenHost+"/generate",
+			s.tokenHost+"/signin/allow",
 			http.MethodPost,
 		),
-		&tokenResponse,
+		&errorResponse,
+	)
+}
+
+// recordSignIn tells token-app whether the credentials were right.
+func (s *Service) recordSignIn(ctx context.Context, username string, success bool) (err error) {
+	var errorResponse tokenapp.ErrorResponse
+
+	return RequestFunc(
+		ctx,
+		s.client,
+		tokenapp.UsernameSuccessRequest{
+			Username: username,
+			Success:  success,
+		},
+		NewHTTPComponents(
+			s.tokenHost+"/signin/result",
+			http.MethodPost,
+		),
+		&errorResponse,
+	)
+}
+
+// LogOut revokes the token and the refresh token family it was issued with,
+// the other sessions of the user stay valid.
+func (s *Service) LogOut(ctx context.Context, token string) (err error) {
+	if _, err = s.checkAndExtractToken(ctx, token); err != nil {
+		return err
+	}
+
+	return s.revokeSession(ctx, token)
+}
+
+// GetAllUsers  ...
+func (s *Service) GetAllUsers(ctx context.Context, query dbapp.UsersQuery) (users []dbapp.User, total int, next string, err error) {
+	var usersErrorResponse dbapp.UsersErrorResponse
+
+	if err = RequestFunc(
+		ctx,
+		s.client,
+		query,
+		NewHTTPComponents(
+			s.dbHost+"/users",
+			http.MethodGet,
+		),
+		&usersErrorResponse,
 	); err != nil {
-		return "", err
+		return nil, 0, "", err
+	}
+
+	return usersErrorResponse.Users, usersErrorResponse.Total, usersErrorResponse.Next, nil
+}
+
+// Profile  ...
+func (s *Service) Profile(ctx context.Context, token string) (user dbapp.User, err error) {
+	var userErrorResponse dbapp.UserErrorResponse
+
+	idUsernameEmailErrResponse, err := s.checkAndExtractToken(ctx, token)
+	if err != nil {
+		return dbapp.User{}, err
 	}
 
 	if err = RequestFunc(
+		ctx,
 		s.client,
-		tokenapp.Token{
-			Token: tokenResponse.Token,
+		dbapp.IDRequest{
+			ID: idUsernameEmailErrResponse.ID,
 		},
 		NewHTTPComponents(
-			s.tokenHost+"/token",
-			http.MethodPost,
+			s.dbHost+"/user/id",
+			http.MethodGet,
 		),
-		&errorTokenResponse,
+		&userErrorResponse,
 	); err != nil {
-		return "", err
+		return dbapp.User{}, err
 	}
 
-	if errorTokenResponse.Err != "" {
-		return "", fmt.Errorf("%w:%s", ErrWebServer, errorTokenResponse.Err)
+	return userErrorResponse.User, nil
+}
+
+// DeleteAccount deletes the account of the token and revokes its tokens.
+func (s *Service) DeleteAccount(ctx context.Context, token string) (err error) {
+	var errorResponse dbapp.ErrorResponse
+
+	idUsernameEmailErrResponse, err := s.checkAndExtractToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if err = RequestFunc(
+		ctx,
+		s.client,
+		dbapp.IDRequest{
+			ID: idUsernameEmailErrResponse.ID,
+		},
+		NewHTTPComponents(
+			s.dbHost+"/user",
+			http.MethodDelete,
+		),
+		&errorResponse,
+	); err != nil {
+		return err
 	}
 
-	return tokenResponse.Token, nil
+	return s.revokeUserTokens(ctx, idUsernameEmailErrResponse.ID)
 }
 
-// SignIn ...
-func (s *Service) SignIn(username, password string) (token string, err error) {
+// UpdateProfile  ...
+func (s *Service) UpdateProfile(ctx context.Context, token, username, email string) (newToken, newRefreshToken string, err error) {
+	var rowsErrorResponse dbapp.RowsErrorResponse
+
+	claims, err := s.checkAndExtractToken(ctx, token)
+	if err != nil {
+		return "", "", err
+	}
+
+	if username == "" {
+		username = claims.Username
+	}
+
+	if email == "" {
+		email = claims.Email
+	}
+
+	if err = RequestFunc(
+		ctx,
+		s.client,
+		dbapp.IDUsernameEmailRequest{
+			ID:       claims.ID,
+			Username: username,
+			Email:    email,
+		},
+		NewHTTPComponents(
+			s.dbHost+"/user",
+			http.MethodPatch,
+		),
+		&rowsErrorResponse,
+	); err != nil {
+		return "", "", err
+	}
+
+	if rowsErrorResponse.RowsAffected == 0 {
+		return "", "", ErrUserNotFound
+	}
+
+	if email != claims.Email {
+		if err = s.sendVerificationEmail(ctx, claims.ID, email); err != nil {
+			return "", "", err
+		}
+	}
+
+	// the token carries the username and email as claims, so it has to be
+	// re-issued when any of them changes.
+	if username == claims.Username && email == claims.Email {
+		return token, "", nil
+	}
+
+	// the old refresh token would keep issuing tokens with the old claims.
+	if err = s.revokeSession(ctx, token); err != nil {
+		return "", "", err
+	}
+
+	return s.issueSession(ctx, claims.ID, username, email, claims.Role)
+}
+
+// ChangePassword  ...
+func (s *Service) ChangePassword(ctx context.Context, token, oldPassword, newPassword string) (err error) {
 	var (
 		userErrorResponse dbapp.UserErrorResponse
-		tokenResponse     tokenapp.Token
-		errorResponse     tokenapp.ErrorResponse
+		rowsErrorResponse dbapp.RowsErrorResponse
 	)
 
+	if newPassword == "" {
+		return ErrEmptyPassword
+	}
+
+	claims, err := s.checkAndExtractToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
 	if err = RequestFunc(
+		ctx,
 		s.client,
 		dbapp.UsernamePasswordRequest{
-			Username: username,
-			Password: password,
+			Username: claims.Username,
+			Password: oldPassword,
 		},
 		NewHTTPComponents(
 			s.dbHost+"/user/username_password",
@@ -155,206 +424,490 @@ func (s *Service) SignIn(username, password string) (token string, err error) {
 		),
 		&userErrorResponse,
 	); err != nil {
-		return "", err
+		if errors.Is(err, ErrNotFound) {
+			return ErrWrongPassword
+		}
+
+		return err
 	}
 
-	if userErrorResponse.Err != "" {
-		return "", fmt.Errorf("%w:%s", ErrWebServer, userErrorResponse.Err)
+	if userErrorResponse.User.ID != claims.ID {
+		return ErrWrongPassword
 	}
 
 	if err = RequestFunc(
+		ctx,
 		s.client,
-		tokenapp.IDUsernameEmailSecretRequest{
-			ID:       userErrorResponse.User.ID,
-			Username: userErrorResponse.User.Username,
-			Email:    userErrorResponse.User.Email,
-			Secret:   s.secret,
+		dbapp.IDPasswordRequest{
+			ID:       claims.ID,
+			Password: newPassword,
 		},
 		NewHTTPComponents(
-			s.tokenHost+"/generate",
+			s.dbHost+"/user/password",
+			http.MethodPatch,
+		),
+		&rowsErrorResponse,
+	); err != nil {
+		return err
+	}
+
+	if rowsErrorResponse.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return s.revokeUserTokens(ctx, claims.ID)
+}
+
+// ForgotPassword  ...
+func (s *Service) ForgotPassword(ctx context.Context, email string) (err error) {
+	var (
+		idResponse    dbapp.IDErrorResponse
+		tokenResponse tokenapp.TokenErrResponse
+	)
+
+	if err = RequestFunc(
+		ctx,
+		s.client,
+		dbapp.EmailRequest{
+			Email: email,
+		},
+		NewHTTPComponents(
+			s.dbHost+"/id/email",
+			http.MethodGet,
+		),
+		&idResponse,
+	); err != nil {
+		// an unknown email is not reported, otherwise this endpoint could be
+		// used to find out which emails are registered.
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+
+		return err
+	}
+
+	if err = RequestFunc(
+		ctx,
+		s.client,
+		tokenapp.IDEmailPurposeRequest{
+			ID:      idResponse.ID,
+			Purpose: tokenapp.PurposePasswordReset,
+		},
+		NewHTTPComponents(
+			s.tokenHost+"/onetime",
 			http.MethodPost,
 		),
 		&tokenResponse,
 	); err != nil {
-		return "", err
+		return err
+	}
+
+	if err = s.mailer.Send(
+		email,
+		"Password reset",
+		"Use this token to reset your password, it can only be used once: "+tokenResponse.Token,
+	); err != nil {
+		return fmt.Errorf("error to send email: %w", err)
+	}
+
+	return nil
+}
+
+// ResetPassword  ...
+func (s *Service) ResetPassword(ctx context.Context, resetToken, newPassword string) (err error) {
+	var (
+		idErrResponse     tokenapp.IDEmailErrResponse
+		rowsErrorResponse dbapp.RowsErrorResponse
+	)
+
+	if newPassword == "" {
+		return ErrEmptyPassword
 	}
 
 	if err = RequestFunc(
+		ctx,
 		s.client,
-		tokenapp.Token{
-			Token: tokenResponse.Token,
+		tokenapp.TokenPurposeRequest{
+			Token:   resetToken,
+			Purpose: tokenapp.PurposePasswordReset,
 		},
 		NewHTTPComponents(
-			s.tokenHost+"/token",
+			s.tokenHost+"/onetime/consume",
 			http.MethodPost,
 		),
-		&errorResponse,
+		&idErrResponse,
 	); err != nil {
-		return "", err
+		return err
+	}
+
+	if err = RequestFunc(
+		ctx,
+		s.client,
+		dbapp.IDPasswordRequest{
+			ID:       idErrResponse.ID,
+			Password: newPassword,
+		},
+		NewHTTPComponents(
+			s.dbHost+"/user/password",
+			http.MethodPatch,
+		),
+		&rowsErrorResponse,
+	); err != nil {
+		return err
 	}
 
-	if errorResponse.Err != "" {
-		return "", fmt.Errorf("%w:%s", ErrWebServer, errorResponse.Err)
+	if rowsErrorResponse.RowsAffected == 0 {
+		return ErrUserNotFound
 	}
 
-	return tokenResponse.Token, nil
+	return s.revokeUserTokens(ctx, idErrResponse.ID)
 }
 
-// LogOut ...
-func (s *Service) LogOut(token string) (err error) {
+// RefreshToken rotates the refresh token and opens a new session with it.
+// The claims kept with the refresh token are checked against the user: a
+// deleted or suspended user is logged out everywhere and a user whose claims
+// changed gets a session with the current ones.
+func (s *Service) RefreshToken(ctx context.Context, refreshToken string) (token, newRefreshToken string, err error) {
 	var (
-		checkErrorResponse tokenapp.CheckErrResponse
-		errorResponse      tokenapp.ErrorResponse
+		tokenResponse      tokenapp.TokenRefreshErrResponse
+		userErrorResponse  dbapp.UserErrorResponse
+		errorTokenResponse tokenapp.ErrorResponse
 	)
 
 	if err = RequestFunc(
+		ctx,
 		s.client,
-		tokenapp.Token{
-			Token: token,
+		tokenapp.RefreshTokenSecretRequest{
+			RefreshToken: refreshToken,
+			Secret:       s.secret,
 		},
 		NewHTTPComponents(
-			s.tokenHost+"/check",
+			s.tokenHost+"/refresh",
 			http.MethodPost,
 		),
-		&checkErrorResponse,
+		&tokenResponse,
 	); err != nil {
-		return err
+		return "", "", err
 	}
 
-	if checkErrorResponse.Err != "" {
-		return fmt.Errorf("%w:%s", ErrWebServer, checkErrorResponse.Err)
+	claims, err := s.extractToken(ctx, tokenResponse.Token)
+	if err != nil {
+		return "", "", err
 	}
 
-	if !checkErrorResponse.Check {
-		err = ErrTokenNotValid
+	if err = RequestFunc(
+		ctx,
+		s.client,
+		dbapp.IDRequest{
+			ID: claims.ID,
+		},
+		NewHTTPComponents(
+			s.dbHost+"/user/id",
+			http.MethodGet,
+		),
+		&userErrorResponse,
+	); err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			return "", "", err
+		}
 
-		return err
+		if err = s.revokeUserTokens(ctx, claims.ID); err != nil {
+			return "", "", err
+		}
+
+		return "", "", ErrTokenNotValid
+	}
+
+	user := userErrorResponse.User
+
+	if user.Suspended {
+		if err = s.revokeUserTokens(ctx, claims.ID); err != nil {
+			return "", "", err
+		}
+
+		return "", "", ErrUserSuspended
+	}
+
+	if user.Username != claims.Username || user.Email != claims.Email || user.Role != claims.Role {
+		// the refresh token just rotated belongs to the old claims.
+		if err = s.revokeSession(ctx, tokenResponse.Token); err != nil {
+			return "", "", err
+		}
+
+		return s.issueSession(ctx, user.ID, user.Username, user.Email, user.Role)
 	}
 
 	if err = RequestFunc(
+		ctx,
 		s.client,
 		tokenapp.Token{
-			Token: token,
+			Token: tokenResponse.Token,
 		},
 		NewHTTPComponents(
 			s.tokenHost+"/token",
-			http.MethodDelete,
+			http.MethodPost,
 		),
-		&errorResponse,
+		&errorTokenResponse,
+	); err != nil {
+		return "", "", err
+	}
+
+	return tokenResponse.Token, tokenResponse.RefreshToken, nil
+}
+
+// VerifyEmail marks the email the token was sent to as verified, as long as
+// the user still has it.
+func (s *Service) VerifyEmail(ctx context.Context, verificationToken string) (err error) {
+	var (
+		idErrResponse     tokenapp.IDEmailErrResponse
+		rowsErrorResponse dbapp.RowsErrorResponse
+	)
+
+	if err = RequestFunc(
+		ctx,
+		s.client,
+		tokenapp.TokenPurposeRequest{
+			Token:   verificationToken,
+			Purpose: tokenapp.PurposeEmailVerification,
+		},
+		NewHTTPComponents(
+			s.tokenHost+"/onetime/consume",
+			http.MethodPost,
+		),
+		&idErrResponse,
+	); err != nil {
+		return err
+	}
+
+	if err = RequestFunc(
+		ctx,
+		s.client,
+		dbapp.IDEmailRequest{
+			ID:    idErrResponse.ID,
+			Email: idErrResponse.Email,
+		},
+		NewHTTPComponents(
+			s.dbHost+"/user/email_verified",
+			http.MethodPatch,
+		),
+		&rowsErrorResponse,
 	); err != nil {
 		return err
 	}
 
-	if errorResponse.Err != "" {
-		return fmt.Errorf("%w:%s", ErrWebServer, errorResponse.Err)
+	// the token was sent to an email the user no longer has.
+	if rowsErrorResponse.RowsAffected == 0 {
+		return ErrVerificationNotValid
 	}
 
 	return nil
 }
 
-// GetAllUsers  ...
-func (s *Service) GetAllUsers() (users []dbapp.User, err error) {
-	var usersErrorResponse dbapp.UsersErrorResponse
+// Authorize checks that the token is active and carries the role.
+func (s *Service) Authorize(ctx context.Context, token, role string) (err error) {
+	if token == "" {
+		return ErrTokenNotValid
+	}
+
+	claims, err := s.checkAndExtractToken(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if claims.Role != role {
+		return ErrForbidden
+	}
 
-	if err = RequestFuncWithoutBody(
+	return nil
+}
+
+// DeleteUser deletes any account and revokes its tokens.
+func (s *Service) DeleteUser(ctx context.Context, id int) (err error) {
+	var rowsErrorResponse dbapp.RowsErrorResponse
+
+	if err = RequestFunc(
+		ctx,
 		s.client,
+		dbapp.IDRequest{
+			ID: id,
+		},
 		NewHTTPComponents(
-			s.dbHost+"/users",
-			http.MethodGet,
+			s.dbHost+"/user",
+			http.MethodDelete,
 		),
-		&usersErrorResponse,
+		&rowsErrorResponse,
 	); err != nil {
-		return nil, err
+		return err
 	}
 
-	if usersErrorResponse.Err != "" {
-		return nil, fmt.Errorf("%w:%s", ErrWebServer, usersErrorResponse.Err)
+	if rowsErrorResponse.RowsAffected == 0 {
+		return ErrUserNotFound
 	}
 
-	return usersErrorResponse.Users, nil
+	return s.revokeUserTokens(ctx, id)
 }
 
-// Profile  ...
-func (s *Service) Profile(token string) (user dbapp.User, err error) {
-	var (
-		checkErrorResponse         tokenapp.CheckErrResponse
-		idUsernameEmailErrResponse tokenapp.IDUsernameEmailErrResponse
-		userErrorResponse          dbapp.UserErrorResponse
-	)
+// SuspendUser suspends or reinstates an account, a suspended user is also
+// logged out everywhere.
+func (s *Service) SuspendUser(ctx context.Context, id int, suspended bool) (err error) {
+	var rowsErrorResponse dbapp.RowsErrorResponse
 
 	if err = RequestFunc(
+		ctx,
 		s.client,
-		tokenapp.Token{
-			Token: token,
+		dbapp.IDSuspendedRequest{
+			ID:        id,
+			Suspended: suspended,
 		},
 		NewHTTPComponents(
-			s.tokenHost+"/check",
-			http.MethodPost,
+			s.dbHost+"/user/suspended",
+			http.MethodPatch,
 		),
-		&checkErrorResponse,
+		&rowsErrorResponse,
 	); err != nil {
-		return dbapp.User{}, err
+		return err
 	}
 
-	if checkErrorResponse.Err != "" {
-		return dbapp.User{}, fmt.Errorf("%w:%s", ErrWebServer, checkErrorResponse.Err)
+	if rowsErrorResponse.RowsAffected == 0 {
+		return ErrUserNotFound
 	}
 
-	if !checkErrorResponse.Check {
-		err = ErrTokenNotValid
+	if !suspended {
+		return nil
+	}
 
-		return dbapp.User{}, err
+	return s.revokeUserTokens(ctx, id)
+}
+
+// revokeUserTokens deletes every token issued to the user.
+func (s *Service) revokeUserTokens(ctx context.Context, id int) (err error) {
+	var errorTokenResponse tokenapp.ErrorResponse
+
+	if err = RequestFunc(
+		ctx,
+		s.client,
+		tokenapp.IDRequest{
+			ID: id,
+		},
+		NewHTTPComponents(
+			s.tokenHost+"/tokens",
+			http.MethodDelete,
+		),
+		&errorTokenResponse,
+	); err != nil {
+		return err
 	}
 
+	return nil
+}
+
+// revokeSession deletes the token and every token of the refresh token family
+// it was issued with.
+func (s *Service) revokeSession(ctx context.Context, token string) (err error) {
+	var errorTokenResponse tokenapp.ErrorResponse
+
 	if err = RequestFunc(
+		ctx,
 		s.client,
 		tokenapp.TokenSecretRequest{
 			Token:  token,
 			Secret: s.secret,
 		},
 		NewHTTPComponents(
-			s.tokenHost+"/extract",
+			s.tokenHost+"/session",
+			http.MethodDelete,
+		),
+		&errorTokenResponse,
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// issueSession generates an access and refresh token pair and activates the
+// access token.
+func (s *Service) issueSession(ctx context.Context, id int, username, email, role string) (token, refreshToken string, err error) {
+	var (
+		tokenResponse      tokenapp.TokenRefreshErrResponse
+		errorTokenResponse tokenapp.ErrorResponse
+	)
+
+	if err = RequestFunc(
+		ctx,
+		s.client,
+		tokenapp.IDUsernameEmailSecretRequest{
+			ID:       id,
+			Username: username,
+			Email:    email,
+			Role:     role,
+			Secret:   s.secret,
+		},
+		NewHTTPComponents(
+			s.tokenHost+"/generate",
 			http.MethodPost,
 		),
-		&idUsernameEmailErrResponse,
+		&tokenResponse,
 	); err != nil {
-		return dbapp.User{}, err
+		return "", "", err
 	}
 
-	if idUsernameEmailErrResponse.Err != "" {
-		return dbapp.User{}, fmt.Errorf("%w:%s", ErrWebServer, idUsernameEmailErrResponse.Err)
+	if err = RequestFunc(
+		ctx,
+		s.client,
+		tokenapp.Token{
+			Token: tokenResponse.Token,
+		},
+		NewHTTPComponents(
+			s.tokenHost+"/token",
+			http.MethodPost,
+		),
+		&errorTokenResponse,
+	); err != nil {
+		return "", "", err
 	}
 
+	return tokenResponse.Token, tokenResponse.RefreshToken, nil
+}
+
+// sendVerificationEmail issues a verification token and mails it to the user.
+func (s *Service) sendVerificationEmail(ctx context.Context, id int, email string) (err error) {
+	var tokenResponse tokenapp.TokenErrResponse
+
 	if err = RequestFunc(
+		ctx,
 		s.client,
-		dbapp.IDRequest{
-			ID: idUsernameEmailErrResponse.ID,
+		tokenapp.IDEmailPurposeRequest{
+			ID:      id,
+			Email:   email,
+			Purpose: tokenapp.PurposeEmailVerification,
 		},
 		NewHTTPComponents(
-			s.dbHost+"/user/id",
-			http.MethodGet,
+			s.tokenHost+"/onetime",
+			http.MethodPost,
 		),
-		&userErrorResponse,
+		&tokenResponse,
 	); err != nil {
-		return dbapp.User{}, err
+		return err
 	}
 
-	if userErrorResponse.Err != "" {
-		return dbapp.User{}, fmt.Errorf("%w:%s", ErrWebServer, userErrorResponse.Err)
+	if err = s.mailer.Send(
+		email,
+		"Verify your email",
+		"Confirm your email opening /verify-email?token="+tokenResponse.Token,
+	); err != nil {
+		return fmt.Errorf("error to send email: %w", err)
 	}
 
-	return userErrorResponse.User, nil
+	return nil
 }
 
-// DeleteAccount  ...
-func (s *Service) DeleteAccount(token string) (err error) {
-	var (
-		checkErrorResponse         tokenapp.CheckErrResponse
-		idUsernameEmailErrResponse tokenapp.IDUsernameEmailErrResponse
-		errorResponse              dbapp.ErrorResponse
-	)
+// checkAndExtractToken verifies that the token is still active and returns its claims.
+func (s *Service) checkAndExtractToken(ctx context.Context, token string) (claims tokenapp.IDUsernameEmailErrResponse, err error) {
+	var checkErrorResponse tokenapp.CheckErrResponse
 
 	if err = RequestFunc(
+		ctx,
 		s.client,
 		tokenapp.Token{
 			Token: token,
@@ -365,20 +918,20 @@ func (s *Service) DeleteAccount(token string) (err error) {
 		),
 		&checkErrorResponse,
 	); err != nil {
-		return err
-	}
-
-	if checkErrorResponse.Err != "" {
-		return fmt.Errorf("%w:%s", ErrWebServer, checkErrorResponse.Err)
+		return tokenapp.IDUsernameEmailErrResponse{}, err
 	}
 
 	if !checkErrorResponse.Check {
-		err = ErrTokenNotValid
-
-		return err
+		return tokenapp.IDUsernameEmailErrResponse{}, ErrTokenNotValid
 	}
 
+	return s.extractToken(ctx, token)
+}
+
+// extractToken returns the claims of the token, active or not.
+func (s *Service) extractToken(ctx context.Context, token string) (claims tokenapp.IDUsernameEmailErrResponse, err error) {
 	if err = RequestFunc(
+		ctx,
 		s.client,
 		tokenapp.TokenSecretRequest{
 			Token:  token,
@@ -388,24 +941,36 @@ func (s *Service) DeleteAccount(token string) (err error) {
 			s.tokenHost+"/extract",
 			http.MethodPost,
 		),
-		&idUsernameEmailErrResponse,
+		&claims,
 	); err != nil {
-		return err
+		return tokenapp.IDUsernameEmailErrResponse{}, err
 	}
 
-	if idUsernameEmailErrResponse.Err != "" {
-		return fmt.Errorf("%w:%s", ErrWebServer, idUsernameEmailErrResponse.Err)
-	}
+	return claims, nil
+}
 
-	return RequestFunc(
-		s.client,
-		dbapp.IDRequest{
-			ID: idUsernameEmailErrResponse.ID,
-		},
-		NewHTTPComponents(
-			s.dbHost+"/user",
-			http.MethodDelete,
-		),
-		&errorResponse,
-	)
+// detachedContext keeps the values of a context but not its cancellation.
+type detachedContext struct {
+	context.Context //nolint:containedctx
+}
+
+// Deadline ...
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// Done ...
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+// Err ...
+func (detachedContext) Err() error {
+	return nil
+}
+
+// withoutCancel returns a context with the values of ctx, the request ID and
+// the trace among them, that is never canceled.
+func withoutCancel(ctx context.Context) context.Context {
+	return detachedContext{ctx}
 }
@@ -2,6 +2,8 @@ package service_test
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -27,6 +29,8 @@ const (
 	portTest      string = "8080"
 	tokenTest     string = "token"
 
+	refreshTokenTest string = "refreshtoken"
+
 	nameNoError string = "NoError"
 )
 
@@ -97,23 +101,23 @@ func getSignUpTestEntity() []signUpTestStruct {
 			method:               http.MethodPost,
 		},
 		{
-			name:       "ErrorGetID",
+			name:       "ErrorOneTimeToken",
 			inUsername: usernameTest,
 			inPassword: passwordTest,
 			inEmail:    emailTest,
 			isError:    true,
-			url:        "http://db:8080/id/username",
-			method:     http.MethodGet,
+			url:        "http://token:8080/onetime",
+			method:     http.MethodPost,
 		},
 		{
-			name:                 "ErrorInsideGetID",
+			name:                 "ErrorInsideOneTimeToken",
 			inUsername:           usernameTest,
 			inPassword:           passwordTest,
 			inEmail:              emailTest,
 			isError:              true,
 			isErrorInsideRequest: true,
-			url:                  "http://db:8080/id/username",
-			method:               http.MethodGet,
+			url:                  "http://token:8080/onetime",
+			method:               http.MethodPost,
 		},
 		{
 			name:       "ErrorGenerate",
@@ -124,6 +128,16 @@ func getSignUpTestEntity() []signUpTestStruct {
 			url:        "http://token:8080/generate",
 			method:     http.MethodPost,
 		},
+		{
+			name:                 "ErrorInsideGenerate",
+			inUsername:           usernameTest,
+			inPassword:           passwordTest,
+			inEmail:              emailTest,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://token:8080/generate",
+			method:               http.MethodPost,
+		},
 		{
 			name:       "ErrorSetToken",
 			inUsername: usernameTest,
@@ -154,7 +168,6 @@ func getProfileTestEntity() []profileTestStruct {
 			outUser: dbapp.User{
 				ID:       idTest,
 				Username: usernameTest,
-				Password: passwordTest,
 				Email:    emailTest,
 			},
 			outCheck: true,
@@ -247,19 +260,21 @@ func TestSignUp(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			var resultToken string
+			var resultToken, resultRefreshToken string
 			var resultErr error
-			var tokenResponse, errorResponse string
+			var tokenResponse, refreshTokenResponse, errorResponse string
 			var mock *service.MockClient
 
 			if tt.isError {
 				errorResponse = errWebServer.Error()
 			} else {
 				tokenResponse = tokenTest
+				refreshTokenResponse = refreshTokenTest
 			}
 
 			responseJSON := `{
 						"token": "token",
+						"refreshToken": "refreshtoken",
 						"id": 1
 			}`
 
@@ -277,10 +292,11 @@ func TestSignUp(t *testing.T) {
 
 			svc := service.NewService(
 				mock,
+				service.NewMockMailSender(nil),
 				&infoServiceTest,
 			)
 
-			resultToken, resultErr = svc.SignUp(tt.inUsername, tt.inPassword, tt.inEmail)
+			resultToken, resultRefreshToken, resultErr = svc.SignUp(context.TODO(), tt.inUsername, tt.inPassword, tt.inEmail)
 
 			if !tt.isError {
 				assert.Nil(t, resultErr)
@@ -288,6 +304,107 @@ func TestSignUp(t *testing.T) {
 				assert.ErrorContains(t, resultErr, errorResponse)
 			}
 			assert.Equal(t, tokenResponse, resultToken)
+			assert.Equal(t, refreshTokenResponse, resultRefreshToken)
+		})
+	}
+}
+
+func TestSignUpCompensation(t *testing.T) {
+	t.Parallel()
+
+	infoServiceTest := service.InfoServices{
+		DBHost:    dbHostTest,
+		DBPort:    portTest,
+		TokenHost: tokenHostTest,
+		TokenPort: portTest,
+		Secret:    secretTest,
+	}
+
+	for _, tt := range []struct {
+		name           string
+		inFailURL      string
+		inFailMethod   string
+		outErr         string
+		outCompensated []string
+	}{
+		{
+			name:         nameNoError,
+			inFailURL:    "",
+			inFailMethod: "",
+		},
+		{
+			name:         "ErrorInsertUser",
+			inFailURL:    "http://db:8080/user",
+			inFailMethod: http.MethodPost,
+			outErr:       errWebServer.Error(),
+		},
+		{
+			name:         "ErrorSetToken",
+			inFailURL:    "http://token:8080/token",
+			inFailMethod: http.MethodPost,
+			outErr:       errWebServer.Error(),
+			outCompensated: []string{
+				"DELETE http://token:8080/tokens",
+				"DELETE http://db:8080/user",
+			},
+		},
+		{
+			name:         "ErrorDeleteUser",
+			inFailURL:    "http://db:8080/user",
+			inFailMethod: http.MethodDelete,
+			outErr:       "error to undo sign up",
+			outCompensated: []string{
+				"DELETE http://token:8080/tokens",
+				"DELETE http://db:8080/user",
+			},
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var compensated []string
+
+			mock := service.NewMockClient(func(r *http.Request) (*http.Response, error) {
+				if r.Method == http.MethodDelete {
+					compensated = append(compensated, r.Method+" "+r.URL.String())
+
+					var body struct {
+						ID int `json:"id"`
+					}
+
+					_ = json.NewDecoder(r.Body).Decode(&body)
+					assert.Equal(t, idTest, body.ID)
+				}
+
+				if r.URL.String() == tt.inFailURL && r.Method == tt.inFailMethod {
+					return nil, errWebServer
+				}
+
+				// failing the email makes the sign up compensate with the failing delete.
+				if r.URL.String() == "http://token:8080/onetime" && tt.name == "ErrorDeleteUser" {
+					return nil, errWebServer
+				}
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{"token":"token","refreshToken":"refreshtoken","id":1}`)),
+				}, nil
+			})
+
+			svc := service.NewService(mock, service.NewMockMailSender(nil), &infoServiceTest)
+
+			token, _, err := svc.SignUp(context.TODO(), usernameTest, passwordTest, emailTest)
+
+			if tt.outErr == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tokenTest, token)
+			} else {
+				assert.ErrorContains(t, err, tt.outErr)
+				assert.Empty(t, token)
+			}
+
+			assert.Equal(t, tt.outCompensated, compensated)
 		})
 	}
 }
@@ -366,19 +483,21 @@ func TestSignIn(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			var resultToken string
+			var resultToken, resultRefreshToken string
 			var resultErr error
-			var tokenResponse, errorResponse string
+			var tokenResponse, refreshTokenResponse, errorResponse string
 			var mock *service.MockClient
 
 			if tt.isError {
 				errorResponse = errWebServer.Error()
 			} else {
 				tokenResponse = tokenTest
+				refreshTokenResponse = refreshTokenTest
 			}
 
 			responseJSON := `{
 					"token":"token",
+					"refreshToken":"refreshtoken",
 					"user":{
 						"id":       1,
 						"username": "username",
@@ -401,10 +520,11 @@ func TestSignIn(t *testing.T) {
 
 			svc := service.NewService(
 				mock,
+				service.NewMockMailSender(nil),
 				&infoServiceTest,
 			)
 
-			resultToken, resultErr = svc.SignIn(tt.inUsername, tt.inPassword)
+			resultToken, resultRefreshToken, resultErr = svc.SignIn(context.TODO(), tt.inUsername, tt.inPassword)
 
 			if !tt.isError {
 				assert.Nil(t, resultErr)
@@ -412,6 +532,7 @@ func TestSignIn(t *testing.T) {
 				assert.ErrorContains(t, resultErr, errorResponse)
 			}
 			assert.Equal(t, tokenResponse, resultToken)
+			assert.Equal(t, refreshTokenResponse, resultRefreshToken)
 		})
 	}
 }
@@ -462,20 +583,28 @@ func TestLogOut(t *testing.T) {
 			method:   http.MethodPost,
 		},
 		{
-			name:     "ErrorDeleteToken",
+			name:     "ErrorExtractToken",
+			inToken:  tokenTest,
+			outCheck: true,
+			isError:  true,
+			url:      "http://token:8080/extract",
+			method:   http.MethodPost,
+		},
+		{
+			name:     "ErrorRevokeSession",
 			inToken:  tokenTest,
 			outCheck: true,
 			isError:  true,
-			url:      "http://token:8080/token",
+			url:      "http://token:8080/session",
 			method:   http.MethodDelete,
 		},
 		{
-			name:                 "ErrorDeleteToken",
+			name:                 "ErrorInsideRevokeSession",
 			inToken:              tokenTest,
 			outCheck:             true,
 			isError:              true,
 			isErrorInsideRequest: true,
-			url:                  "http://token:8080/token",
+			url:                  "http://token:8080/session",
 			method:               http.MethodDelete,
 		},
 	} {
@@ -517,10 +646,11 @@ func TestLogOut(t *testing.T) {
 
 			svc := service.NewService(
 				mock,
+				service.NewMockMailSender(nil),
 				&infoServiceTest,
 			)
 
-			resultErr = svc.LogOut(tt.inToken)
+			resultErr = svc.LogOut(context.TODO(), tt.inToken)
 
 			if !tt.isError {
 				if tt.outCheck {
@@ -542,7 +672,9 @@ func TestGetAllUsers(t *testing.T) {
 		name                 string
 		url                  string
 		method               string
+		outNext              string
 		outUsers             []dbapp.User
+		outTotal             int
 		isError              bool
 		isErrorInsideRequest bool
 	}{
@@ -552,13 +684,14 @@ func TestGetAllUsers(t *testing.T) {
 				{
 					ID:       idTest,
 					Username: usernameTest,
-					Password: passwordTest,
 					Email:    emailTest,
 				},
 			},
-			isError: false,
-			url:     "http://db:8080/users",
-			method:  http.MethodGet,
+			outTotal: 3,
+			outNext:  "MQ",
+			isError:  false,
+			url:      "http://db:8080/users",
+			method:   http.MethodGet,
 		},
 		{
 			name:     "ErrorGetAllUsers",
@@ -589,6 +722,8 @@ func TestGetAllUsers(t *testing.T) {
 			}
 
 			var resultUsers []dbapp.User
+			var resultTotal int
+			var resultNext string
 			var resultErr error
 			var errorResponse string
 			var mock *service.MockClient
@@ -601,13 +736,16 @@ func TestGetAllUsers(t *testing.T) {
 				"users":[
 					{
 						"username":"username",
-						"password":"password",
 						"email":"email@email.com",
 						"id":1
 					}
-				]
+				],
+				"total":3,
+				"next":"MQ"
 			}`
 
+			query := dbapp.UsersQuery{Limit: 1, UsernamePrefix: "user", Sort: dbapp.SortDesc}
+
 			if tt.isError {
 				mock = service.NewMockClient(getIsErrorMock(
 					tt.isErrorInsideRequest,
@@ -615,17 +753,26 @@ func TestGetAllUsers(t *testing.T) {
 					responseJSON,
 				))
 			} else {
-				mock = service.NewMockClient(getMock(
-					responseJSON,
-				))
+				mock = service.NewMockClient(func(req *http.Request) (*http.Response, error) {
+					var forwarded dbapp.UsersQuery
+
+					if err := json.NewDecoder(req.Body).Decode(&forwarded); err != nil {
+						return nil, err
+					}
+
+					assert.Equal(t, query, forwarded)
+
+					return getMock(responseJSON)(req)
+				})
 			}
 
 			svc := service.NewService(
 				mock,
+				service.NewMockMailSender(nil),
 				&infoServiceTest,
 			)
 
-			resultUsers, resultErr = svc.GetAllUsers()
+			resultUsers, resultTotal, resultNext, resultErr = svc.GetAllUsers(context.TODO(), query)
 
 			if !tt.isError {
 				assert.Nil(t, resultErr)
@@ -633,6 +780,8 @@ func TestGetAllUsers(t *testing.T) {
 				assert.ErrorContains(t, resultErr, errorResponse)
 			}
 			assert.Equal(t, tt.outUsers, resultUsers)
+			assert.Equal(t, tt.outTotal, resultTotal)
+			assert.Equal(t, tt.outNext, resultNext)
 		})
 	}
 }
@@ -665,7 +814,6 @@ func TestProfile(t *testing.T) {
 			responseJSON := fmt.Sprintf(`{
 					"user":{
 						"username":"username",
-						"password":"password",
 						"email":"email@email.com",
 						"id":1
 					},
@@ -689,10 +837,11 @@ func TestProfile(t *testing.T) {
 
 			svc := service.NewService(
 				mock,
+				service.NewMockMailSender(nil),
 				&infoServiceTest,
 			)
 
-			resultUser, resultErr = svc.Profile(tt.inToken)
+			resultUser, resultErr = svc.Profile(context.TODO(), tt.inToken)
 
 			if !tt.isError {
 				if tt.outCheck {
@@ -779,6 +928,14 @@ func TestDeleteAccount(t *testing.T) {
 			url:      "http://db:8080/user",
 			method:   http.MethodDelete,
 		},
+		{
+			name:     "ErrorRevokeTokens",
+			inToken:  tokenTest,
+			outCheck: true,
+			isError:  true,
+			url:      "http://token:8080/tokens",
+			method:   http.MethodDelete,
+		},
 	} {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
@@ -803,7 +960,6 @@ func TestDeleteAccount(t *testing.T) {
 			responseJSON := fmt.Sprintf(`{
 					"user":{
 						"username":"username",
-						"password":"password",
 						"email":"email@email.com",
 						"id":1
 					},
@@ -827,10 +983,11 @@ func TestDeleteAccount(t *testing.T) {
 
 			svc := service.NewService(
 				mock,
+				service.NewMockMailSender(nil),
 				&infoServiceTest,
 			)
 
-			resultErr = svc.DeleteAccount(tt.inToken)
+			resultErr = svc.DeleteAccount(context.TODO(), tt.inToken)
 
 			if !tt.isError {
 				if tt.outCheck {
@@ -845,8 +1002,1414 @@ func TestDeleteAccount(t *testing.T) {
 	}
 }
 
-func getMock(jsonResponse string) func(*http.Request) (*http.Response, error) {
-	return func(r *http.Request) (*http.Response, error) {
+func TestUpdateProfile(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name                 string
+		inUsername, inEmail  string
+		url                  string
+		method               string
+		outToken             string
+		outErr               string
+		rowsAffected         int
+		isError              bool
+		isErrorInsideRequest bool
+	}{
+		{
+			name:         "NoErrorSameClaims",
+			rowsAffected: 1,
+			outToken:     tokenTest,
+		},
+		{
+			name:         "NoErrorNewUsername",
+			inUsername:   "newusername",
+			rowsAffected: 1,
+			outToken:     "newtoken",
+		},
+		{
+			name:         "ErrorUserNotFound",
+			inEmail:      "new@email.com",
+			rowsAffected: 0,
+			outErr:       service.ErrUserNotFound.Error(),
+		},
+		{
+			name:         "ErrorCheckToken",
+			rowsAffected: 1,
+			isError:      true,
+			url:          "http://token:8080/check",
+			method:       http.MethodPost,
+		},
+		{
+			name:                 "ErrorInsideExtractToken",
+			rowsAffected:         1,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://token:8080/extract",
+			method:               http.MethodPost,
+		},
+		{
+			name:         "ErrorUpdateUser",
+			rowsAffected: 1,
+			isError:      true,
+			url:          "http://db:8080/user",
+			method:       http.MethodPatch,
+		},
+		{
+			name:                 "ErrorInsideUpdateUser",
+			rowsAffected:         1,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://db:8080/user",
+			method:               http.MethodPatch,
+		},
+		{
+			name:         "ErrorGenerateToken",
+			inUsername:   "newusername",
+			rowsAffected: 1,
+			isError:      true,
+			url:          "http://token:8080/generate",
+			method:       http.MethodPost,
+		},
+		{
+			name:                 "ErrorInsideSetToken",
+			inUsername:           "newusername",
+			rowsAffected:         1,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://token:8080/token",
+			method:               http.MethodPost,
+		},
+		{
+			name:         "ErrorRevokeOldSession",
+			inUsername:   "newusername",
+			rowsAffected: 1,
+			isError:      true,
+			url:          "http://token:8080/session",
+			method:       http.MethodDelete,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			infoServiceTest := service.InfoServices{
+				DBHost:    dbHostTest,
+				DBPort:    portTest,
+				TokenHost: tokenHostTest,
+				TokenPort: portTest,
+				Secret:    secretTest,
+			}
+
+			var mock *service.MockClient
+
+			responseJSON := fmt.Sprintf(`{
+					"token":"newtoken",
+					"id":1,
+					"username":"username",
+					"email":"email@email.com",
+					"check":true,
+					"rowsAffected":%d
+				}`, tt.rowsAffected)
+
+			if tt.isError {
+				mock = service.NewMockClient(getIsErrorMock(
+					tt.isErrorInsideRequest,
+					newErrorHTTPComponets(tt.url, tt.method),
+					responseJSON,
+				))
+			} else {
+				mock = service.NewMockClient(getMock(
+					responseJSON,
+				))
+			}
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			resultToken, _, resultErr := svc.UpdateProfile(context.TODO(), tokenTest, tt.inUsername, tt.inEmail)
+
+			switch {
+			case tt.isError:
+				assert.ErrorContains(t, resultErr, errWebServer.Error())
+			case tt.outErr != "":
+				assert.ErrorContains(t, resultErr, tt.outErr)
+			default:
+				assert.Nil(t, resultErr)
+			}
+			assert.Equal(t, tt.outToken, resultToken)
+		})
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name                 string
+		inNewPassword        string
+		url                  string
+		method               string
+		outErr               string
+		outUserID            int
+		isError              bool
+		isErrorInsideRequest bool
+		isNotFound           bool
+	}{
+		{
+			name:          nameNoError,
+			inNewPassword: "newpassword",
+			outUserID:     idTest,
+		},
+		{
+			name:          "ErrorEmptyPassword",
+			inNewPassword: "",
+			outUserID:     idTest,
+			outErr:        service.ErrEmptyPassword.Error(),
+		},
+		{
+			name:          "ErrorWrongPassword",
+			inNewPassword: "newpassword",
+			outUserID:     0,
+			outErr:        service.ErrWrongPassword.Error(),
+		},
+		{
+			name:          "ErrorWrongPasswordNotFound",
+			inNewPassword: "newpassword",
+			outUserID:     idTest,
+			outErr:        service.ErrWrongPassword.Error(),
+			url:           "http://db:8080/user/username_password",
+			isNotFound:    true,
+		},
+		{
+			name:          "ErrorCheckToken",
+			inNewPassword: "newpassword",
+			outUserID:     idTest,
+			isError:       true,
+			url:           "http://token:8080/check",
+			method:        http.MethodPost,
+		},
+		{
+			name:          "ErrorGetUser",
+			inNewPassword: "newpassword",
+			outUserID:     idTest,
+			isError:       true,
+			url:           "http://db:8080/user/username_password",
+			method:        http.MethodGet,
+		},
+		{
+			name:                 "ErrorInsideGetUser",
+			inNewPassword:        "newpassword",
+			outUserID:            idTest,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://db:8080/user/username_password",
+			method:               http.MethodGet,
+		},
+		{
+			name:          "ErrorUpdatePassword",
+			inNewPassword: "newpassword",
+			outUserID:     idTest,
+			isError:       true,
+			url:           "http://db:8080/user/password",
+			method:        http.MethodPatch,
+		},
+		{
+			name:                 "ErrorInsideUpdatePassword",
+			inNewPassword:        "newpassword",
+			outUserID:            idTest,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://db:8080/user/password",
+			method:               http.MethodPatch,
+		},
+		{
+			name:          "ErrorRevokeTokens",
+			inNewPassword: "newpassword",
+			outUserID:     idTest,
+			isError:       true,
+			url:           "http://token:8080/tokens",
+			method:        http.MethodDelete,
+		},
+		{
+			name:                 "ErrorInsideRevokeTokens",
+			inNewPassword:        "newpassword",
+			outUserID:            idTest,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://token:8080/tokens",
+			method:               http.MethodDelete,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			infoServiceTest := service.InfoServices{
+				DBHost:    dbHostTest,
+				DBPort:    portTest,
+				TokenHost: tokenHostTest,
+				TokenPort: portTest,
+				Secret:    secretTest,
+			}
+
+			var mock *service.MockClient
+
+			responseJSON := fmt.Sprintf(`{
+					"user":{
+						"username":"username",
+						"email":"email@email.com",
+						"id":%d
+					},
+					"id":1,
+					"username":"username",
+					"email":"email@email.com",
+					"check":true,
+					"rowsAffected":1
+				}`, tt.outUserID)
+
+			switch {
+			case tt.isError:
+				mock = service.NewMockClient(getIsErrorMock(
+					tt.isErrorInsideRequest,
+					newErrorHTTPComponets(tt.url, tt.method),
+					responseJSON,
+				))
+			case tt.isNotFound:
+				mock = service.NewMockClient(getNotFoundMock(tt.url, responseJSON))
+			default:
+				mock = service.NewMockClient(getMock(
+					responseJSON,
+				))
+			}
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			resultErr := svc.ChangePassword(context.TODO(), tokenTest, passwordTest, tt.inNewPassword)
+
+			switch {
+			case tt.isError:
+				assert.ErrorContains(t, resultErr, errWebServer.Error())
+			case tt.outErr != "":
+				assert.ErrorContains(t, resultErr, tt.outErr)
+			default:
+				assert.Nil(t, resultErr)
+			}
+		})
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name                 string
+		url                  string
+		method               string
+		outErr               string
+		inUserID             int
+		isError              bool
+		isErrorInsideRequest bool
+		isErrorMailer        bool
+		isNotFound           bool
+		outMailSent          bool
+	}{
+		{
+			name:        nameNoError,
+			inUserID:    idTest,
+			outMailSent: true,
+		},
+		{
+			name:        "NoErrorUnknownEmail",
+			inUserID:    idTest,
+			url:         "http://db:8080/id/email",
+			isNotFound:  true,
+			outMailSent: false,
+		},
+		{
+			name:     "ErrorGetID",
+			inUserID: idTest,
+			isError:  true,
+			url:      "http://db:8080/id/email",
+			method:   http.MethodGet,
+		},
+		{
+			name:                 "ErrorInsideGetID",
+			inUserID:             idTest,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://db:8080/id/email",
+			method:               http.MethodGet,
+		},
+		{
+			name:     "ErrorGenerateResetToken",
+			inUserID: idTest,
+			isError:  true,
+			url:      "http://token:8080/onetime",
+			method:   http.MethodPost,
+		},
+		{
+			name:                 "ErrorInsideGenerateResetToken",
+			inUserID:             idTest,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://token:8080/onetime",
+			method:               http.MethodPost,
+		},
+		{
+			name:          "ErrorSendMail",
+			inUserID:      idTest,
+			isErrorMailer: true,
+			outErr:        "error to send email",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			infoServiceTest := service.InfoServices{
+				DBHost:    dbHostTest,
+				DBPort:    portTest,
+				TokenHost: tokenHostTest,
+				TokenPort: portTest,
+				Secret:    secretTest,
+			}
+
+			var mock *service.MockClient
+
+			var mailSent bool
+
+			responseJSON := fmt.Sprintf(`{
+					"token":"resettoken",
+					"id":%d
+				}`, tt.inUserID)
+
+			switch {
+			case tt.isError:
+				mock = service.NewMockClient(getIsErrorMock(
+					tt.isErrorInsideRequest,
+					newErrorHTTPComponets(tt.url, tt.method),
+					responseJSON,
+				))
+			case tt.isNotFound:
+				mock = service.NewMockClient(getNotFoundMock(tt.url, responseJSON))
+			default:
+				mock = service.NewMockClient(getMock(
+					responseJSON,
+				))
+			}
+
+			mailer := service.NewMockMailSender(func(to, _, body string) error {
+				if tt.isErrorMailer {
+					return errWebServer
+				}
+
+				mailSent = true
+
+				assert.Equal(t, emailTest, to)
+				assert.Contains(t, body, "resettoken")
+
+				return nil
+			})
+
+			svc := service.NewService(
+				mock,
+				mailer,
+				&infoServiceTest,
+			)
+
+			resultErr := svc.ForgotPassword(context.TODO(), emailTest)
+
+			switch {
+			case tt.isError:
+				assert.ErrorContains(t, resultErr, errWebServer.Error())
+			case tt.outErr != "":
+				assert.ErrorContains(t, resultErr, tt.outErr)
+			default:
+				assert.Nil(t, resultErr)
+			}
+
+			assert.Equal(t, tt.outMailSent, mailSent)
+		})
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name                 string
+		inNewPassword        string
+		url                  string
+		method               string
+		outErr               string
+		rowsAffected         int
+		isError              bool
+		isErrorInsideRequest bool
+	}{
+		{
+			name:          nameNoError,
+			inNewPassword: "newpassword",
+			rowsAffected:  1,
+		},
+		{
+			name:          "ErrorEmptyPassword",
+			inNewPassword: "",
+			rowsAffected:  1,
+			outErr:        service.ErrEmptyPassword.Error(),
+		},
+		{
+			name:          "ErrorUserNotFound",
+			inNewPassword: "newpassword",
+			rowsAffected:  0,
+			outErr:        service.ErrUserNotFound.Error(),
+		},
+		{
+			name:                 "ErrorInsideConsumeResetToken",
+			inNewPassword:        "newpassword",
+			rowsAffected:         1,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://token:8080/onetime/consume",
+			method:               http.MethodPost,
+		},
+		{
+			name:          "ErrorUpdatePassword",
+			inNewPassword: "newpassword",
+			rowsAffected:  1,
+			isError:       true,
+			url:           "http://db:8080/user/password",
+			method:        http.MethodPatch,
+		},
+		{
+			name:                 "ErrorInsideRevokeTokens",
+			inNewPassword:        "newpassword",
+			rowsAffected:         1,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://token:8080/tokens",
+			method:               http.MethodDelete,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			infoServiceTest := service.InfoServices{
+				DBHost:    dbHostTest,
+				DBPort:    portTest,
+				TokenHost: tokenHostTest,
+				TokenPort: portTest,
+				Secret:    secretTest,
+			}
+
+			var mock *service.MockClient
+
+			responseJSON := fmt.Sprintf(`{
+					"id":1,
+					"rowsAffected":%d
+				}`, tt.rowsAffected)
+
+			if tt.isError {
+				mock = service.NewMockClient(getIsErrorMock(
+					tt.isErrorInsideRequest,
+					newErrorHTTPComponets(tt.url, tt.method),
+					responseJSON,
+				))
+			} else {
+				mock = service.NewMockClient(getMock(
+					responseJSON,
+				))
+			}
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			resultErr := svc.ResetPassword(context.TODO(), "resettoken", tt.inNewPassword)
+
+			switch {
+			case tt.isError:
+				assert.ErrorContains(t, resultErr, errWebServer.Error())
+			case tt.outErr != "":
+				assert.ErrorContains(t, resultErr, tt.outErr)
+			default:
+				assert.Nil(t, resultErr)
+			}
+		})
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name                 string
+		url                  string
+		method               string
+		isError              bool
+		isErrorInsideRequest bool
+	}{
+		{
+			name: nameNoError,
+		},
+		{
+			name:    "ErrorRefresh",
+			isError: true,
+			url:     "http://token:8080/refresh",
+			method:  http.MethodPost,
+		},
+		{
+			name:                 "ErrorInsideRefresh",
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://token:8080/refresh",
+			method:               http.MethodPost,
+		},
+		{
+			name:    "ErrorExtractToken",
+			isError: true,
+			url:     "http://token:8080/extract",
+			method:  http.MethodPost,
+		},
+		{
+			name:    "ErrorGetUser",
+			isError: true,
+			url:     "http://db:8080/user/id",
+			method:  http.MethodGet,
+		},
+		{
+			name:    "ErrorSetToken",
+			isError: true,
+			url:     "http://token:8080/token",
+			method:  http.MethodPost,
+		},
+		{
+			name:                 "ErrorInsideSetToken",
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://token:8080/token",
+			method:               http.MethodPost,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			infoServiceTest := service.InfoServices{
+				DBHost:    dbHostTest,
+				DBPort:    portTest,
+				TokenHost: tokenHostTest,
+				TokenPort: portTest,
+				Secret:    secretTest,
+			}
+
+			var mock *service.MockClient
+
+			responseJSON := `{
+					"token":"token",
+					"refreshToken":"refreshtoken",
+					"user":{
+						"username":"username",
+						"email":"email@email.com",
+						"role":"user",
+						"id":1
+					},
+					"id":1,
+					"username":"username",
+					"email":"email@email.com",
+					"role":"user"
+				}`
+
+			if tt.isError {
+				mock = service.NewMockClient(getIsErrorMock(
+					tt.isErrorInsideRequest,
+					newErrorHTTPComponets(tt.url, tt.method),
+					responseJSON,
+				))
+			} else {
+				mock = service.NewMockClient(getMock(
+					responseJSON,
+				))
+			}
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			resultToken, resultRefreshToken, resultErr := svc.RefreshToken(context.TODO(), "oldrefreshtoken")
+
+			if tt.isError {
+				assert.ErrorContains(t, resultErr, errWebServer.Error())
+				assert.Empty(t, resultToken)
+				assert.Empty(t, resultRefreshToken)
+			} else {
+				assert.Nil(t, resultErr)
+				assert.Equal(t, tokenTest, resultToken)
+				assert.Equal(t, refreshTokenTest, resultRefreshToken)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenChecksUser(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		outErr      error
+		name        string
+		inStatus    int
+		inUser      string
+		outToken    string
+		outRevoked  bool
+		outSession  bool
+		outReissued bool
+	}{
+		{
+			name:     "Unchanged",
+			inStatus: http.StatusOK,
+			inUser:   `{"user":{"id":1,"username":"username","email":"email@email.com","role":"user"}}`,
+			outToken: "rotated",
+		},
+		{
+			name:        "ClaimsChanged",
+			inStatus:    http.StatusOK,
+			inUser:      `{"user":{"id":1,"username":"username","email":"new@email.com","role":"user"}}`,
+			outToken:    "reissued",
+			outSession:  true,
+			outReissued: true,
+		},
+		{
+			name:       "Suspended",
+			inStatus:   http.StatusOK,
+			inUser:     `{"user":{"id":1,"username":"username","email":"email@email.com","role":"user","suspended":true}}`,
+			outErr:     service.ErrUserSuspended,
+			outRevoked: true,
+		},
+		{
+			name:       "Deleted",
+			inStatus:   http.StatusNotFound,
+			inUser:     `{"type":"about:blank","title":"Not Found","status":404,"detail":"user not found"}`,
+			outErr:     service.ErrTokenNotValid,
+			outRevoked: true,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var revoked, session, reissued bool
+
+			mock := service.NewMockClient(func(r *http.Request) (*http.Response, error) {
+				status, body := http.StatusOK, `{}`
+
+				switch r.URL.String() {
+				case "http://token:8080/refresh":
+					body = `{"token":"rotated","refreshToken":"rotatedrefresh"}`
+				case "http://token:8080/extract":
+					body = `{"id":1,"username":"username","email":"email@email.com","role":"user"}`
+				case "http://db:8080/user/id":
+					status, body = tt.inStatus, tt.inUser
+				case "http://token:8080/generate":
+					reissued = true
+					body = `{"token":"reissued","refreshToken":"reissuedrefresh"}`
+				case "http://token:8080/tokens":
+					revoked = true
+				case "http://token:8080/session":
+					session = true
+				}
+
+				return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}, nil
+			})
+
+			svc := service.NewService(mock, service.NewMockMailSender(nil), &service.InfoServices{
+				DBHost:    dbHostTest,
+				DBPort:    portTest,
+				TokenHost: tokenHostTest,
+				TokenPort: portTest,
+				Secret:    secretTest,
+			})
+
+			token, _, err := svc.RefreshToken(context.TODO(), "oldrefreshtoken")
+
+			if tt.outErr != nil {
+				assert.ErrorIs(t, err, tt.outErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.outToken, token)
+			assert.Equal(t, tt.outRevoked, revoked)
+			assert.Equal(t, tt.outSession, session)
+			assert.Equal(t, tt.outReissued, reissued)
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name                 string
+		inToken              string
+		inRole               string
+		url                  string
+		method               string
+		outErr               string
+		isError              bool
+		isErrorInsideRequest bool
+	}{
+		{
+			name:    nameNoError,
+			inToken: tokenTest,
+			inRole:  dbapp.RoleAdmin,
+		},
+		{
+			name:    "ErrorForbidden",
+			inToken: tokenTest,
+			inRole:  "superadmin",
+			outErr:  service.ErrForbidden.Error(),
+		},
+		{
+			name:    "ErrorWithoutToken",
+			inToken: "",
+			inRole:  dbapp.RoleAdmin,
+			outErr:  service.ErrTokenNotValid.Error(),
+		},
+		{
+			name:    "ErrorCheckToken",
+			inToken: tokenTest,
+			inRole:  dbapp.RoleAdmin,
+			isError: true,
+			url:     "http://token:8080/check",
+			method:  http.MethodPost,
+		},
+		{
+			name:                 "ErrorInsideExtractToken",
+			inToken:              tokenTest,
+			inRole:               dbapp.RoleAdmin,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://token:8080/extract",
+			method:               http.MethodPost,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			infoServiceTest := service.InfoServices{
+				DBHost:    dbHostTest,
+				DBPort:    portTest,
+				TokenHost: tokenHostTest,
+				TokenPort: portTest,
+				Secret:    secretTest,
+			}
+
+			var mock *service.MockClient
+
+			responseJSON := `{
+					"check":true,
+					"id":1,
+					"role":"admin"
+				}`
+
+			if tt.isError {
+				mock = service.NewMockClient(getIsErrorMock(
+					tt.isErrorInsideRequest,
+					newErrorHTTPComponets(tt.url, tt.method),
+					responseJSON,
+				))
+			} else {
+				mock = service.NewMockClient(getMock(
+					responseJSON,
+				))
+			}
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			resultErr := svc.Authorize(context.TODO(), tt.inToken, tt.inRole)
+
+			switch {
+			case tt.isError:
+				assert.ErrorContains(t, resultErr, errWebServer.Error())
+			case tt.outErr != "":
+				assert.ErrorContains(t, resultErr, tt.outErr)
+			default:
+				assert.Nil(t, resultErr)
+			}
+		})
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name                 string
+		url                  string
+		method               string
+		outErr               string
+		rowsAffected         int
+		isError              bool
+		isErrorInsideRequest bool
+	}{
+		{
+			name:         nameNoError,
+			rowsAffected: 1,
+		},
+		{
+			name:         "ErrorUserNotFound",
+			rowsAffected: 0,
+			outErr:       service.ErrUserNotFound.Error(),
+		},
+		{
+			name:         "ErrorDeleteUser",
+			rowsAffected: 1,
+			isError:      true,
+			url:          "http://db:8080/user",
+			method:       http.MethodDelete,
+		},
+		{
+			name:                 "ErrorInsideDeleteUser",
+			rowsAffected:         1,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://db:8080/user",
+			method:               http.MethodDelete,
+		},
+		{
+			name:                 "ErrorInsideRevokeTokens",
+			rowsAffected:         1,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://token:8080/tokens",
+			method:               http.MethodDelete,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			infoServiceTest := service.InfoServices{
+				DBHost:    dbHostTest,
+				DBPort:    portTest,
+				TokenHost: tokenHostTest,
+				TokenPort: portTest,
+				Secret:    secretTest,
+			}
+
+			var mock *service.MockClient
+
+			responseJSON := fmt.Sprintf(`{
+					"rowsAffected":%d
+				}`, tt.rowsAffected)
+
+			if tt.isError {
+				mock = service.NewMockClient(getIsErrorMock(
+					tt.isErrorInsideRequest,
+					newErrorHTTPComponets(tt.url, tt.method),
+					responseJSON,
+				))
+			} else {
+				mock = service.NewMockClient(getMock(
+					responseJSON,
+				))
+			}
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			resultErr := svc.DeleteUser(context.TODO(), idTest)
+
+			switch {
+			case tt.isError:
+				assert.ErrorContains(t, resultErr, errWebServer.Error())
+			case tt.outErr != "":
+				assert.ErrorContains(t, resultErr, tt.outErr)
+			default:
+				assert.Nil(t, resultErr)
+			}
+		})
+	}
+}
+
+func TestSuspendUser(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name                 string
+		url                  string
+		method               string
+		outErr               string
+		rowsAffected         int
+		inSuspended          bool
+		isError              bool
+		isErrorInsideRequest bool
+	}{
+		{
+			name:         nameNoError,
+			inSuspended:  true,
+			rowsAffected: 1,
+		},
+		{
+			name:         "NoErrorReinstate",
+			inSuspended:  false,
+			rowsAffected: 1,
+		},
+		{
+			name:         "ErrorUserNotFound",
+			inSuspended:  true,
+			rowsAffected: 0,
+			outErr:       service.ErrUserNotFound.Error(),
+		},
+		{
+			name:         "ErrorSuspendUser",
+			inSuspended:  true,
+			rowsAffected: 1,
+			isError:      true,
+			url:          "http://db:8080/user/suspended",
+			method:       http.MethodPatch,
+		},
+		{
+			name:                 "ErrorInsideSuspendUser",
+			inSuspended:          true,
+			rowsAffected:         1,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://db:8080/user/suspended",
+			method:               http.MethodPatch,
+		},
+		{
+			name:                 "ErrorInsideRevokeTokens",
+			inSuspended:          true,
+			rowsAffected:         1,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://token:8080/tokens",
+			method:               http.MethodDelete,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			infoServiceTest := service.InfoServices{
+				DBHost:    dbHostTest,
+				DBPort:    portTest,
+				TokenHost: tokenHostTest,
+				TokenPort: portTest,
+				Secret:    secretTest,
+			}
+
+			var mock *service.MockClient
+
+			responseJSON := fmt.Sprintf(`{
+					"rowsAffected":%d
+				}`, tt.rowsAffected)
+
+			if tt.isError {
+				mock = service.NewMockClient(getIsErrorMock(
+					tt.isErrorInsideRequest,
+					newErrorHTTPComponets(tt.url, tt.method),
+					responseJSON,
+				))
+			} else {
+				mock = service.NewMockClient(getMock(
+					responseJSON,
+				))
+			}
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			resultErr := svc.SuspendUser(context.TODO(), idTest, tt.inSuspended)
+
+			switch {
+			case tt.isError:
+				assert.ErrorContains(t, resultErr, errWebServer.Error())
+			case tt.outErr != "":
+				assert.ErrorContains(t, resultErr, tt.outErr)
+			default:
+				assert.Nil(t, resultErr)
+			}
+		})
+	}
+}
+
+func TestSignInSuspendedUser(t *testing.T) {
+	t.Parallel()
+
+	infoServiceTest := service.InfoServices{
+		DBHost:    dbHostTest,
+		DBPort:    portTest,
+		TokenHost: tokenHostTest,
+		TokenPort: portTest,
+		Secret:    secretTest,
+	}
+
+	responseJSON := `{
+			"token":"token",
+			"user":{"id":1,"suspended":true}
+		}`
+
+	svc := service.NewService(
+		service.NewMockClient(getMock(responseJSON)),
+		service.NewMockMailSender(nil),
+		&infoServiceTest,
+	)
+
+	resultToken, _, resultErr := svc.SignIn(context.TODO(), usernameTest, passwordTest)
+
+	assert.ErrorIs(t, resultErr, service.ErrUserSuspended)
+	assert.Empty(t, resultToken)
+}
+
+func TestSignInRecordsResult(t *testing.T) {
+	t.Parallel()
+
+	infoServiceTest := service.InfoServices{
+		DBHost:    dbHostTest,
+		DBPort:    portTest,
+		TokenHost: tokenHostTest,
+		TokenPort: portTest,
+		Secret:    secretTest,
+	}
+
+	for _, tt := range []struct {
+		outKind    error
+		name       string
+		inBody     string
+		inStatus   int
+		outResults []bool
+		outSession bool
+	}{
+		{
+			name:       "NoError",
+			inStatus:   http.StatusOK,
+			inBody:     `{"user":{"id":1}}`,
+			outResults: []bool{true},
+			outSession: true,
+		},
+		{
+			name:       "WrongPassword",
+			inStatus:   http.StatusNotFound,
+			inBody:     `{"type":"about:blank","title":"Not Found","status":404,"detail":"user not found"}`,
+			outKind:    service.ErrWrongPassword,
+			outResults: []bool{false},
+		},
+		{
+			name:       "WrongPasswordEmptyUser",
+			inStatus:   http.StatusOK,
+			inBody:     `{"user":{"username":"","email":"","role":"","id":0,"emailVerified":false,"suspended":false}}`,
+			outKind:    service.ErrWrongPassword,
+			outResults: []bool{false},
+		},
+		{
+			name:     "ErrorDatabase",
+			inStatus: http.StatusInternalServerError,
+			inBody:   `{"user":{"id":1}}`,
+			outKind:  service.ErrWebServer,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				results []bool
+				session bool
+			)
+
+			mock := service.NewMockClient(func(r *http.Request) (*http.Response, error) {
+				switch r.URL.String() {
+				case "http://db:8080/user/username_password":
+					return &http.Response{
+						StatusCode: tt.inStatus,
+						Body:       io.NopCloser(strings.NewReader(tt.inBody)),
+					}, nil
+				case "http://token:8080/generate":
+					session = true
+				case "http://token:8080/signin/result":
+					var body struct {
+						Success bool `json:"success"`
+					}
+
+					_ = json.NewDecoder(r.Body).Decode(&body)
+					results = append(results, body.Success)
+				}
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{"token":"token"}`)),
+				}, nil
+			})
+
+			svc := service.NewService(mock, service.NewMockMailSender(nil), &infoServiceTest)
+
+			_, _, err := svc.SignIn(context.TODO(), usernameTest, passwordTest)
+
+			if tt.outKind == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.outKind)
+			}
+
+			assert.Equal(t, tt.outResults, results)
+			assert.Equal(t, tt.outSession, session)
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name                 string
+		url                  string
+		method               string
+		outErr               string
+		rowsAffected         int
+		isError              bool
+		isErrorInsideRequest bool
+	}{
+		{
+			name:         nameNoError,
+			rowsAffected: 1,
+		},
+		{
+			name:         "ErrorEmailChanged",
+			rowsAffected: 0,
+			outErr:       service.ErrVerificationNotValid.Error(),
+		},
+		{
+			name:         "ErrorConsumeVerificationToken",
+			rowsAffected: 1,
+			isError:      true,
+			url:          "http://token:8080/onetime/consume",
+			method:       http.MethodPost,
+		},
+		{
+			name:                 "ErrorInsideConsumeVerificationToken",
+			rowsAffected:         1,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://token:8080/onetime/consume",
+			method:               http.MethodPost,
+		},
+		{
+			name:         "ErrorMarkEmailVerified",
+			rowsAffected: 1,
+			isError:      true,
+			url:          "http://db:8080/user/email_verified",
+			method:       http.MethodPatch,
+		},
+		{
+			name:                 "ErrorInsideMarkEmailVerified",
+			rowsAffected:         1,
+			isError:              true,
+			isErrorInsideRequest: true,
+			url:                  "http://db:8080/user/email_verified",
+			method:               http.MethodPatch,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			infoServiceTest := service.InfoServices{
+				DBHost:    dbHostTest,
+				DBPort:    portTest,
+				TokenHost: tokenHostTest,
+				TokenPort: portTest,
+				Secret:    secretTest,
+			}
+
+			var mock *service.MockClient
+
+			responseJSON := fmt.Sprintf(`{
+					"id":1,
+					"rowsAffected":%d
+				}`, tt.rowsAffected)
+
+			if tt.isError {
+				mock = service.NewMockClient(getIsErrorMock(
+					tt.isErrorInsideRequest,
+					newErrorHTTPComponets(tt.url, tt.method),
+					responseJSON,
+				))
+			} else {
+				mock = service.NewMockClient(getMock(
+					responseJSON,
+				))
+			}
+
+			svc := service.NewService(
+				mock,
+				service.NewMockMailSender(nil),
+				&infoServiceTest,
+			)
+
+			resultErr := svc.VerifyEmail(context.TODO(), "verificationtoken")
+
+			switch {
+			case tt.isError:
+				assert.ErrorContains(t, resultErr, errWebServer.Error())
+			case tt.outErr != "":
+				assert.ErrorContains(t, resultErr, tt.outErr)
+			default:
+				assert.Nil(t, resultErr)
+			}
+		})
+	}
+}
+
+func TestVerifyEmailBindsEmail(t *testing.T) {
+	t.Parallel()
+
+	var verified dbapp.IDEmailRequest
+
+	mock := service.NewMockClient(func(r *http.Request) (*http.Response, error) {
+		body := `{"id":1,"email":"old@email.com"}`
+
+		if r.URL.String() == "http://db:8080/user/email_verified" {
+			_ = json.NewDecoder(r.Body).Decode(&verified)
+			body = `{"rowsAffected":1}`
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	})
+
+	svc := service.NewService(mock, service.NewMockMailSender(nil), &service.InfoServices{
+		DBHost:    dbHostTest,
+		DBPort:    portTest,
+		TokenHost: tokenHostTest,
+		TokenPort: portTest,
+		Secret:    secretTest,
+	})
+
+	assert.NoError(t, svc.VerifyEmail(context.TODO(), "verificationtoken"))
+	assert.Equal(t, dbapp.IDEmailRequest{ID: 1, Email: "old@email.com"}, verified)
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name            string
+		inEmailVerified bool
+		outSignInErr    string
+	}{
+		{
+			name:            nameNoError,
+			inEmailVerified: true,
+		},
+		{
+			name:            "ErrorEmailNotVerified",
+			inEmailVerified: false,
+			outSignInErr:    service.ErrEmailNotVerified.Error(),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			infoServiceTest := service.InfoServices{
+				DBHost:               dbHostTest,
+				DBPort:               portTest,
+				TokenHost:            tokenHostTest,
+				TokenPort:            portTest,
+				Secret:               secretTest,
+				RequireVerifiedEmail: true,
+			}
+
+			var mailSent bool
+
+			responseJSON := fmt.Sprintf(`{
+					"token":"token",
+					"id":1,
+					"user":{"id":1,"emailVerified":%t}
+				}`, tt.inEmailVerified)
+
+			mailer := service.NewMockMailSender(func(to, _, body string) error {
+				mailSent = true
+
+				assert.Equal(t, emailTest, to)
+				assert.Contains(t, body, "/verify-email?token=token")
+
+				return nil
+			})
+
+			svc := service.NewService(
+				service.NewMockClient(getMock(responseJSON)),
+				mailer,
+				&infoServiceTest,
+			)
+
+			// SignUp mails the verification link but doesn't open a session.
+			resultToken, _, resultErr := svc.SignUp(context.TODO(), usernameTest, passwordTest, emailTest)
+			assert.Nil(t, resultErr)
+			assert.Empty(t, resultToken)
+			assert.True(t, mailSent)
+
+			_, _, resultErr = svc.SignIn(context.TODO(), usernameTest, passwordTest)
+			if tt.outSignInErr != "" {
+				assert.ErrorContains(t, resultErr, tt.outSignInErr)
+			} else {
+				assert.Nil(t, resultErr)
+			}
+		})
+	}
+}
+
+func getMock(jsonResponse string) func(*http.Request) (*http.Response, error) {
+	return func(r *http.Request) (*http.Response, error) {
+		return &http.Response{
+			Body: io.NopCloser(strings.NewReader(jsonResponse)),
+		}, nil
+	}
+}
+
+// getNotFoundMock answers the request to url like database-app does when no
+// user matches it.
+func getNotFoundMock(url, jsonResponse string) func(*http.Request) (*http.Response, error) {
+	return func(r *http.Request) (*http.Response, error) {
+		if r.URL.String() == url {
+			return &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body: io.NopCloser(strings.NewReader(`{
+					"type":"about:blank",
+					"title":"Not Found",
+					"status":404,
+					"detail":"user not found"
+				}`)),
+			}, nil
+		}
+
 		return &http.Response{
 			Body: io.NopCloser(strings.NewReader(jsonResponse)),
 		}, nil
@@ -863,8 +2426,12 @@ func getIsErrorMock(
 		if r.URL.String() == errorHTTPComponents.errorURL && r.Method == errorHTTPComponents.errorMethod {
 			if isErrorInsideRequest {
 				return &http.Response{
+					StatusCode: http.StatusInternalServerError,
 					Body: io.NopCloser(bytes.NewReader([]byte(`{
-										"err":"error"
+										"type":"about:blank",
+										"title":"Internal Server Error",
+										"status":500,
+										"detail":"error"
 									}`),
 					)),
 				}, nil
@@ -0,0 +1,116 @@
+package service_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	recorderOnce sync.Once
+	recorder     *tracetest.SpanRecorder
+)
+
+// spanRecorder installs, once for every test, a global tracer provider that
+// records the spans.
+func spanRecorder() *tracetest.SpanRecorder {
+	recorderOnce.Do(func() {
+		recorder = tracetest.NewSpanRecorder()
+
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+
+	return recorder
+}
+
+// endedSpans are the ended spans of the trace of traceID.
+func endedSpans(traceID trace.TraceID) map[string]sdktrace.ReadOnlySpan {
+	spans := map[string]sdktrace.ReadOnlySpan{}
+
+	for _, span := range spanRecorder().Ended() {
+		if span.SpanContext().TraceID() == traceID {
+			spans[span.Name()] = span
+		}
+	}
+
+	return spans
+}
+
+func TestRequestFuncTracing(t *testing.T) {
+	t.Parallel()
+
+	spanRecorder()
+
+	for _, tt := range []struct {
+		name     string
+		inStatus int
+		outCode  codes.Code
+	}{
+		{
+			name:     nameNoError,
+			inStatus: http.StatusOK,
+			outCode:  codes.Unset,
+		},
+		{
+			name:     "ErrorNotFound",
+			inStatus: http.StatusNotFound,
+			outCode:  codes.Error,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var traceparent string
+
+			mock := service.NewMockClient(func(req *http.Request) (*http.Response, error) {
+				traceparent = req.Header.Get("traceparent")
+
+				return &http.Response{
+					StatusCode: tt.inStatus,
+					Body:       io.NopCloser(strings.NewReader(`{"id": 1}`)),
+				}, nil
+			})
+
+			ctx, parent := otel.Tracer("test").Start(context.TODO(), "parent")
+
+			var response dbapp.IDErrorResponse
+
+			_ = service.RequestFunc(
+				ctx,
+				mock,
+				dbapp.UsernameRequest{Username: usernameTest},
+				service.NewHTTPComponents("http://db-app:7070/id/username", http.MethodGet),
+				&response,
+			)
+
+			parent.End()
+
+			span, ok := endedSpans(parent.SpanContext().TraceID())["GET /id/username"]
+			if !assert.True(t, ok) {
+				return
+			}
+
+			assert.Equal(t, trace.SpanKindClient, span.SpanKind())
+			assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+			assert.Contains(t, traceparent, span.SpanContext().SpanID().String())
+			assert.Contains(t, span.Attributes(), semconv.HTTPStatusCodeKey.Int(tt.inStatus))
+			assert.Equal(t, tt.outCode, span.Status().Code)
+		})
+	}
+}
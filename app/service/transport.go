@@ -5,12 +5,22 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/cfabrica46/gokit-crud/platform/logging"
 	httptransport "github.com/go-kit/kit/transport/http"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var errFailedGetHeader = errors.New("failed to get header")
+var (
+	errFailedGetHeader = &Error{Kind: ErrUnauthorized, Err: errors.New("failed to get header")}
+	errFailedGetQuery  = &Error{Kind: ErrValidation, Err: errors.New("failed to get query parameter")}
+	errInvalidQuery    = &Error{Kind: ErrValidation, Err: errors.New("invalid query parameter")}
+)
 
 // DecodeRequestWithoutBody ...
 func DecodeRequestWithoutBody() httptransport.DecodeRequestFunc {
@@ -23,11 +33,16 @@ func DecodeRequestWithoutBody() httptransport.DecodeRequestFunc {
 
 // DecodeRequest ...
 func DecodeRequestWithBody[req UsernamePasswordEmailRequest |
-	UsernamePasswordRequest](request req,
+	UsernamePasswordRequest |
+	EmailRequest |
+	TokenPasswordRequest |
+	RefreshTokenRequest |
+	IDRequest |
+	IDSuspendedRequest](request req,
 ) httptransport.DecodeRequestFunc {
 	return func(_ context.Context, r *http.Request) (any, error) {
 		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-			return nil, fmt.Errorf("failed to decode request: %w", err)
+			return nil, &Error{Kind: ErrValidation, Err: fmt.Errorf("failed to decode request: %w", err)}
 		}
 
 		return request, nil
@@ -46,6 +61,147 @@ func DecodeRequestWithHeader(request TokenRequest) httptransport.DecodeRequestFu
 	}
 }
 
+// DecodeRequestWithFields reads the ?fields= projection, a UsersQueryRequest
+// also takes the limit, cursor, username, email and sort parameters and a
+// TokenFieldsRequest the token from the Authorization header.
+func DecodeRequestWithFields[req UsersQueryRequest | TokenFieldsRequest](request req,
+) httptransport.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (any, error) {
+		var fields []string
+
+		if query := r.URL.Query().Get("fields"); query != "" {
+			fields = strings.Split(query, ",")
+		}
+
+		switch typedRequest := any(&request).(type) {
+		case *UsersQueryRequest:
+			query := r.URL.Query()
+
+			if limit := query.Get("limit"); limit != "" {
+				var err error
+
+				typedRequest.Query.Limit, err = strconv.Atoi(limit)
+				if err != nil {
+					return nil, fmt.Errorf("%w: limit", errInvalidQuery)
+				}
+			}
+
+			typedRequest.Query.Cursor = query.Get("cursor")
+			typedRequest.Query.UsernamePrefix = query.Get("username")
+			typedRequest.Query.EmailPrefix = query.Get("email")
+			typedRequest.Query.Sort = query.Get("sort")
+			typedRequest.Fields = fields
+		case *TokenFieldsRequest:
+			if r.Header.Get("Authorization") == "" {
+				return nil, errFailedGetHeader
+			}
+
+			typedRequest.Token = r.Header.Get("Authorization")
+			typedRequest.Fields = fields
+		}
+
+		return request, nil
+	}
+}
+
+// TokenToContext moves the Authorization header into the request context so
+// endpoint middlewares can authorize the caller.
+func TokenToContext() httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		return context.WithValue(ctx, tokenContextKey, r.Header.Get("Authorization"))
+	}
+}
+
+// ClientIPToContext puts the IP of the caller in the request context, the
+// IP the sign in attempts are limited by. Behind a trusted proxy it is the
+// last address of X-Forwarded-For, the one the proxy saw, and else the
+// remote address of the connection.
+func ClientIPToContext(trustProxy bool) httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		ip := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+
+		if forwarded := r.Header.Get("X-Forwarded-For"); trustProxy && forwarded != "" {
+			addrs := strings.Split(forwarded, ",")
+			ip = strings.TrimSpace(addrs[len(addrs)-1])
+		}
+
+		return context.WithValue(ctx, clientIPContextKey, ip)
+	}
+}
+
+// DecodeRequestWithQuery ...
+func DecodeRequestWithQuery(request TokenRequest) httptransport.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (any, error) {
+		if r.URL.Query().Get("token") == "" {
+			return nil, errFailedGetQuery
+		}
+
+		request.Token = r.URL.Query().Get("token")
+
+		return request, nil
+	}
+}
+
+// DecodeRequestWithHeaderAndBody ...
+func DecodeRequestWithHeaderAndBody[req TokenUsernameEmailRequest |
+	TokenOldNewPasswordRequest](request req,
+) httptransport.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (any, error) {
+		token := r.Header.Get("Authorization")
+		if token == "" {
+			return nil, errFailedGetHeader
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			return nil, &Error{Kind: ErrValidation, Err: fmt.Errorf("failed to decode request: %w", err)}
+		}
+
+		switch typedRequest := any(&request).(type) {
+		case *TokenUsernameEmailRequest:
+			typedRequest.Token = token
+		case *TokenOldNewPasswordRequest:
+			typedRequest.Token = token
+		}
+
+		return request, nil
+	}
+}
+
+// EncodeError is the ServerErrorEncoder of every route, it reports err as
+// an RFC 7807 problem document. The detail of a failure of the service is
+// left out, it would leak the internals, LoggingMiddleware logs it instead.
+func EncodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	status := StatusCode(err)
+
+	detail := err.Error()
+	if status >= http.StatusInternalServerError {
+		detail = ""
+	}
+
+	trace.SpanFromContext(ctx).RecordError(err)
+
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		w.Header().Set(logging.RequestIDHeader, requestID)
+	}
+
+	if retryAfter := RetryAfter(err); retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+}
+
 // EncodeResponse ...
 func EncodeResponse(_ context.Context, w http.ResponseWriter, response any) (err error) {
 	if err = json.NewEncoder(w).Encode(response); err != nil {
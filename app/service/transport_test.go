@@ -3,11 +3,17 @@ package service_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/cfabrica46/gokit-crud/app/service"
+	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -24,6 +30,32 @@ const (
 		 "username": "username",
 		 "password": "password"
 	}`
+
+	usernameEmailRequestJSON = `{
+		 "username": "username",
+		 "email": "email@email.com"
+	}`
+
+	emailRequestJSON = `{
+		 "email": "email@email.com"
+	}`
+
+	//nolint:gosec
+	tokenPasswordRequestJSON = `{
+		 "token": "token",
+		 "password": "password"
+	}`
+
+	//nolint:gosec
+	refreshTokenRequestJSON = `{
+		 "refreshToken": "token"
+	}`
+
+	//nolint:gosec
+	oldNewPasswordRequestJSON = `{
+		 "oldPassword": "password",
+		 "newPassword": "newpassword"
+	}`
 )
 
 func TestDecodeRequestWithoutBody(t *testing.T) {
@@ -75,6 +107,33 @@ func TestDecodeRequestWithBody(t *testing.T) {
 		assert.Error(t, err)
 	}
 
+	emailReq, err := http.NewRequest(
+		http.MethodPost,
+		urlTest,
+		bytes.NewBuffer([]byte(emailRequestJSON)),
+	)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	tokenPasswordReq, err := http.NewRequest(
+		http.MethodPost,
+		urlTest,
+		bytes.NewBuffer([]byte(tokenPasswordRequestJSON)),
+	)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	refreshTokenReq, err := http.NewRequest(
+		http.MethodPost,
+		urlTest,
+		bytes.NewBuffer([]byte(refreshTokenRequestJSON)),
+	)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
 	badReq, err := http.NewRequest(http.MethodPost, urlTest, bytes.NewBuffer([]byte{}))
 	if err != nil {
 		assert.Error(t, err)
@@ -87,6 +146,7 @@ func TestDecodeRequestWithBody(t *testing.T) {
 		outUsername string
 		outPassword string
 		outEmail    string
+		outToken    string
 		outErr      string
 		outID       int
 	}{
@@ -107,6 +167,28 @@ func TestDecodeRequestWithBody(t *testing.T) {
 			outPassword: passwordTest,
 			outErr:      "",
 		},
+		{
+			name:     nameNoError + "EmailRequest",
+			inType:   service.EmailRequest{},
+			in:       emailReq,
+			outEmail: emailTest,
+			outErr:   "",
+		},
+		{
+			name:        nameNoError + "TokenPasswordRequest",
+			inType:      service.TokenPasswordRequest{},
+			in:          tokenPasswordReq,
+			outToken:    tokenTest,
+			outPassword: passwordTest,
+			outErr:      "",
+		},
+		{
+			name:     nameNoError + "RefreshTokenRequest",
+			inType:   service.RefreshTokenRequest{},
+			in:       refreshTokenReq,
+			outToken: tokenTest,
+			outErr:   "",
+		},
 		{
 			name:   "BadRequest",
 			inType: service.UsernamePasswordEmailRequest{},
@@ -154,6 +236,43 @@ func TestDecodeRequestWithBody(t *testing.T) {
 					assert.NotNil(t, err)
 				}
 
+			case service.EmailRequest:
+				req, err = service.DecodeRequestWithBody(resultType)(context.TODO(), tt.in)
+				if err != nil {
+					resultErr = err.Error()
+				}
+
+				result, ok := req.(service.EmailRequest)
+				assert.True(t, ok)
+
+				assert.Equal(t, tt.outEmail, result.Email)
+				assert.Contains(t, resultErr, tt.outErr)
+
+			case service.TokenPasswordRequest:
+				req, err = service.DecodeRequestWithBody(resultType)(context.TODO(), tt.in)
+				if err != nil {
+					resultErr = err.Error()
+				}
+
+				result, ok := req.(service.TokenPasswordRequest)
+				assert.True(t, ok)
+
+				assert.Equal(t, tt.outToken, result.Token)
+				assert.Equal(t, tt.outPassword, result.Password)
+				assert.Contains(t, resultErr, tt.outErr)
+
+			case service.RefreshTokenRequest:
+				req, err = service.DecodeRequestWithBody(resultType)(context.TODO(), tt.in)
+				if err != nil {
+					resultErr = err.Error()
+				}
+
+				result, ok := req.(service.RefreshTokenRequest)
+				assert.True(t, ok)
+
+				assert.Equal(t, tt.outToken, result.RefreshToken)
+				assert.Contains(t, resultErr, tt.outErr)
+
 			default:
 				assert.Fail(t, "Error to type inType")
 			}
@@ -230,6 +349,399 @@ func TestDecodeRequestWithHeader(t *testing.T) {
 	}
 }
 
+func TestDecodeRequestWithFields(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		inType    any
+		name      string
+		inURL     string
+		inHeader  string
+		outErr    string
+		outToken  string
+		outQuery  dbapp.UsersQuery
+		outFields []string
+	}{
+		{
+			name:      nameNoError + "UsersQueryRequest",
+			inType:    service.UsersQueryRequest{},
+			inURL:     urlTest + "?fields=id,username&limit=5&cursor=MQ&username=us&email=em&sort=desc",
+			outFields: []string{"id", "username"},
+			outQuery: dbapp.UsersQuery{
+				Cursor:         "MQ",
+				UsernamePrefix: "us",
+				EmailPrefix:    "em",
+				Sort:           dbapp.SortDesc,
+				Limit:          5,
+			},
+		},
+		{
+			name:   nameNoError + "WithoutFields",
+			inType: service.UsersQueryRequest{},
+			inURL:  urlTest,
+		},
+		{
+			name:   "BadRequestLimit",
+			inType: service.UsersQueryRequest{},
+			inURL:  urlTest + "?limit=five",
+			outErr: "invalid query parameter",
+		},
+		{
+			name:      nameNoError + "TokenFieldsRequest",
+			inType:    service.TokenFieldsRequest{},
+			inURL:     urlTest + "?fields=email",
+			inHeader:  tokenTest,
+			outToken:  tokenTest,
+			outFields: []string{"email"},
+		},
+		{
+			name:   "BadRequest",
+			inType: service.TokenFieldsRequest{},
+			inURL:  urlTest,
+			outErr: "failed to get header",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			req, err := http.NewRequest(http.MethodGet, tt.inURL, nil)
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			if tt.inHeader != "" {
+				req.Header.Set("Authorization", tt.inHeader)
+			}
+
+			var r any
+
+			switch resultType := tt.inType.(type) {
+			case service.UsersQueryRequest:
+				r, err = service.DecodeRequestWithFields(resultType)(context.TODO(), req)
+				if tt.outErr != "" {
+					assert.ErrorContains(t, err, tt.outErr)
+
+					return
+				}
+
+				result, ok := r.(service.UsersQueryRequest)
+				assert.True(t, ok)
+				assert.Equal(t, tt.outFields, result.Fields)
+				assert.Equal(t, tt.outQuery, result.Query)
+
+			case service.TokenFieldsRequest:
+				r, err = service.DecodeRequestWithFields(resultType)(context.TODO(), req)
+				if tt.outErr != "" {
+					assert.ErrorContains(t, err, tt.outErr)
+
+					return
+				}
+
+				result, ok := r.(service.TokenFieldsRequest)
+				assert.True(t, ok)
+				assert.Equal(t, tt.outToken, result.Token)
+				assert.Equal(t, tt.outFields, result.Fields)
+			}
+
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestDecodeRequestWithQuery(t *testing.T) {
+	t.Parallel()
+
+	okReq, err := http.NewRequest(http.MethodGet, urlTest+"?token=token", nil)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	badReq, err := http.NewRequest(http.MethodGet, urlTest, nil)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	for _, tt := range []struct {
+		in       *http.Request
+		name     string
+		outErr   string
+		outToken string
+	}{
+		{
+			name:     nameNoError,
+			in:       okReq,
+			outToken: tokenTest,
+		},
+		{
+			name:   "BadRequest",
+			in:     badReq,
+			outErr: "failed to get query parameter",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			r, err := service.DecodeRequestWithQuery(service.TokenRequest{})(context.TODO(), tt.in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			result, ok := r.(service.TokenRequest)
+			if tt.name == nameNoError {
+				if !ok {
+					assert.Fail(t, "Error to type inType")
+				}
+
+				assert.Equal(t, tt.outToken, result.Token)
+				assert.Nil(t, err)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestDecodeRequestWithHeaderAndBody(t *testing.T) {
+	t.Parallel()
+
+	okReq, err := http.NewRequest(
+		http.MethodPut,
+		urlTest,
+		bytes.NewBuffer([]byte(usernameEmailRequestJSON)),
+	)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	okReq.Header.Set("Authorization", "token")
+
+	noHeaderReq, err := http.NewRequest(
+		http.MethodPut,
+		urlTest,
+		bytes.NewBuffer([]byte(usernameEmailRequestJSON)),
+	)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	badReq, err := http.NewRequest(http.MethodPut, urlTest, bytes.NewBuffer([]byte{}))
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	badReq.Header.Set("Authorization", "token")
+
+	for _, tt := range []struct {
+		in          *http.Request
+		name        string
+		outErr      string
+		outToken    string
+		outUsername string
+		outEmail    string
+	}{
+		{
+			name:        nameNoError,
+			in:          okReq,
+			outToken:    tokenTest,
+			outUsername: usernameTest,
+			outEmail:    emailTest,
+			outErr:      "",
+		},
+		{
+			name:   "ErrorNoHeader",
+			in:     noHeaderReq,
+			outErr: "failed to get header",
+		},
+		{
+			name:   "BadRequest",
+			in:     badReq,
+			outErr: "EOF",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			r, err := service.DecodeRequestWithHeaderAndBody(
+				service.TokenUsernameEmailRequest{},
+			)(context.TODO(), tt.in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			if tt.name == nameNoError {
+				result, ok := r.(service.TokenUsernameEmailRequest)
+				assert.True(t, ok)
+
+				assert.Equal(t, tt.outToken, result.Token)
+				assert.Equal(t, tt.outUsername, result.Username)
+				assert.Equal(t, tt.outEmail, result.Email)
+				assert.Nil(t, err)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestDecodeRequestWithHeaderAndBodyPassword(t *testing.T) {
+	t.Parallel()
+
+	req, err := http.NewRequest(
+		http.MethodPost,
+		urlTest,
+		bytes.NewBuffer([]byte(oldNewPasswordRequestJSON)),
+	)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	req.Header.Set("Authorization", "token")
+
+	r, err := service.DecodeRequestWithHeaderAndBody(
+		service.TokenOldNewPasswordRequest{},
+	)(context.TODO(), req)
+	assert.Nil(t, err)
+
+	result, ok := r.(service.TokenOldNewPasswordRequest)
+	assert.True(t, ok)
+
+	assert.Equal(t, tokenTest, result.Token)
+	assert.Equal(t, passwordTest, result.OldPassword)
+	assert.Equal(t, "newpassword", result.NewPassword)
+}
+
+func TestEncodeError(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+
+	service.EncodeError(context.TODO(), service.ErrUserSuspended, w)
+
+	var problem service.Problem
+
+	err := json.NewDecoder(w.Body).Decode(&problem)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, service.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusForbidden),
+		Detail: service.ErrUserSuspended.Error(),
+		Status: http.StatusForbidden,
+	}, problem)
+}
+
+func TestEncodeErrorInternal(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+
+	service.EncodeError(context.TODO(), errors.New("dial tcp 10.0.0.2:8080: connection refused"), w)
+
+	var problem service.Problem
+
+	err := json.NewDecoder(w.Body).Decode(&problem)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, service.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+	}, problem)
+}
+
+func TestEncodeErrorRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+
+	service.EncodeError(context.TODO(), &service.Error{
+		Kind:       service.ErrTooManyRequests,
+		Err:        errors.New("too many sign in attempts"),
+		RetryAfter: 90 * time.Second,
+	}, w)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "90", w.Header().Get("Retry-After"))
+}
+
+func TestClientIPToContext(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name         string
+		inForwarded  string
+		outIP        string
+		inTrustProxy bool
+	}{
+		{
+			name:  "RemoteAddr",
+			outIP: "192.0.2.1",
+		},
+		{
+			name:        "UntrustedProxy",
+			inForwarded: "203.0.113.7",
+			outIP:       "192.0.2.1",
+		},
+		{
+			name:         "TrustedProxy",
+			inForwarded:  "198.51.100.2, 203.0.113.7",
+			inTrustProxy: true,
+			outIP:        "203.0.113.7",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var ip string
+
+			mock := service.NewMockClient(func(r *http.Request) (*http.Response, error) {
+				if strings.HasSuffix(r.URL.Path, "/signin/allow") {
+					var body struct {
+						IP string `json:"ip"`
+					}
+
+					_ = json.NewDecoder(r.Body).Decode(&body)
+					ip = body.IP
+				}
+
+				return &http.Response{
+					StatusCode: http.StatusTooManyRequests,
+					Body:       io.NopCloser(strings.NewReader(`{}`)),
+				}, nil
+			})
+
+			svc := service.NewService(mock, service.NewMockMailSender(nil), &service.InfoServices{})
+
+			req := httptest.NewRequest(http.MethodPost, "/signin", nil)
+			if tt.inForwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.inForwarded)
+			}
+
+			ctx := service.ClientIPToContext(tt.inTrustProxy)(context.TODO(), req)
+
+			_, _, err := svc.SignIn(ctx, usernameTest, passwordTest)
+
+			assert.ErrorIs(t, err, service.ErrTooManyRequests)
+			assert.Equal(t, tt.outIP, ip)
+		})
+	}
+}
+
 func TestEncodeResponse(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
+)
+
+var ErrUnknownField = &Error{Kind: ErrValidation, Err: errors.New("unknown field")}
+
+// UserView is the public representation of a user. It is built field by field
+// so internal data can't leak into the response by adding it to dbapp.User.
+type UserView map[string]any
+
+// NewUserView projects the user onto the requested fields, all the public
+// fields are returned when none is requested.
+func NewUserView(user dbapp.User, fields []string) (view UserView, err error) {
+	public := UserView{
+		"id":            user.ID,
+		"username":      user.Username,
+		"email":         user.Email,
+		"emailVerified": user.EmailVerified,
+		"role":          user.Role,
+		"suspended":     user.Suspended,
+	}
+
+	if len(fields) == 0 {
+		return public, nil
+	}
+
+	view = make(UserView, len(fields))
+
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+
+		value, ok := public[field]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownField, field)
+		}
+
+		view[field] = value
+	}
+
+	return view, nil
+}
+
+// NewUserViews projects every user onto the requested fields.
+func NewUserViews(users []dbapp.User, fields []string) (views []UserView, err error) {
+	views = make([]UserView, 0, len(users))
+
+	for _, user := range users {
+		view, err := NewUserView(user, fields)
+		if err != nil {
+			return nil, err
+		}
+
+		views = append(views, view)
+	}
+
+	return views, nil
+}
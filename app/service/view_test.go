@@ -0,0 +1,85 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/cfabrica46/gokit-crud/app/service"
+	dbapp "github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUserView(t *testing.T) {
+	t.Parallel()
+
+	user := dbapp.User{
+		ID:            idTest,
+		Username:      usernameTest,
+		Email:         emailTest,
+		Role:          dbapp.RoleAdmin,
+		EmailVerified: true,
+	}
+
+	for _, tt := range []struct {
+		name     string
+		outErr   string
+		outView  service.UserView
+		inFields []string
+	}{
+		{
+			name: nameNoError,
+			outView: service.UserView{
+				"id":            idTest,
+				"username":      usernameTest,
+				"email":         emailTest,
+				"emailVerified": true,
+				"role":          dbapp.RoleAdmin,
+				"suspended":     false,
+			},
+		},
+		{
+			name:     nameNoError + "Fields",
+			inFields: []string{"id", " role"},
+			outView: service.UserView{
+				"id":   idTest,
+				"role": dbapp.RoleAdmin,
+			},
+		},
+		{
+			name:     "ErrorUnknownField",
+			inFields: []string{"id", "password"},
+			outErr:   service.ErrUnknownField.Error(),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			result, err := service.NewUserView(user, tt.inFields)
+
+			if tt.outErr == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.outErr)
+			}
+
+			assert.Equal(t, tt.outView, result)
+		})
+	}
+}
+
+func TestNewUserViews(t *testing.T) {
+	t.Parallel()
+
+	users := []dbapp.User{
+		{ID: 1, Username: "one"},
+		{ID: 2, Username: "two"},
+	}
+
+	result, err := service.NewUserViews(users, []string{"username"})
+	assert.Nil(t, err)
+	assert.Equal(t, []service.UserView{{"username": "one"}, {"username": "two"}}, result)
+
+	result, err = service.NewUserViews(users, []string{"password"})
+	assert.ErrorIs(t, err, service.ErrUnknownField)
+	assert.Nil(t, result)
+}
@@ -0,0 +1,59 @@
+// Package config is the configuration of database-app.
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/platform/settings"
+)
+
+// Config is read by Load, see settings.Load for the sources and the tags.
+type Config struct {
+	Port       string `env:"PORT" default:"7070" usage:"port to listen on" validate:"required,port"`
+	DBHost     string `env:"DB_HOST" usage:"host of Postgres" validate:"required"`
+	DBPort     string `env:"DB_PORT" default:"5432" usage:"port of Postgres" validate:"required,port"`
+	DBUsername string `env:"DB_USERNAME" usage:"user of Postgres" validate:"required"`
+	DBPassword string `env:"DB_PASSWORD" usage:"password of the user" secret:"true"`
+	DBName     string `env:"DB_NAME" usage:"name of the database" validate:"required"`
+	DBSSLMode  string `env:"DB_SSLMODE" default:"disable" usage:"sslmode of Postgres" validate:"oneof=disable allow prefer require verify-ca verify-full"`
+	DBDriver   string `env:"DB_DRIVER" default:"postgres" usage:"database/sql driver" validate:"required"`
+	TraceFile  string `env:"TRACE_FILE" usage:"where the spans are exported: stdout or a path, off if empty"`
+	LogLevel   string `env:"LOG_LEVEL" default:"info" usage:"minimum level logged" validate:"oneof=debug info warn error"`
+	LogFormat  string `env:"LOG_FORMAT" default:"logfmt" usage:"format of the logs" validate:"oneof=logfmt json"`
+
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" default:"15s" usage:"wait for the requests in flight on shutdown" validate:"positive"`
+	DrainDelay      time.Duration `env:"DRAIN_DELAY" default:"5s" usage:"wait after failing the readiness probe before shutting down"`
+}
+
+// Load reads the configuration from the flags in args, the environment
+// through lookupEnv, the .env file and the YAML file, it fails when a value
+// is missing or malformed.
+func Load(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := &Config{}
+
+	if err := settings.Load(cfg, "database-app", args, lookupEnv); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// KeyValues is the effective configuration with the secrets redacted, to be
+// logged.
+func (c *Config) KeyValues() []any {
+	return settings.KeyValues(c)
+}
+
+// DataSourceName is the connection string of Postgres.
+func (c *Config) DataSourceName() string {
+	return fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		c.DBHost,
+		c.DBPort,
+		c.DBUsername,
+		c.DBPassword,
+		c.DBName,
+		c.DBSSLMode,
+	)
+}
@@ -0,0 +1,150 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/database-app/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func lookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	required := map[string]string{"DB_HOST": "postgres", "DB_USERNAME": "user", "DB_NAME": "go_crud"}
+
+	for _, tt := range []struct {
+		name     string
+		inArgs   []string
+		inEnv    map[string]string
+		outCfg   *config.Config
+		outErr   string
+		outCheck func(t *testing.T, cfg *config.Config)
+	}{
+		{
+			name:  "Defaults",
+			inEnv: required,
+			outCfg: &config.Config{
+				Port:            "7070",
+				DBHost:          "postgres",
+				DBPort:          "5432",
+				DBUsername:      "user",
+				DBName:          "go_crud",
+				DBSSLMode:       "disable",
+				DBDriver:        "postgres",
+				LogLevel:        "info",
+				LogFormat:       "logfmt",
+				ShutdownTimeout: 15 * time.Second,
+				DrainDelay:      5 * time.Second,
+			},
+		},
+		{
+			name:   "FlagOverEnv",
+			inArgs: []string{"-port", "8000", "-shutdown-timeout", "1m"},
+			inEnv:  map[string]string{"DB_HOST": "postgres", "DB_USERNAME": "user", "DB_NAME": "go_crud", "PORT": "9000"},
+			outCheck: func(t *testing.T, cfg *config.Config) {
+				t.Helper()
+				assert.Equal(t, "8000", cfg.Port)
+				assert.Equal(t, time.Minute, cfg.ShutdownTimeout)
+			},
+		},
+		{
+			name:   "ErrorRequired",
+			inEnv:  map[string]string{"DB_HOST": "postgres"},
+			outErr: "invalid configuration: DB_USERNAME: required; DB_NAME: required",
+		},
+		{
+			name: "ErrorFormats",
+			inEnv: map[string]string{
+				"DB_HOST":          "postgres",
+				"DB_USERNAME":      "user",
+				"DB_NAME":          "go_crud",
+				"PORT":             "70000",
+				"DB_SSLMODE":       "maybe",
+				"SHUTDOWN_TIMEOUT": "soon",
+			},
+			outErr: `invalid configuration: PORT: invalid port "70000"; ` +
+				`DB_SSLMODE: must be one of disable, allow, prefer, require, verify-ca, verify-full, not "maybe"; ` +
+				`SHUTDOWN_TIMEOUT: invalid duration "soon"`,
+		},
+		{
+			name:   "ErrorNotPositive",
+			inArgs: []string{"-shutdown-timeout", "-1s"},
+			inEnv:  required,
+			outErr: `invalid configuration: SHUTDOWN_TIMEOUT: must be positive, not "-1s"`,
+		},
+		{
+			name:   "ErrorFlag",
+			inArgs: []string{"-unknown"},
+			inEnv:  required,
+			outErr: "failed to parse flags: flag provided but not defined: -unknown",
+		},
+		{
+			name:   "ErrorEnvFile",
+			inArgs: []string{"-env-file", "missing.env"},
+			inEnv:  required,
+			outErr: "failed to read missing.env",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := config.Load(tt.inArgs, lookup(tt.inEnv))
+
+			if tt.outErr != "" {
+				assert.ErrorContains(t, err, tt.outErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			if tt.outCfg != nil {
+				assert.Equal(t, tt.outCfg, cfg)
+			}
+
+			if tt.outCheck != nil {
+				tt.outCheck(t, cfg)
+			}
+		})
+	}
+}
+
+func TestLoadSecretFile(t *testing.T) {
+	t.Parallel()
+
+	password := writeFile(t, "password", "s3cret\n")
+
+	cfg, err := config.Load(nil, lookup(map[string]string{
+		"DB_HOST":          "postgres",
+		"DB_USERNAME":      "user",
+		"DB_NAME":          "go_crud",
+		"DB_PASSWORD_FILE": password,
+	}))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "s3cret", cfg.DBPassword)
+	assert.Contains(t, cfg.DataSourceName(), "password=s3cret ")
+	assert.Subset(t, cfg.KeyValues(), []any{"DB_PASSWORD", "[redacted]", "DB_HOST", "postgres"})
+	assert.NotContains(t, cfg.KeyValues(), "s3cret")
+}
@@ -1,42 +1,62 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
-	"log"
+	"errors"
+	"flag"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/cfabrica46/gokit-crud/database-app/config"
 	"github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/cfabrica46/gokit-crud/platform/health"
+	"github.com/cfabrica46/gokit-crud/platform/logging"
+	"github.com/cfabrica46/gokit-crud/platform/metrics"
+	"github.com/cfabrica46/gokit-crud/platform/tracing"
+	"github.com/go-kit/kit/endpoint"
 	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/gorilla/mux"
-	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	_ "github.com/lib/pq"
 )
 
 func main() {
-	log.SetFlags(log.Lshortfile)
+	cfg, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+
+	logger := logging.NewLogger(os.Stderr, cfg.LogFormat, cfg.LogLevel)
+	logger = log.With(logger, "service", "database-app")
 
-	if err := godotenv.Load(".env"); err != nil {
-		log.Println(err)
+	if err != nil {
+		_ = level.Error(logger).Log("err", err)
+		os.Exit(1)
 	}
 
-	dbInfo := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_USERNAME"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_SSLMODE"),
-	)
+	_ = level.Info(logger).Log(append([]any{"msg", "configuration"}, cfg.KeyValues()...)...)
 
-	fmt.Println(dbInfo)
+	stopTracing := tracing.Setup(logger, "database-app", cfg.TraceFile)
+	defer stopTracing(cfg.ShutdownTimeout)
 
-	db, err := sql.Open(os.Getenv("DB_DRIVER"), dbInfo)
+	_ = level.Info(logger).Log(
+		"msg", "connecting to the database",
+		"host", cfg.DBHost,
+		"port", cfg.DBPort,
+		"name", cfg.DBName,
+	)
+
+	db, err := sql.Open(cfg.DBDriver, cfg.DataSourceName())
 	if err != nil {
-		log.Println(err)
+		_ = level.Error(logger).Log("err", err)
 
 		return
 	}
@@ -44,62 +64,157 @@ func main() {
 
 	err = db.Ping()
 	if err != nil {
-		log.Println(err)
+		_ = level.Error(logger).Log("err", err)
+
+		return
+	}
+
+	runServer(logger, cfg, db)
+}
+
+// runServer serves until SIGINT or SIGTERM, then it stops being ready, waits
+// DRAIN_DELAY for the traffic to move away and up to SHUTDOWN_TIMEOUT for the
+// requests in flight.
+func runServer(logger log.Logger, cfg *config.Config, db *sql.DB) {
+	service.RegisterDBStats(prometheus.DefaultRegisterer, db)
+
+	readiness := health.NewReadiness(logger, time.Second, map[string]health.Check{
+		"postgres": db.PingContext,
+	})
+
+	router := newRouter(logger, service.GetService(db), readiness, prometheus.DefaultRegisterer)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: router, ReadHeaderTimeout: 10 * time.Second}
+
+	_ = level.Info(logger).Log("msg", "listening", "addr", srv.Addr)
+
+	if err := health.Serve(ctx, srv, readiness, cfg.DrainDelay, cfg.ShutdownTimeout); err != nil {
+		_ = level.Error(logger).Log("err", err)
 
 		return
 	}
 
-	runServer(os.Getenv("PORT"), db)
+	_ = level.Info(logger).Log("msg", "stopped")
 }
 
-func runServer(port string, db *sql.DB) {
-	svc := service.GetService(db)
+// newRouter routes the endpoints of svc.
+func newRouter(
+	logger log.Logger,
+	svc *service.Service,
+	readiness *health.Readiness,
+	reg prometheus.Registerer,
+) *mux.Router {
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(service.EncodeError),
+	}
+	options = append(options, tracing.ServerTracing()...)
+	options = append(options, logging.ServerOptions(logger, logging.UserIDFromHeader)...)
+
+	endpointMetrics := metrics.NewEndpointMetrics(reg, service.MetricsNamespace)
+	instrument := func(method string) endpoint.Middleware {
+		return endpoint.Chain(
+			service.LoggingMiddleware(logger, method),
+			metrics.InstrumentingMiddleware(endpointMetrics, method),
+		)
+	}
 
 	getAllUsersHandler := httptransport.NewServer(
-		service.MakeGetAllUsersEndpoint(svc),
-		service.DecodeRequestWithoutBody(),
+		instrument("GetAllUsers")(service.MakeGetAllUsersEndpoint(svc)),
+		service.DecodeUsersQueryRequest(),
 		service.EncodeResponse,
+		options...,
 	)
 
 	getUserByIDHandler := httptransport.NewServer(
-		service.MakeGetUserByIDEndpoint(svc),
+		instrument("GetUserByID")(service.MakeGetUserByIDEndpoint(svc)),
 		service.DecodeRequest(service.IDRequest{}),
 		service.EncodeResponse,
+		options...,
 	)
 
 	getUserByUsernameAndPasswordHandler := httptransport.NewServer(
-		service.MakeGetUserByUsernameAndPasswordEndpoint(svc),
+		instrument("GetUserByUsernameAndPassword")(service.MakeGetUserByUsernameAndPasswordEndpoint(svc)),
 		service.DecodeRequest(service.UsernamePasswordRequest{}),
 		service.EncodeResponse,
+		options...,
 	)
 
 	getIDByUsernameHandler := httptransport.NewServer(
-		service.MakeGetIDByUsernameEndpoint(svc),
+		instrument("GetIDByUsername")(service.MakeGetIDByUsernameEndpoint(svc)),
 		service.DecodeRequest(service.UsernameRequest{}),
 		service.EncodeResponse,
+		options...,
+	)
+
+	getIDByEmailHandler := httptransport.NewServer(
+		instrument("GetIDByEmail")(service.MakeGetIDByEmailEndpoint(svc)),
+		service.DecodeRequest(service.EmailRequest{}),
+		service.EncodeResponse,
+		options...,
 	)
 
 	insertUserHandler := httptransport.NewServer(
-		service.MakeInsertUserEndpoint(svc),
+		instrument("InsertUser")(service.MakeInsertUserEndpoint(svc)),
 		service.DecodeRequest(service.UsernamePasswordEmailRequest{}),
 		service.EncodeResponse,
+		options...,
 	)
 
 	deleteUserHandler := httptransport.NewServer(
-		service.MakeDeleteUserEndpoint(svc),
+		instrument("DeleteUser")(service.MakeDeleteUserEndpoint(svc)),
 		service.DecodeRequest(service.IDRequest{}),
 		service.EncodeResponse,
+		options...,
+	)
+
+	updateUserHandler := httptransport.NewServer(
+		instrument("UpdateUser")(service.MakeUpdateUserEndpoint(svc)),
+		service.DecodeRequest(service.IDUsernameEmailRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	updatePasswordHandler := httptransport.NewServer(
+		instrument("UpdatePassword")(service.MakeUpdatePasswordEndpoint(svc)),
+		service.DecodeRequest(service.IDPasswordRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	verifyEmailHandler := httptransport.NewServer(
+		instrument("VerifyEmail")(service.MakeVerifyEmailEndpoint(svc)),
+		service.DecodeRequest(service.IDEmailRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	suspendUserHandler := httptransport.NewServer(
+		instrument("SuspendUser")(service.MakeSuspendUserEndpoint(svc)),
+		service.DecodeRequest(service.IDSuspendedRequest{}),
+		service.EncodeResponse,
+		options...,
 	)
 
 	router := mux.NewRouter()
+	router.Methods(http.MethodGet).Path("/healthz").Handler(health.LivenessHandler())
+	router.Methods(http.MethodGet).Path("/readyz").Handler(readiness)
+	router.Methods(http.MethodGet).Path("/metrics").Handler(promhttp.Handler())
+	router.Methods(http.MethodGet).Path("/openapi.json").Handler(service.OpenAPIHandler())
 	router.Methods(http.MethodGet).Path("/users").Handler(getAllUsersHandler)
 	router.Methods(http.MethodGet).Path("/user/id").Handler(getUserByIDHandler)
 	router.Methods(http.MethodGet).Path("/user/username_password").
 		Handler(getUserByUsernameAndPasswordHandler)
 	router.Methods(http.MethodGet).Path("/id/username").Handler(getIDByUsernameHandler)
+	router.Methods(http.MethodGet).Path("/id/email").Handler(getIDByEmailHandler)
 	router.Methods(http.MethodPost).Path("/user").Handler(insertUserHandler)
 	router.Methods(http.MethodDelete).Path("/user").Handler(deleteUserHandler)
+	router.Methods(http.MethodPatch).Path("/user").Handler(updateUserHandler)
+	router.Methods(http.MethodPatch).Path("/user/password").Handler(updatePasswordHandler)
+	router.Methods(http.MethodPatch).Path("/user/email_verified").Handler(verifyEmailHandler)
+	router.Methods(http.MethodPatch).Path("/user/suspended").Handler(suspendUserHandler)
 
-	log.Println("ListenAndServe on localhost:" + os.Getenv("PORT"))
-	log.Println(http.ListenAndServe(":"+port, router))
+	return router
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/cfabrica46/gokit-crud/platform/health"
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestRoutesMatchOpenAPI fails when a route is added, moved or removed
+// without documenting it in service.Operations.
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	router := newRouter(
+		log.NewNopLogger(),
+		service.GetService(nil),
+		health.NewReadiness(log.NewNopLogger(), time.Second, nil),
+		prometheus.NewRegistry(),
+	)
+
+	var routes []string
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+
+		switch path {
+		case "/healthz", "/readyz", "/metrics", "/openapi.json":
+			return nil
+		}
+
+		for _, method := range methods {
+			routes = append(routes, method+" "+path)
+		}
+
+		return nil
+	})
+	assert.NoError(t, err)
+
+	documented := make([]string, 0, len(service.Operations))
+	for _, op := range service.Operations {
+		documented = append(documented, op.Method+" "+op.Path)
+	}
+
+	sort.Strings(routes)
+	sort.Strings(documented)
+
+	assert.Equal(t, documented, routes)
+}
@@ -14,42 +14,41 @@ var ErrRequest = errors.New("error to request")
 
 // MakeGetAllUsersEndpoint ...
 func MakeGetAllUsersEndpoint(svc serviceInterface) endpoint.Endpoint {
-	return func(_ context.Context, _ any) (any, error) {
-		var errMessage string
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(UsersQuery)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type UsersQuery", ErrRequest)
+		}
 
-		users, err := svc.GetAllUsers()
+		users, total, next, err := svc.GetAllUsers(ctx, req)
 		if err != nil {
-			errMessage = err.Error()
+			return nil, err
 		}
 
-		return UsersErrorResponse{Users: users, Err: errMessage}, nil
+		return UsersErrorResponse{Users: users, Total: total, Next: next}, nil
 	}
 }
 
 // MakeGetUserByIDEndpoint ...
 func MakeGetUserByIDEndpoint(svc serviceInterface) endpoint.Endpoint {
-	return func(_ context.Context, request any) (any, error) {
-		var errMessage string
-
+	return func(ctx context.Context, request any) (any, error) {
 		req, ok := request.(IDRequest)
 		if !ok {
 			return nil, fmt.Errorf("%w: isn't of type GenerateTokenRequest", ErrRequest)
 		}
 
-		user, err := svc.GetUserByID(req.ID)
+		user, err := svc.GetUserByID(ctx, req.ID)
 		if err != nil {
-			errMessage = err.Error()
+			return nil, err
 		}
 
-		return UserErrorResponse{User: user, Err: errMessage}, nil
+		return UserErrorResponse{User: user}, nil
 	}
 }
 
 // MakeGetUserByUsernameAndPasswordEndpoint ...
 func MakeGetUserByUsernameAndPasswordEndpoint(svc serviceInterface) endpoint.Endpoint {
-	return func(_ context.Context, request any) (any, error) {
-		var errMessage string
-
+	return func(ctx context.Context, request any) (any, error) {
 		req, ok := request.(UsernamePasswordRequest)
 		if !ok {
 			return nil, fmt.Errorf("%w: isn't of type GenerateTokenRequest", ErrRequest)
@@ -57,39 +56,52 @@ func MakeGetUserByUsernameAndPasswordEndpoint(svc serviceInterface) endpoint.End
 
 		passwordHashed := NewHashHex(req.Password)
 
-		user, err := svc.GetUserByUsernameAndPassword(req.Username, passwordHashed)
+		user, err := svc.GetUserByUsernameAndPassword(ctx, req.Username, passwordHashed)
 		if err != nil {
-			errMessage = err.Error()
+			return nil, err
 		}
 
-		return UserErrorResponse{User: user, Err: errMessage}, nil
+		return UserErrorResponse{User: user}, nil
 	}
 }
 
 // MakeGetIDByUsernameEndpoint ...
 func MakeGetIDByUsernameEndpoint(svc serviceInterface) endpoint.Endpoint {
-	return func(_ context.Context, request any) (any, error) {
-		var errMessage string
-
+	return func(ctx context.Context, request any) (any, error) {
 		req, ok := request.(UsernameRequest)
 		if !ok {
 			return nil, fmt.Errorf("%w: isn't of type GenerateTokenRequest", ErrRequest)
 		}
 
-		id, err := svc.GetIDByUsername(req.Username)
+		id, err := svc.GetIDByUsername(ctx, req.Username)
+		if err != nil {
+			return nil, err
+		}
+
+		return IDErrorResponse{ID: id}, nil
+	}
+}
+
+// MakeGetIDByEmailEndpoint ...
+func MakeGetIDByEmailEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(EmailRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type EmailRequest", ErrRequest)
+		}
+
+		id, err := svc.GetIDByEmail(ctx, req.Email)
 		if err != nil {
-			errMessage = err.Error()
+			return nil, err
 		}
 
-		return IDErrorResponse{ID: id, Err: errMessage}, nil
+		return IDErrorResponse{ID: id}, nil
 	}
 }
 
 // MakeInsertUserEndpoint ...
 func MakeInsertUserEndpoint(svc serviceInterface) endpoint.Endpoint {
-	return func(_ context.Context, request any) (any, error) {
-		var errMessage string
-
+	return func(ctx context.Context, request any) (any, error) {
 		req, ok := request.(UsernamePasswordEmailRequest)
 		if !ok {
 			return nil, fmt.Errorf("%w: isn't of type GenerateTokenRequest", ErrRequest)
@@ -97,31 +109,99 @@ func MakeInsertUserEndpoint(svc serviceInterface) endpoint.Endpoint {
 
 		passwordHashed := NewHashHex(req.Password)
 
-		err := svc.InsertUser(req.Username, passwordHashed, req.Email)
+		id, err := svc.InsertUser(ctx, req.Username, passwordHashed, req.Email)
 		if err != nil {
-			errMessage = err.Error()
+			return nil, err
 		}
 
-		return ErrorResponse{errMessage}, nil
+		return IDErrorResponse{ID: id}, nil
 	}
 }
 
 // MakeDeleteUserEndpoint ...
 func MakeDeleteUserEndpoint(svc serviceInterface) endpoint.Endpoint {
-	return func(_ context.Context, request any) (any, error) {
-		var errMessage string
-
+	return func(ctx context.Context, request any) (any, error) {
 		req, ok := request.(IDRequest)
 		if !ok {
 			return nil, fmt.Errorf("%w: isn't of type GenerateTokenRequest", ErrRequest)
 		}
 
-		rowsAffected, err := svc.DeleteUser(req.ID)
+		rowsAffected, err := svc.DeleteUser(ctx, req.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return RowsErrorResponse{RowsAffected: rowsAffected}, nil
+	}
+}
+
+// MakeUpdateUserEndpoint ...
+func MakeUpdateUserEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(IDUsernameEmailRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type IDUsernameEmailRequest", ErrRequest)
+		}
+
+		rowsAffected, err := svc.UpdateUser(ctx, req.ID, req.Username, req.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		return RowsErrorResponse{RowsAffected: rowsAffected}, nil
+	}
+}
+
+// MakeUpdatePasswordEndpoint ...
+func MakeUpdatePasswordEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(IDPasswordRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type IDPasswordRequest", ErrRequest)
+		}
+
+		passwordHashed := NewHashHex(req.Password)
+
+		rowsAffected, err := svc.UpdatePassword(ctx, req.ID, passwordHashed)
+		if err != nil {
+			return nil, err
+		}
+
+		return RowsErrorResponse{RowsAffected: rowsAffected}, nil
+	}
+}
+
+// MakeVerifyEmailEndpoint ...
+func MakeVerifyEmailEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(IDEmailRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type IDEmailRequest", ErrRequest)
+		}
+
+		rowsAffected, err := svc.VerifyEmail(ctx, req.ID, req.Email)
+		if err != nil {
+			return nil, err
+		}
+
+		return RowsErrorResponse{RowsAffected: rowsAffected}, nil
+	}
+}
+
+// MakeSuspendUserEndpoint ...
+func MakeSuspendUserEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(IDSuspendedRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type IDSuspendedRequest", ErrRequest)
+		}
+
+		rowsAffected, err := svc.SuspendUser(ctx, req.ID, req.Suspended)
 		if err != nil {
-			errMessage = err.Error()
+			return nil, err
 		}
 
-		return RowsErrorResponse{RowsAffected: rowsAffected, Err: errMessage}, nil
+		return RowsErrorResponse{RowsAffected: rowsAffected}, nil
 	}
 }
 
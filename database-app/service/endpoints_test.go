@@ -28,7 +28,7 @@ func TestMakeGetAllUsersEndpoint(t *testing.T) {
 			outID:       idTest,
 			outUsername: usernameTest,
 			outEmail:    emailTest,
-			inRequest:   service.EmptyRequest{},
+			inRequest:   service.UsersQuery{},
 			outErr:      "",
 		},
 		{
@@ -36,9 +36,14 @@ func TestMakeGetAllUsersEndpoint(t *testing.T) {
 			outID:       idTest,
 			outUsername: usernameTest,
 			outEmail:    emailTest,
-			inRequest:   service.EmptyRequest{},
+			inRequest:   service.UsersQuery{},
 			outErr:      errDatabaseClosed,
 		},
+		{
+			name:      nameErrorRequest,
+			inRequest: service.EmptyRequest{},
+			outErr:    "isn't of type",
+		},
 	} {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
@@ -60,28 +65,38 @@ func TestMakeGetAllUsersEndpoint(t *testing.T) {
 					"id",
 					"username",
 					"email",
+					"email_verified",
+					"role",
+					"suspended",
 				}).AddRow(
 				tt.outID,
 				tt.outUsername,
 				tt.outEmail,
+				true,
+				service.RoleUser,
+				false,
 			)
 
-			mock.ExpectQuery("^SELECT id, username, email FROM users").WillReturnRows(rows)
+			mock.ExpectQuery("^SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectQuery("^SELECT id, username, email, email_verified, role, suspended FROM users").WillReturnRows(rows)
+
+			var resultErr string
 
 			r, err := service.MakeGetAllUsersEndpoint(svc)(context.TODO(), tt.inRequest)
 			if err != nil {
-				assert.Error(t, err)
+				resultErr = err.Error()
 			}
 
 			result, ok := r.(service.UsersErrorResponse)
-			if !ok {
+			if !ok && err == nil {
 				assert.Fail(t, "response is not of the type indicated")
 			}
 
 			if tt.name == nameNoError {
-				assert.Empty(t, result.Err)
+				assert.Empty(t, resultErr)
+				assert.Equal(t, 1, result.Total)
 			} else {
-				assert.Contains(t, result.Err, tt.outErr)
+				assert.Contains(t, resultErr, tt.outErr)
 			}
 		})
 	}
@@ -145,16 +160,20 @@ func TestMakeGetUserByIDEndpoint(t *testing.T) {
 				[]string{
 					"id",
 					"username",
-					"password",
 					"email",
+					"email_verified",
+					"role",
+					"suspended",
 				}).AddRow(
 				tt.inID,
 				tt.inUsername,
-				tt.inPassword,
 				tt.inEmail,
+				true,
+				service.RoleUser,
+				false,
 			)
 
-			mock.ExpectQuery("^SELECT id, username, password, email FROM users").
+			mock.ExpectQuery("^SELECT id, username, email, email_verified, role, suspended FROM users").
 				WithArgs(tt.inID).WillReturnRows(rows)
 
 			r, err := service.MakeGetUserByIDEndpoint(svc)(context.TODO(), tt.inRequest)
@@ -162,19 +181,13 @@ func TestMakeGetUserByIDEndpoint(t *testing.T) {
 				resultErr = err.Error()
 			}
 
-			result, ok := r.(service.UserErrorResponse)
-			if !ok {
-				if tt.name != nameErrorRequest {
-					assert.Fail(t, "response is not of the type indicated")
-				}
-			}
-
-			if result.Err != "" {
-				resultErr = result.Err
+			_, ok := r.(service.UserErrorResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
 			}
 
 			if tt.name == nameNoError {
-				assert.Empty(t, result.Err)
+				assert.Empty(t, resultErr)
 			} else {
 				assert.Contains(t, resultErr, tt.outErr)
 			}
@@ -243,17 +256,21 @@ func TestMakeGetUserByUsernameAndPasswordEndpoint(t *testing.T) {
 				[]string{
 					"id",
 					"username",
-					"password",
 					"email",
+					"email_verified",
+					"role",
+					"suspended",
 				}).AddRow(
 				tt.inID,
 				tt.inUsername,
-				tt.inPassword,
 				tt.inEmail,
+				true,
+				service.RoleUser,
+				false,
 			)
 
-			mock.ExpectQuery("^SELECT id, username, password, email FROM users").
-				WithArgs(tt.inUsername, tt.inPassword).WillReturnRows(rows)
+			mock.ExpectQuery("^SELECT id, username, email, email_verified, role, suspended FROM users").
+				WithArgs(tt.inUsername, service.NewHashHex(tt.inPassword)).WillReturnRows(rows)
 
 			r, err := service.MakeGetUserByUsernameAndPasswordEndpoint(svc)(
 				context.TODO(),
@@ -263,19 +280,13 @@ func TestMakeGetUserByUsernameAndPasswordEndpoint(t *testing.T) {
 				resultErr = err.Error()
 			}
 
-			result, ok := r.(service.UserErrorResponse)
-			if !ok {
-				if tt.name != nameErrorRequest {
-					assert.Fail(t, "response is not of the type indicated")
-				}
-			}
-
-			if result.Err != "" {
-				resultErr = result.Err
+			_, ok := r.(service.UserErrorResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
 			}
 
 			if tt.name == nameNoError {
-				assert.Empty(t, result.Err)
+				assert.Empty(t, resultErr)
 			} else {
 				assert.Contains(t, resultErr, tt.outErr)
 			}
@@ -344,19 +355,89 @@ func TestGetIDByUsernameEndpoint(t *testing.T) {
 				resultErr = err.Error()
 			}
 
-			result, ok := r.(service.IDErrorResponse)
-			if !ok {
-				if tt.name != nameErrorRequest {
-					assert.Fail(t, "response is not of the type indicated")
-				}
+			_, ok := r.(service.IDErrorResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestGetIDByEmailEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		inRequest any
+		name      string
+		inEmail   string
+		outErr    string
+		inID      int
+	}{
+		{
+			name:    nameNoError,
+			inID:    idTest,
+			inEmail: emailTest,
+			inRequest: service.EmailRequest{
+				Email: emailTest,
+			},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			inRequest: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:      nameErrorDBClosed,
+			inID:      idTest,
+			inEmail:   emailTest,
+			inRequest: service.EmailRequest{},
+			outErr:    errDatabaseClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Error(t, err)
 			}
+			defer db.Close()
 
-			if result.Err != "" {
-				resultErr = result.Err
+			if tt.name == nameErrorDBClosed {
+				db.Close()
+			}
+
+			svc := service.GetService(db)
+
+			rows := sqlmock.NewRows([]string{"id"}).AddRow(tt.inID)
+
+			mock.ExpectQuery("^SELECT id FROM users WHERE email").WithArgs(tt.inEmail).WillReturnRows(rows)
+
+			r, err := service.MakeGetIDByEmailEndpoint(svc)(context.TODO(), tt.inRequest)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			result, ok := r.(service.IDErrorResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
 			}
 
 			if tt.name == nameNoError {
-				assert.Empty(t, result.Err)
+				assert.Empty(t, resultErr)
+				assert.Equal(t, tt.inID, result.ID)
 			} else {
 				assert.Contains(t, resultErr, tt.outErr)
 			}
@@ -419,31 +500,26 @@ func TestMakeInsertUserEndpoint(t *testing.T) {
 
 			svc := service.GetService(db)
 
-			mock.ExpectExec("^INSERT INTO users").
+			mock.ExpectQuery("^INSERT INTO users").
 				WithArgs(
 					tt.inUsername,
-					tt.inPassword,
+					service.NewHashHex(tt.inPassword),
 					tt.inEmail,
-				).WillReturnResult(sqlmock.NewResult(0, 1))
+				).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(idTest))
 
 			r, err := service.MakeInsertUserEndpoint(svc)(context.TODO(), tt.inRequest)
 			if err != nil {
 				resultErr = err.Error()
 			}
 
-			result, ok := r.(service.ErrorResponse)
-			if !ok {
-				if tt.name != nameErrorRequest {
-					assert.Fail(t, "response is not of the type indicated")
-				}
-			}
-
-			if result.Err != "" {
-				resultErr = result.Err
+			result, ok := r.(service.IDErrorResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
 			}
 
 			if tt.name == nameNoError {
-				assert.Empty(t, result.Err)
+				assert.Empty(t, resultErr)
+				assert.Equal(t, idTest, result.ID)
 			} else {
 				assert.Contains(t, resultErr, tt.outErr)
 			}
@@ -508,19 +584,313 @@ func TestMakeDeleteUserEndpoint(t *testing.T) {
 				resultErr = err.Error()
 			}
 
+			_, ok := r.(service.RowsErrorResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestMakeUpdateUserEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		inRequest           any
+		name                string
+		inUsername, inEmail string
+		outErr              string
+		inID                int
+	}{
+		{
+			name:       nameNoError,
+			inID:       idTest,
+			inUsername: usernameTest,
+			inEmail:    emailTest,
+			inRequest: service.IDUsernameEmailRequest{
+				ID:       idTest,
+				Username: usernameTest,
+				Email:    emailTest,
+			},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			inRequest: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:      nameErrorDBClosed,
+			inID:      idTest,
+			inRequest: service.IDUsernameEmailRequest{},
+			outErr:    errDatabaseClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Error(t, err)
+			}
+			defer db.Close()
+
+			if tt.name == nameErrorDBClosed {
+				db.Close()
+			}
+
+			svc := service.GetService(db)
+
+			mock.ExpectExec("^UPDATE users").
+				WithArgs(tt.inUsername, tt.inEmail, tt.inID).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			r, err := service.MakeUpdateUserEndpoint(svc)(context.TODO(), tt.inRequest)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
 			result, ok := r.(service.RowsErrorResponse)
-			if !ok {
-				if tt.name != nameErrorRequest {
-					assert.Fail(t, "response is not of the type indicated")
-				}
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
 			}
 
-			if result.Err != "" {
-				resultErr = result.Err
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+				assert.Equal(t, 1, result.RowsAffected)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestMakeUpdatePasswordEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		inRequest  any
+		name       string
+		inPassword string
+		outErr     string
+		inID       int
+	}{
+		{
+			name:       nameNoError,
+			inID:       idTest,
+			inPassword: passwordTest,
+			inRequest: service.IDPasswordRequest{
+				ID:       idTest,
+				Password: passwordTest,
+			},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			inRequest: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:      nameErrorDBClosed,
+			inID:      idTest,
+			inRequest: service.IDPasswordRequest{},
+			outErr:    errDatabaseClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Error(t, err)
+			}
+			defer db.Close()
+
+			if tt.name == nameErrorDBClosed {
+				db.Close()
+			}
+
+			svc := service.GetService(db)
+
+			mock.ExpectExec("^UPDATE users SET password").
+				WithArgs(service.NewHashHex(tt.inPassword), tt.inID).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			r, err := service.MakeUpdatePasswordEndpoint(svc)(context.TODO(), tt.inRequest)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			result, ok := r.(service.RowsErrorResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+				assert.Equal(t, 1, result.RowsAffected)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestMakeVerifyEmailEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		inRequest any
+		name      string
+		outErr    string
+		inID      int
+	}{
+		{
+			name: nameNoError,
+			inID: idTest,
+			inRequest: service.IDEmailRequest{
+				ID:    idTest,
+				Email: emailTest,
+			},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			inRequest: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:      nameErrorDBClosed,
+			inID:      idTest,
+			inRequest: service.IDEmailRequest{},
+			outErr:    errDatabaseClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Error(t, err)
+			}
+			defer db.Close()
+
+			if tt.name == nameErrorDBClosed {
+				db.Close()
+			}
+
+			svc := service.GetService(db)
+
+			mock.ExpectExec("^UPDATE users SET email_verified = TRUE").
+				WithArgs(tt.inID, emailTest).WillReturnResult(sqlmock.NewResult(0, 1))
+
+			r, err := service.MakeVerifyEmailEndpoint(svc)(context.TODO(), tt.inRequest)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			result, ok := r.(service.RowsErrorResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+				assert.Equal(t, 1, result.RowsAffected)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestMakeSuspendUserEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		inRequest any
+		name      string
+		outErr    string
+		inID      int
+	}{
+		{
+			name: nameNoError,
+			inID: idTest,
+			inRequest: service.IDSuspendedRequest{
+				ID:        idTest,
+				Suspended: true,
+			},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			inRequest: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:      nameErrorDBClosed,
+			inID:      idTest,
+			inRequest: service.IDSuspendedRequest{},
+			outErr:    errDatabaseClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Error(t, err)
+			}
+			defer db.Close()
+
+			if tt.name == nameErrorDBClosed {
+				db.Close()
+			}
+
+			svc := service.GetService(db)
+
+			mock.ExpectExec("^UPDATE users SET suspended").
+				WithArgs(true, tt.inID).WillReturnResult(sqlmock.NewResult(0, 1))
+
+			r, err := service.MakeSuspendUserEndpoint(svc)(context.TODO(), tt.inRequest)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			result, ok := r.(service.RowsErrorResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
 			}
 
 			if tt.name == nameNoError {
-				assert.Empty(t, result.Err)
+				assert.Empty(t, resultErr)
+				assert.Equal(t, 1, result.RowsAffected)
 			} else {
 				assert.Contains(t, resultErr, tt.outErr)
 			}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kinds of errors, each kind is reported with its own HTTP status.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+)
+
+// Error gives Err a kind, the kind decides the HTTP status it is reported
+// with while the message and the wrapped chain stay those of Err.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+// Error ...
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap ...
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the kind of the error.
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+// StatusCode returns the HTTP status err is reported with.
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
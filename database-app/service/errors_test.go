@@ -0,0 +1,66 @@
+package service_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusCode(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		in     error
+		name   string
+		out    int
+		outMsg string
+	}{
+		{
+			name:   "Conflict",
+			in:     fmt.Errorf("error to update user: %w", service.ErrUserAlreadyExists),
+			out:    http.StatusConflict,
+			outMsg: "error to update user: username or email already in use",
+		},
+		{
+			name:   "NotFound",
+			in:     fmt.Errorf("error to get user by ID: %w", service.ErrUserNotFound),
+			out:    http.StatusNotFound,
+			outMsg: "error to get user by ID: user not found",
+		},
+		{
+			name:   "Validation",
+			in:     service.ErrInvalidCursor,
+			out:    http.StatusBadRequest,
+			outMsg: "invalid cursor",
+		},
+		{
+			name:   "Internal",
+			in:     errors.New("sql: database is closed"),
+			out:    http.StatusInternalServerError,
+			outMsg: "sql: database is closed",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.out, service.StatusCode(tt.in))
+			assert.EqualError(t, tt.in, tt.outMsg)
+		})
+	}
+}
+
+func TestErrorKeepsChain(t *testing.T) {
+	t.Parallel()
+
+	err := &service.Error{Kind: service.ErrValidation, Err: fmt.Errorf("failed to decode request: %w", io.EOF)}
+
+	assert.ErrorIs(t, err, service.ErrValidation)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.NotErrorIs(t, err, service.ErrConflict)
+}
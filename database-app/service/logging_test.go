@@ -0,0 +1,175 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/cfabrica46/gokit-crud/platform/logging"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/stretchr/testify/assert"
+)
+
+var errEndpoint = errors.New("endpoint error")
+
+// logLines decodes the JSON lines written by a logger.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var lines []map[string]any
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var decoded map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(line), &decoded))
+
+		lines = append(lines, decoded)
+	}
+
+	return lines
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		inErr    error
+		name     string
+		inLevel  string
+		outLevel string
+		outLines int
+	}{
+		{
+			name:     nameNoError,
+			inLevel:  "debug",
+			outLevel: "debug",
+			outLines: 1,
+		},
+		{
+			name:     "NoErrorInfo",
+			inLevel:  "info",
+			outLines: 0,
+		},
+		{
+			name:     "ErrorConflict",
+			inLevel:  "info",
+			inErr:    service.ErrUserAlreadyExists,
+			outLevel: "warn",
+			outLines: 1,
+		},
+		{
+			name:     "ErrorInternal",
+			inLevel:  "error",
+			inErr:    errEndpoint,
+			outLevel: "error",
+			outLines: 1,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			logger := logging.NewLogger(&buf, "json", tt.inLevel)
+
+			e := service.LoggingMiddleware(logger, "InsertUser")(func(context.Context, any) (any, error) {
+				return nil, tt.inErr
+			})
+
+			_, _ = e(context.TODO(), service.UsernamePasswordEmailRequest{Password: "hunter2"})
+
+			lines := logLines(t, &buf)
+			if !assert.Len(t, lines, tt.outLines) || tt.outLines == 0 {
+				return
+			}
+
+			assert.Equal(t, tt.outLevel, lines[0]["level"])
+			assert.Equal(t, "InsertUser", lines[0]["endpoint"])
+			assert.NotContains(t, buf.String(), "hunter2")
+		})
+	}
+}
+
+func TestServerLogging(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		inErr        error
+		name         string
+		inRequestID  string
+		outRequestID string
+		outCode      float64
+	}{
+		{
+			name:         nameNoError,
+			inRequestID:  "request-1",
+			outRequestID: "request-1",
+			outCode:      http.StatusOK,
+		},
+		{
+			name:         "ErrorConflict",
+			inRequestID:  "request-2",
+			inErr:        service.ErrUserAlreadyExists,
+			outRequestID: "request-2",
+			outCode:      http.StatusConflict,
+		},
+		{
+			name:    "GeneratedID",
+			outCode: http.StatusOK,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			handler := httptransport.NewServer(
+				func(context.Context, any) (any, error) { return service.ErrorResponse{}, tt.inErr },
+				func(context.Context, *http.Request) (any, error) { return nil, nil },
+				service.EncodeResponse,
+				append(
+					[]httptransport.ServerOption{httptransport.ServerErrorEncoder(service.EncodeError)},
+					logging.ServerOptions(logging.NewLogger(&buf, "json", "info"), logging.UserIDFromHeader)...,
+				)...,
+			)
+
+			req := httptest.NewRequest(http.MethodPost, "/user", nil)
+			req.Header.Set(logging.UserIDHeader, "1")
+
+			if tt.inRequestID != "" {
+				req.Header.Set(logging.RequestIDHeader, tt.inRequestID)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			requestID := w.Header().Get(logging.RequestIDHeader)
+			if tt.outRequestID != "" {
+				assert.Equal(t, tt.outRequestID, requestID)
+			} else {
+				assert.Len(t, requestID, 32)
+			}
+
+			lines := logLines(t, &buf)
+			if !assert.Len(t, lines, 1) {
+				return
+			}
+
+			assert.Equal(t, "/user", lines[0]["path"])
+			assert.Equal(t, tt.outCode, lines[0]["code"])
+			assert.Equal(t, requestID, lines[0]["request_id"])
+			assert.Equal(t, "1", lines[0]["user_id"])
+		})
+	}
+}
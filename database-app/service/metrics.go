@@ -0,0 +1,16 @@
+package service
+
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// MetricsNamespace prefixes the metrics of the service.
+const MetricsNamespace = "database_app"
+
+// RegisterDBStats registers the sql.DBStats of db in reg as gauges.
+func RegisterDBStats(reg prometheus.Registerer, db *sql.DB) {
+	reg.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
+}
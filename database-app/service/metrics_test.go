@@ -0,0 +1,27 @@
+package service_test
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterDBStats(t *testing.T) {
+	t.Parallel()
+
+	db, _, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	defer db.Close()
+
+	reg := prometheus.NewRegistry()
+	service.RegisterDBStats(reg, db)
+
+	count, err := testutil.GatherAndCount(reg, "go_sql_open_connections", "go_sql_in_use_connections", "go_sql_wait_count_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+}
@@ -1,9 +1,38 @@
 package service
 
-// User ...
+const (
+	// RoleUser is the role every new account starts with.
+	RoleUser string = "user"
+
+	// RoleAdmin can list, delete and suspend other accounts.
+	RoleAdmin string = "admin"
+)
+
+// User ... the password hash is never loaded, the credentials are only
+// compared inside the query.
 type User struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-	Email    string `json:"email"`
-	ID       int    `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	Role          string `json:"role"`
+	ID            int    `json:"id"`
+	EmailVerified bool   `json:"emailVerified"`
+	Suspended     bool   `json:"suspended"`
+}
+
+const (
+	// SortAsc lists users from the lowest id up, it is the default order.
+	SortAsc string = "asc"
+
+	// SortDesc lists users from the highest id down.
+	SortDesc string = "desc"
+)
+
+// UsersQuery narrows and pages the users listing. Cursor is the opaque value
+// returned as next by the previous page.
+type UsersQuery struct {
+	Cursor         string `json:"cursor,omitempty"`
+	UsernamePrefix string `json:"username,omitempty"`
+	EmailPrefix    string `json:"email,omitempty"`
+	Sort           string `json:"sort,omitempty"`
+	Limit          int    `json:"limit,omitempty"`
 }
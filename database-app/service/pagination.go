@@ -0,0 +1,122 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultUsersLimit int = 20
+	maxUsersLimit     int = 100
+)
+
+var (
+	ErrInvalidCursor = &Error{Kind: ErrValidation, Err: errors.New("invalid cursor")}
+	ErrInvalidSort   = &Error{Kind: ErrValidation, Err: errors.New("invalid sort order")}
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// encodeCursor hides the id the next page starts after.
+func encodeCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+// decodeCursor ...
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+
+	id, err := strconv.Atoi(string(raw))
+	if err != nil || id < 0 {
+		return 0, ErrInvalidCursor
+	}
+
+	return id, nil
+}
+
+// usersFilter builds the WHERE clause shared by the page and count queries.
+func usersFilter(query UsersQuery) (conditions []string, args []any) {
+	if query.UsernamePrefix != "" {
+		args = append(args, likeEscaper.Replace(query.UsernamePrefix)+"%")
+		conditions = append(conditions, fmt.Sprintf("username LIKE $%d", len(args)))
+	}
+
+	if query.EmailPrefix != "" {
+		args = append(args, likeEscaper.Replace(query.EmailPrefix)+"%")
+		conditions = append(conditions, fmt.Sprintf("email LIKE $%d", len(args)))
+	}
+
+	return conditions, args
+}
+
+// whereClause ...
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// usersPageQuery returns the count query, the page query and their
+// arguments. The page query asks for one extra row to know if there is a
+// next page.
+func usersPageQuery(query UsersQuery) (
+	countSQL string,
+	countArgs []any,
+	pageSQL string,
+	pageArgs []any,
+	limit int,
+	err error,
+) {
+	order, operator := "ASC", ">"
+
+	switch strings.ToLower(query.Sort) {
+	case "", SortAsc:
+	case SortDesc:
+		order, operator = "DESC", "<"
+	default:
+		return "", nil, "", nil, 0, ErrInvalidSort
+	}
+
+	limit = query.Limit
+	if limit <= 0 {
+		limit = defaultUsersLimit
+	}
+
+	if limit > maxUsersLimit {
+		limit = maxUsersLimit
+	}
+
+	conditions, args := usersFilter(query)
+	countSQL = "SELECT COUNT(*) FROM users" + whereClause(conditions)
+	countArgs = append([]any{}, args...)
+
+	if query.Cursor != "" {
+		var after int
+
+		after, err = decodeCursor(query.Cursor)
+		if err != nil {
+			return "", nil, "", nil, 0, err
+		}
+
+		args = append(args, after)
+		conditions = append(conditions, fmt.Sprintf("id %s $%d", operator, len(args)))
+	}
+
+	args = append(args, limit+1)
+	pageSQL = fmt.Sprintf(
+		"SELECT id, username, email, email_verified, role, suspended FROM users%s ORDER BY id %s LIMIT $%d",
+		whereClause(conditions),
+		order,
+		len(args),
+	)
+
+	return countSQL, countArgs, pageSQL, args, limit, nil
+}
@@ -19,6 +19,11 @@ type UsernameRequest struct {
 	Username string `json:"username"`
 }
 
+// EmailRequest ...
+type EmailRequest struct {
+	Email string `json:"email"`
+}
+
 // UsernamePasswordEmailRequest ...
 type UsernamePasswordEmailRequest struct {
 	Username string `json:"username"`
@@ -26,33 +31,64 @@ type UsernamePasswordEmailRequest struct {
 	Email    string `json:"email"`
 }
 
+// IDUsernameEmailRequest ...
+type IDUsernameEmailRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	ID       int    `json:"id"`
+}
+
+// IDEmailRequest ...
+type IDEmailRequest struct {
+	Email string `json:"email"`
+	ID    int    `json:"id"`
+}
+
+// IDPasswordRequest ...
+type IDPasswordRequest struct {
+	Password string `json:"password"`
+	ID       int    `json:"id"`
+}
+
+// IDSuspendedRequest ...
+type IDSuspendedRequest struct {
+	ID        int  `json:"id"`
+	Suspended bool `json:"suspended"`
+}
+
 // ---
 
 // UsersErrorResponse ...
 type UsersErrorResponse struct {
-	Err   string `json:"err,omitempty"`
+	Next  string `json:"next,omitempty"`
 	Users []User `json:"users"`
+	Total int    `json:"total"`
 }
 
 // UserErrorResponse ...
 type UserErrorResponse struct {
-	Err  string `json:"err,omitempty"`
-	User User   `json:"user"`
+	User User `json:"user"`
 }
 
 // IDErrorResponse ...
 type IDErrorResponse struct {
-	Err string `json:"err,omitempty"`
-	ID  int    `json:"id"`
+	ID int `json:"id"`
 }
 
-// ErrorResponse ...
-type ErrorResponse struct {
-	Err string `json:"err,omitempty"`
-}
+// ErrorResponse is the body of the calls that return nothing, failures are
+// reported as a Problem.
+type ErrorResponse struct{}
 
 // RowsErrorResponse ...
 type RowsErrorResponse struct {
-	Err          string `json:"err,omitempty"`
-	RowsAffected int    `json:"rowsAffected"`
+	RowsAffected int `json:"rowsAffected"`
+}
+
+// Problem is the RFC 7807 application/problem+json body errors are reported
+// with.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Detail string `json:"detail,omitempty"`
+	Status int    `json:"status"`
 }
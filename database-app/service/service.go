@@ -1,64 +1,119 @@
 package service
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
+
+	"github.com/lib/pq"
+)
+
+const uniqueViolationCode pq.ErrorCode = "23505"
+
+var (
+	ErrUserAlreadyExists = &Error{
+		Kind: ErrConflict,
+		Err:  errors.New("username or email already in use"),
+	}
+	ErrUserNotFound = &Error{
+		Kind: ErrNotFound,
+		Err:  errors.New("user not found"),
+	}
 )
 
 type serviceInterface interface {
-	GetAllUsers() ([]User, error)
-	GetUserByID(int) (User, error)
-	GetUserByUsernameAndPassword(string, string) (User, error)
-	GetIDByUsername(string) (int, error)
-	InsertUser(string, string, string) error
-	DeleteUser(int) (int, error)
+	GetAllUsers(context.Context, UsersQuery) ([]User, int, string, error)
+	GetUserByID(context.Context, int) (User, error)
+	GetUserByUsernameAndPassword(context.Context, string, string) (User, error)
+	GetIDByUsername(context.Context, string) (int, error)
+	GetIDByEmail(context.Context, string) (int, error)
+	InsertUser(context.Context, string, string, string) (int, error)
+	DeleteUser(context.Context, int) (int, error)
+	UpdateUser(context.Context, int, string, string) (int, error)
+	UpdatePassword(context.Context, int, string) (int, error)
+	VerifyEmail(context.Context, int, string) (int, error)
+	SuspendUser(context.Context, int, bool) (int, error)
 }
 
 // Service ...
 type Service struct {
-	db *sql.DB
+	db tracedDB
 }
 
 // GetService ...
 func GetService(db *sql.DB) *Service {
-	return &Service{db: db}
+	return &Service{db: tracedDB{db}}
 }
 
-// GetAllUsers ...
-func (s Service) GetAllUsers() (users []User, err error) {
-	rows, err := s.db.Query("SELECT id, username, password, email FROM users")
+// GetAllUsers returns one page of users matching the query, the total of
+// users matching the filters and the cursor of the next page, empty on the
+// last one.
+func (s Service) GetAllUsers(ctx context.Context, query UsersQuery) (users []User, total int, next string, err error) {
+	countSQL, countArgs, pageSQL, pageArgs, limit, err := usersPageQuery(query)
 	if err != nil {
-		return nil, fmt.Errorf("uwu error to get all users: %w", err)
+		return nil, 0, "", fmt.Errorf("error to get all users: %w", err)
+	}
+
+	if err = s.db.QueryRowContext(ctx, countSQL, countArgs...).Scan(&total); err != nil {
+		return nil, 0, "", fmt.Errorf("error to count users: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, pageSQL, pageArgs...)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("uwu error to get all users: %w", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
 		var userBeta User
 
-		err = rows.Scan(&userBeta.ID, &userBeta.Username, &userBeta.Password, &userBeta.Email)
+		err = rows.Scan(
+			&userBeta.ID,
+			&userBeta.Username,
+			&userBeta.Email,
+			&userBeta.EmailVerified,
+			&userBeta.Role,
+			&userBeta.Suspended,
+		)
 		if err != nil {
-			return nil, fmt.Errorf("error to get all users: %w", err)
+			return nil, 0, "", fmt.Errorf("error to get all users: %w", err)
 		}
 
 		users = append(users, userBeta)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("error to get all users: %w", err)
+		return nil, 0, "", fmt.Errorf("error to get all users: %w", err)
+	}
+
+	if len(users) > limit {
+		users = users[:limit]
+		next = encodeCursor(users[limit-1].ID)
 	}
 
-	return users, nil
+	return users, total, next, nil
 }
 
 // GetUserByID ...
-func (s Service) GetUserByID(id int) (user User, err error) {
-	row := s.db.QueryRow("SELECT id, username, password, email FROM users WHERE id = $1", id)
+func (s Service) GetUserByID(ctx context.Context, id int) (user User, err error) {
+	row := s.db.QueryRowContext(
+		ctx,
+		"SELECT id, username, email, email_verified, role, suspended FROM users WHERE id = $1",
+		id,
+	)
 
-	err = row.Scan(&user.ID, &user.Username, &user.Password, &user.Email)
+	err = row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.EmailVerified,
+		&user.Role,
+		&user.Suspended,
+	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return User{}, nil
+			return User{}, fmt.Errorf("error to get user by ID: %w", ErrUserNotFound)
 		}
 
 		return User{}, fmt.Errorf("error to get user by ID: %w", err)
@@ -68,17 +123,25 @@ func (s Service) GetUserByID(id int) (user User, err error) {
 }
 
 // GetUserByUsernameAndPassword ...
-func (s Service) GetUserByUsernameAndPassword(username, password string) (user User, err error) {
-	row := s.db.QueryRow(
-		"SELECT id, username, password, email FROM users WHERE username = $1 AND password = $2",
+func (s Service) GetUserByUsernameAndPassword(ctx context.Context, username, password string) (user User, err error) {
+	row := s.db.QueryRowContext(
+		ctx,
+		"SELECT id, username, email, email_verified, role, suspended FROM users WHERE username = $1 AND password = $2",
 		username,
 		password,
 	)
 
-	err = row.Scan(&user.ID, &user.Username, &user.Password, &user.Email)
+	err = row.Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.EmailVerified,
+		&user.Role,
+		&user.Suspended,
+	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return User{}, nil
+			return User{}, fmt.Errorf("error to get user by username and password: %w", ErrUserNotFound)
 		}
 
 		return User{}, fmt.Errorf("error to get user by username and password: %w", err)
@@ -88,13 +151,13 @@ func (s Service) GetUserByUsernameAndPassword(username, password string) (user U
 }
 
 // GetIDByUsername ...
-func (s Service) GetIDByUsername(username string) (id int, err error) {
-	row := s.db.QueryRow("SELECT id FROM users WHERE username = $1", username)
+func (s Service) GetIDByUsername(ctx context.Context, username string) (id int, err error) {
+	row := s.db.QueryRowContext(ctx, "SELECT id FROM users WHERE username = $1", username)
 
 	err = row.Scan(&id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return 0, nil
+			return 0, fmt.Errorf("error to get ID by username: %w", ErrUserNotFound)
 		}
 
 		return 0, fmt.Errorf("error to get ID by username: %w", err)
@@ -103,24 +166,46 @@ func (s Service) GetIDByUsername(username string) (id int, err error) {
 	return id, nil
 }
 
-// InsertUser ...
-func (s *Service) InsertUser(username, password, email string) (err error) {
-	_, err = s.db.Exec(
-		"INSERT INTO users(username, password, email) VALUES ($1,$2,$3)",
+// GetIDByEmail ...
+func (s Service) GetIDByEmail(ctx context.Context, email string) (id int, err error) {
+	row := s.db.QueryRowContext(ctx, "SELECT id FROM users WHERE email = $1", email)
+
+	err = row.Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, fmt.Errorf("error to get ID by email: %w", ErrUserNotFound)
+		}
+
+		return 0, fmt.Errorf("error to get ID by email: %w", err)
+	}
+
+	return id, nil
+}
+
+// InsertUser creates the user and returns its id.
+func (s *Service) InsertUser(ctx context.Context, username, password, email string) (id int, err error) {
+	err = s.db.QueryRowContext(
+		ctx,
+		"INSERT INTO users(username, password, email) VALUES ($1,$2,$3) RETURNING id",
 		username,
 		password,
 		email,
-	)
+	).Scan(&id)
 	if err != nil {
-		return fmt.Errorf("error to insert user: %w", err)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode {
+			return 0, fmt.Errorf("error to insert user: %w", ErrUserAlreadyExists)
+		}
+
+		return 0, fmt.Errorf("error to insert user: %w", err)
 	}
 
-	return nil
+	return id, nil
 }
 
 // DeleteUser ...
-func (s *Service) DeleteUser(id int) (rowsAffected int, err error) {
-	r, err := s.db.Exec("DELETE FROM users WHERE id = $1", id)
+func (s *Service) DeleteUser(ctx context.Context, id int) (rowsAffected int, err error) {
+	r, err := s.db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
 	if err != nil {
 		return 0, fmt.Errorf("error to delete user: %w", err)
 	}
@@ -131,3 +216,72 @@ func (s *Service) DeleteUser(id int) (rowsAffected int, err error) {
 
 	return rowsAffected, nil
 }
+
+// UpdateUser ...
+func (s *Service) UpdateUser(ctx context.Context, id int, username, email string) (rowsAffected int, err error) {
+	// a new email has to be verified again.
+	r, err := s.db.ExecContext(
+		ctx,
+		"UPDATE users SET username = $1, email = $2, email_verified = (email_verified AND email = $2) WHERE id = $3",
+		username,
+		email,
+		id,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolationCode {
+			return 0, fmt.Errorf("error to update user: %w", ErrUserAlreadyExists)
+		}
+
+		return 0, fmt.Errorf("error to update user: %w", err)
+	}
+
+	count, _ := r.RowsAffected()
+
+	rowsAffected = int(count)
+
+	return rowsAffected, nil
+}
+
+// UpdatePassword ...
+func (s *Service) UpdatePassword(ctx context.Context, id int, password string) (rowsAffected int, err error) {
+	r, err := s.db.ExecContext(ctx, "UPDATE users SET password = $1 WHERE id = $2", password, id)
+	if err != nil {
+		return 0, fmt.Errorf("error to update password: %w", err)
+	}
+
+	count, _ := r.RowsAffected()
+
+	rowsAffected = int(count)
+
+	return rowsAffected, nil
+}
+
+// VerifyEmail marks the email of the user as verified while it is still
+// the user's email, no row is affected once the user changed it.
+func (s *Service) VerifyEmail(ctx context.Context, id int, email string) (rowsAffected int, err error) {
+	r, err := s.db.ExecContext(ctx, "UPDATE users SET email_verified = TRUE WHERE id = $1 AND email = $2", id, email)
+	if err != nil {
+		return 0, fmt.Errorf("error to verify email: %w", err)
+	}
+
+	count, _ := r.RowsAffected()
+
+	rowsAffected = int(count)
+
+	return rowsAffected, nil
+}
+
+// SuspendUser ...
+func (s *Service) SuspendUser(ctx context.Context, id int, suspended bool) (rowsAffected int, err error) {
+	r, err := s.db.ExecContext(ctx, "UPDATE users SET suspended = $1 WHERE id = $2", suspended, id)
+	if err != nil {
+		return 0, fmt.Errorf("error to suspend user: %w", err)
+	}
+
+	count, _ := r.RowsAffected()
+
+	rowsAffected = int(count)
+
+	return rowsAffected, nil
+}
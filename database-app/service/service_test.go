@@ -1,8 +1,13 @@
 package service_test
 
 import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
 	"testing"
 
+	"github.com/lib/pq"
+
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/cfabrica46/gokit-crud/database-app/service"
 	"github.com/stretchr/testify/assert"
@@ -22,6 +27,7 @@ const (
 	nameErrorRequest  string = "ErrorRequest"
 	nameErrorDBClosed string = "ErrorDBClosed"
 	nameErrorNoRows   string = "ErrorNoRows"
+	nameErrorUnique   string = "ErrorUniqueViolation"
 )
 
 func TestGetAllUsers(t *testing.T) {
@@ -84,15 +90,22 @@ func TestGetAllUsers(t *testing.T) {
 					"id",
 					"username",
 					"email",
+					"email_verified",
+					"role",
+					"suspended",
 				}).AddRow(
 				tt.outID,
 				tt.outUsername,
 				tt.outEmail,
+				true,
+				service.RoleUser,
+				false,
 			)
 
-			mock.ExpectQuery("SELECT id, username, email FROM users").WillReturnRows(rows)
+			mock.ExpectQuery("^SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			mock.ExpectQuery("^SELECT id, username, email, email_verified, role, suspended FROM users").WillReturnRows(rows)
 
-			_, err = svc.GetAllUsers()
+			_, _, _, err = svc.GetAllUsers(context.TODO(), service.UsersQuery{})
 			if err != nil {
 				resultErr = err.Error()
 			}
@@ -106,6 +119,119 @@ func TestGetAllUsers(t *testing.T) {
 	}
 }
 
+func TestGetAllUsersPagination(t *testing.T) {
+	t.Parallel()
+
+	cursor := base64.RawURLEncoding.EncodeToString([]byte("2"))
+
+	for _, tt := range []struct {
+		name                string
+		inQuery             service.UsersQuery
+		outCountSQL         string
+		outPageSQL          string
+		outNext             string
+		outErr              string
+		outCountArgs        []driver.Value
+		outPageArgs         []driver.Value
+		inIDs               []int
+		outLen, outTotal    int
+		isQueryNotPerformed bool
+	}{
+		{
+			name:         "DefaultsLastPage",
+			inQuery:      service.UsersQuery{},
+			outCountSQL:  "SELECT COUNT(*) FROM users",
+			outPageSQL:   "SELECT id, username, email, email_verified, role, suspended FROM users ORDER BY id ASC LIMIT $1",
+			outPageArgs:  []driver.Value{21},
+			inIDs:        []int{1, 2},
+			outLen:       2,
+			outTotal:     2,
+			outNext:      "",
+			outCountArgs: []driver.Value{},
+		},
+		{
+			name:         "HasNextPage",
+			inQuery:      service.UsersQuery{Limit: 2},
+			outCountSQL:  "SELECT COUNT(*) FROM users",
+			outPageSQL:   "SELECT id, username, email, email_verified, role, suspended FROM users ORDER BY id ASC LIMIT $1",
+			outPageArgs:  []driver.Value{3},
+			inIDs:        []int{1, 2, 3},
+			outLen:       2,
+			outTotal:     3,
+			outNext:      cursor,
+			outCountArgs: []driver.Value{},
+		},
+		{
+			name: "FiltersCursorDesc",
+			inQuery: service.UsersQuery{
+				Cursor:         cursor,
+				UsernamePrefix: "us_r",
+				EmailPrefix:    "em%",
+				Sort:           service.SortDesc,
+				Limit:          500,
+			},
+			outCountSQL:  "SELECT COUNT(*) FROM users WHERE username LIKE $1 AND email LIKE $2",
+			outPageSQL:   "SELECT id, username, email, email_verified, role, suspended FROM users WHERE username LIKE $1 AND email LIKE $2 AND id < $3 ORDER BY id DESC LIMIT $4",
+			outCountArgs: []driver.Value{`us\_r%`, `em\%%`},
+			outPageArgs:  []driver.Value{`us\_r%`, `em\%%`, 2, 101},
+			inIDs:        []int{1},
+			outLen:       1,
+			outTotal:     3,
+			outNext:      "",
+		},
+		{
+			name:                "ErrorInvalidCursor",
+			inQuery:             service.UsersQuery{Cursor: "!!"},
+			outErr:              service.ErrInvalidCursor.Error(),
+			isQueryNotPerformed: true,
+		},
+		{
+			name:                "ErrorInvalidSort",
+			inQuery:             service.UsersQuery{Sort: "sideways"},
+			outErr:              service.ErrInvalidSort.Error(),
+			isQueryNotPerformed: true,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				assert.Error(t, err)
+			}
+			defer db.Close()
+
+			svc := service.GetService(db)
+
+			if !tt.isQueryNotPerformed {
+				rows := sqlmock.NewRows([]string{"id", "username", "email", "email_verified", "role", "suspended"})
+				for _, id := range tt.inIDs {
+					rows.AddRow(id, usernameTest, emailTest, true, service.RoleUser, false)
+				}
+
+				mock.ExpectQuery(tt.outCountSQL).
+					WithArgs(tt.outCountArgs...).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tt.outTotal))
+				mock.ExpectQuery(tt.outPageSQL).WithArgs(tt.outPageArgs...).WillReturnRows(rows)
+			}
+
+			users, total, next, err := svc.GetAllUsers(context.TODO(), tt.inQuery)
+			if tt.outErr != "" {
+				assert.ErrorContains(t, err, tt.outErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, users, tt.outLen)
+			assert.Equal(t, tt.outTotal, total)
+			assert.Equal(t, tt.outNext, next)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 func TestGetUserByID(t *testing.T) {
 	t.Parallel()
 
@@ -129,7 +255,7 @@ func TestGetUserByID(t *testing.T) {
 			inUsername: usernameTest,
 			inPassword: passwordTest,
 			inEmail:    emailTest,
-			outErr:     "",
+			outErr:     "user not found",
 		},
 		{
 			name:       nameErrorDBClosed,
@@ -162,24 +288,35 @@ func TestGetUserByID(t *testing.T) {
 				[]string{
 					"id",
 					"username",
-					"password",
 					"email",
+					"email_verified",
+					"role",
+					"suspended",
 				}).AddRow(
 				tt.inID,
 				tt.inUsername,
-				tt.inPassword,
 				tt.inEmail,
+				true,
+				service.RoleUser,
+				false,
 			)
 
 			if tt.name == nameErrorNoRows {
-				rows = sqlmock.NewRows([]string{"id", "username", "password", "email"})
+				rows = sqlmock.NewRows([]string{
+					"id",
+					"username",
+					"email",
+					"email_verified",
+					"role",
+					"suspended",
+				})
 			}
 
 			mock.ExpectQuery(
-				"^SELECT id, username, password, email FROM users",
+				"^SELECT id, username, email, email_verified, role, suspended FROM users",
 			).WithArgs(tt.inID).WillReturnRows(rows)
 
-			_, err = svc.GetUserByID(tt.inID)
+			_, err = svc.GetUserByID(context.TODO(), tt.inID)
 			if err != nil {
 				resultErr = err.Error()
 			}
@@ -216,7 +353,7 @@ func TestGetUserByUsernameAndPassword(t *testing.T) {
 			inUsername: usernameTest,
 			inPassword: passwordTest,
 			inEmail:    emailTest,
-			outErr:     "",
+			outErr:     "user not found",
 		},
 		{
 			name:       nameErrorDBClosed,
@@ -249,24 +386,35 @@ func TestGetUserByUsernameAndPassword(t *testing.T) {
 				[]string{
 					"id",
 					"username",
-					"password",
 					"email",
+					"email_verified",
+					"role",
+					"suspended",
 				}).AddRow(
 				tt.inID,
 				tt.inUsername,
-				tt.inPassword,
 				tt.inEmail,
+				true,
+				service.RoleUser,
+				false,
 			)
 
 			if tt.name == nameErrorNoRows {
-				rows = sqlmock.NewRows([]string{"id", "username", "password", "email"})
+				rows = sqlmock.NewRows([]string{
+					"id",
+					"username",
+					"email",
+					"email_verified",
+					"role",
+					"suspended",
+				})
 			}
 
 			mock.ExpectQuery(
-				"^SELECT id, username, password, email FROM users",
+				"^SELECT id, username, email, email_verified, role, suspended FROM users",
 			).WithArgs(tt.inUsername, tt.inPassword).WillReturnRows(rows)
 
-			_, err = svc.GetUserByUsernameAndPassword(tt.inUsername, tt.inPassword)
+			_, err = svc.GetUserByUsernameAndPassword(context.TODO(), tt.inUsername, tt.inPassword)
 			if err != nil {
 				resultErr = err.Error()
 			}
@@ -299,7 +447,7 @@ func TestGetIDByUsername(t *testing.T) {
 			name:       nameErrorNoRows,
 			inID:       idTest,
 			inUsername: usernameTest,
-			outErr:     "",
+			outErr:     "user not found",
 		},
 		{
 			name:       nameErrorDBClosed,
@@ -334,7 +482,75 @@ func TestGetIDByUsername(t *testing.T) {
 
 			mock.ExpectQuery("^SELECT id FROM users").WithArgs(tt.inUsername).WillReturnRows(rows)
 
-			_, err = svc.GetIDByUsername(tt.inUsername)
+			_, err = svc.GetIDByUsername(context.TODO(), tt.inUsername)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestGetIDByEmail(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name    string
+		inEmail string
+		outErr  string
+		inID    int
+	}{
+		{
+			name:    nameNoError,
+			inID:    idTest,
+			inEmail: emailTest,
+			outErr:  "",
+		},
+		{
+			name:    nameErrorNoRows,
+			inID:    idTest,
+			inEmail: emailTest,
+			outErr:  "user not found",
+		},
+		{
+			name:    nameErrorDBClosed,
+			inID:    idTest,
+			inEmail: emailTest,
+			outErr:  "sql: database is closed",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Error(t, err)
+			}
+			defer db.Close()
+
+			if tt.name == nameErrorDBClosed {
+				db.Close()
+			}
+
+			svc := service.GetService(db)
+
+			rows := sqlmock.NewRows([]string{"id"}).AddRow(tt.inID)
+
+			if tt.name == nameErrorNoRows {
+				rows = sqlmock.NewRows([]string{"id"})
+			}
+
+			mock.ExpectQuery("^SELECT id FROM users WHERE email").WithArgs(tt.inEmail).WillReturnRows(rows)
+
+			_, err = svc.GetIDByEmail(context.TODO(), tt.inEmail)
 			if err != nil {
 				resultErr = err.Error()
 			}
@@ -355,6 +571,7 @@ func TestInsertUser(t *testing.T) {
 		name                            string
 		inUsername, inPassword, inEmail string
 		outErr                          string
+		outID                           int
 	}{
 		{
 			name:       nameNoError,
@@ -362,13 +579,14 @@ func TestInsertUser(t *testing.T) {
 			inPassword: passwordTest,
 			inEmail:    emailTest,
 			outErr:     "",
+			outID:      idTest,
 		},
 		{
-			name:       nameErrorNoRows,
+			name:       nameErrorUnique,
 			inUsername: usernameTest,
 			inPassword: passwordTest,
 			inEmail:    emailTest,
-			outErr:     "",
+			outErr:     service.ErrUserAlreadyExists.Error(),
 		},
 		{
 			name:       nameErrorDBClosed,
@@ -396,21 +614,27 @@ func TestInsertUser(t *testing.T) {
 
 			svc := service.GetService(db)
 
-			mock.ExpectExec(
-				"^INSERT INTO users",
+			query := mock.ExpectQuery(
+				"^INSERT INTO users(.+) RETURNING id",
 			).WithArgs(
 				tt.inUsername,
 				tt.inPassword,
 				tt.inEmail,
-			).WillReturnResult(
-				sqlmock.NewResult(0, 1),
 			)
 
-			err = svc.InsertUser(tt.inUsername, tt.inPassword, tt.inEmail)
+			if tt.name == nameErrorUnique {
+				query.WillReturnError(&pq.Error{Code: "23505"})
+			} else {
+				query.WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(idTest))
+			}
+
+			id, err := svc.InsertUser(context.TODO(), tt.inUsername, tt.inPassword, tt.inEmail)
 			if err != nil {
 				resultErr = err.Error()
 			}
 
+			assert.Equal(t, tt.outID, id)
+
 			if tt.name == nameNoError {
 				assert.Empty(t, resultErr)
 			} else {
@@ -465,7 +689,270 @@ func TestDeleteUser(t *testing.T) {
 				sqlmock.NewResult(0, 1),
 			)
 
-			_, err = svc.DeleteUser(tt.inID)
+			_, err = svc.DeleteUser(context.TODO(), tt.inID)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestUpdateUser(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name                string
+		inUsername, inEmail string
+		outErr              string
+		inID                int
+	}{
+		{
+			name:       nameNoError,
+			inID:       idTest,
+			inUsername: usernameTest,
+			inEmail:    emailTest,
+			outErr:     "",
+		},
+		{
+			name:       nameErrorUnique,
+			inID:       idTest,
+			inUsername: usernameTest,
+			inEmail:    emailTest,
+			outErr:     service.ErrUserAlreadyExists.Error(),
+		},
+		{
+			name:       nameErrorDBClosed,
+			inID:       idTest,
+			inUsername: usernameTest,
+			inEmail:    emailTest,
+			outErr:     "sql: database is closed",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Error(t, err)
+			}
+			defer db.Close()
+
+			if tt.name == nameErrorDBClosed {
+				db.Close()
+			}
+
+			svc := service.GetService(db)
+
+			exec := mock.ExpectExec(
+				"^UPDATE users",
+			).WithArgs(
+				tt.inUsername,
+				tt.inEmail,
+				tt.inID,
+			)
+
+			if tt.name == nameErrorUnique {
+				exec.WillReturnError(&pq.Error{Code: "23505"})
+			} else {
+				exec.WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			_, err = svc.UpdateUser(context.TODO(), tt.inID, tt.inUsername, tt.inEmail)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestUpdatePassword(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name       string
+		inPassword string
+		outErr     string
+		inID       int
+	}{
+		{
+			name:       nameNoError,
+			inID:       idTest,
+			inPassword: passwordTest,
+			outErr:     "",
+		},
+		{
+			name:       nameErrorDBClosed,
+			inID:       idTest,
+			inPassword: passwordTest,
+			outErr:     "sql: database is closed",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Error(t, err)
+			}
+			defer db.Close()
+
+			if tt.name == nameErrorDBClosed {
+				db.Close()
+			}
+
+			svc := service.GetService(db)
+
+			mock.ExpectExec(
+				"^UPDATE users SET password",
+			).WithArgs(
+				tt.inPassword,
+				tt.inID,
+			).WillReturnResult(
+				sqlmock.NewResult(0, 1),
+			)
+
+			_, err = svc.UpdatePassword(context.TODO(), tt.inID, tt.inPassword)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name   string
+		outErr string
+		inID   int
+	}{
+		{
+			name:   nameNoError,
+			inID:   idTest,
+			outErr: "",
+		},
+		{
+			name:   nameErrorDBClosed,
+			inID:   idTest,
+			outErr: "sql: database is closed",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Error(t, err)
+			}
+			defer db.Close()
+
+			if tt.name == nameErrorDBClosed {
+				db.Close()
+			}
+
+			svc := service.GetService(db)
+
+			mock.ExpectExec(
+				"^UPDATE users SET email_verified = TRUE WHERE id = \\$1 AND email = \\$2",
+			).WithArgs(
+				tt.inID,
+				emailTest,
+			).WillReturnResult(
+				sqlmock.NewResult(0, 1),
+			)
+
+			_, err = svc.VerifyEmail(context.TODO(), tt.inID, emailTest)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestSuspendUser(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name        string
+		outErr      string
+		inID        int
+		inSuspended bool
+	}{
+		{
+			name:        nameNoError,
+			inID:        idTest,
+			inSuspended: true,
+			outErr:      "",
+		},
+		{
+			name:        nameErrorDBClosed,
+			inID:        idTest,
+			inSuspended: true,
+			outErr:      "sql: database is closed",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				assert.Error(t, err)
+			}
+			defer db.Close()
+
+			if tt.name == nameErrorDBClosed {
+				db.Close()
+			}
+
+			svc := service.GetService(db)
+
+			mock.ExpectExec(
+				"^UPDATE users SET suspended",
+			).WithArgs(
+				tt.inSuspended,
+				tt.inID,
+			).WillReturnResult(
+				sqlmock.NewResult(0, 1),
+			)
+
+			_, err = svc.SuspendUser(context.TODO(), tt.inID, tt.inSuspended)
 			if err != nil {
 				resultErr = err.Error()
 			}
@@ -0,0 +1,78 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cfabrica46/gokit-crud/database-app/service"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	recorderOnce sync.Once
+	recorder     *tracetest.SpanRecorder
+)
+
+// spanRecorder installs, once for every test, a global tracer provider that
+// records the spans.
+func spanRecorder() *tracetest.SpanRecorder {
+	recorderOnce.Do(func() {
+		recorder = tracetest.NewSpanRecorder()
+
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+
+	return recorder
+}
+
+// endedSpans are the ended spans of the trace of traceID.
+func endedSpans(traceID trace.TraceID) map[string]sdktrace.ReadOnlySpan {
+	spans := map[string]sdktrace.ReadOnlySpan{}
+
+	for _, span := range spanRecorder().Ended() {
+		if span.SpanContext().TraceID() == traceID {
+			spans[span.Name()] = span
+		}
+	}
+
+	return spans
+}
+
+func TestTracedDB(t *testing.T) {
+	t.Parallel()
+
+	spanRecorder()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+
+	defer db.Close()
+
+	mock.ExpectQuery("^SELECT id FROM users").WithArgs(usernameTest).WillReturnRows(
+		sqlmock.NewRows([]string{"id"}).AddRow(idTest),
+	)
+
+	ctx, parent := otel.Tracer("test").Start(context.TODO(), "parent")
+
+	_, err = service.GetService(db).GetIDByUsername(ctx, usernameTest)
+	assert.NoError(t, err)
+
+	parent.End()
+
+	span, ok := endedSpans(parent.SpanContext().TraceID())["SELECT"]
+	if !assert.True(t, ok) {
+		return
+	}
+
+	assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	assert.Contains(t, span.Attributes(), semconv.DBStatementKey.String("SELECT id FROM users WHERE username = $1"))
+}
@@ -3,10 +3,14 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 
+	"github.com/cfabrica46/gokit-crud/platform/logging"
 	httptransport "github.com/go-kit/kit/transport/http"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // DecodeRequestWithoutBody ...
@@ -22,17 +26,65 @@ func DecodeRequestWithoutBody() httptransport.DecodeRequestFunc {
 func DecodeRequest[req IDRequest |
 	UsernamePasswordRequest |
 	UsernameRequest |
-	UsernamePasswordEmailRequest](request req,
+	UsernamePasswordEmailRequest |
+	IDUsernameEmailRequest |
+	IDPasswordRequest |
+	IDEmailRequest |
+	EmailRequest |
+	IDSuspendedRequest](request req,
 ) httptransport.DecodeRequestFunc {
 	return func(_ context.Context, r *http.Request) (any, error) {
 		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-			return nil, fmt.Errorf("failed to decode request: %w", err)
+			return nil, &Error{Kind: ErrValidation, Err: fmt.Errorf("failed to decode request: %w", err)}
 		}
 
 		return request, nil
 	}
 }
 
+// DecodeUsersQueryRequest ... an empty body lists the first page with the
+// default limit.
+func DecodeUsersQueryRequest() httptransport.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (any, error) {
+		var request UsersQuery
+
+		err := json.NewDecoder(r.Body).Decode(&request)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, &Error{Kind: ErrValidation, Err: fmt.Errorf("failed to decode request: %w", err)}
+		}
+
+		return request, nil
+	}
+}
+
+// EncodeError is the ServerErrorEncoder of every route, it reports err as
+// an RFC 7807 problem document. The detail of a failure of the service is
+// left out, it would leak the internals, LoggingMiddleware logs it instead.
+func EncodeError(ctx context.Context, err error, w http.ResponseWriter) {
+	status := StatusCode(err)
+
+	detail := err.Error()
+	if status >= http.StatusInternalServerError {
+		detail = ""
+	}
+
+	trace.SpanFromContext(ctx).RecordError(err)
+
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		w.Header().Set(logging.RequestIDHeader, requestID)
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	})
+}
+
 // EncodeResponse ...
 func EncodeResponse(_ context.Context, w http.ResponseWriter, response any) error {
 	if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -3,6 +3,8 @@ package service_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -13,7 +15,8 @@ import (
 )
 
 type myRequests struct {
-	idReq, usernameReq, usernamePasswordReq, usernamePasswordEmailReq, badReq *http.Request
+	idReq, usernameReq, usernamePasswordReq, usernamePasswordEmailReq *http.Request
+	idUsernameEmailReq, emailReq, badReq                              *http.Request
 }
 
 const (
@@ -37,6 +40,16 @@ const (
 		 "password": "password",
 		 "email": "email@email.com"
 	}`
+
+	emailRequestJSON = `{
+		 "email": "email@email.com"
+	}`
+
+	idUsernameEmailRequestJSON = `{
+		 "id": 1,
+		 "username": "username",
+		 "email": "email@email.com"
+	}`
 )
 
 func TestDecodeRequestWithoutBody(t *testing.T) {
@@ -67,6 +80,64 @@ func TestDecodeRequestWithoutBody(t *testing.T) {
 	}
 }
 
+func TestDecodeUsersQueryRequest(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		out    any
+		in     string
+		name   string
+		outErr string
+	}{
+		{
+			name:   nameNoError + "EmptyBody",
+			in:     "",
+			outErr: "",
+			out:    service.UsersQuery{},
+		},
+		{
+			name:   nameNoError,
+			in:     `{"cursor":"Mg","username":"ce","email":"ce@","sort":"desc","limit":5}`,
+			outErr: "",
+			out: service.UsersQuery{
+				Cursor:         "Mg",
+				UsernamePrefix: "ce",
+				EmailPrefix:    "ce@",
+				Sort:           service.SortDesc,
+				Limit:          5,
+			},
+		},
+		{
+			name:   "BadRequest",
+			in:     `{"limit":"five"}`,
+			outErr: "failed to decode request",
+			out:    nil,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			req := httptest.NewRequest(http.MethodGet, "/users", bytes.NewBufferString(tt.in))
+
+			r, err := service.DecodeUsersQueryRequest()(context.TODO(), req)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			if tt.outErr == "" {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+
+			assert.Equal(t, tt.out, r)
+		})
+	}
+}
+
 func TestDecodeRequest(t *testing.T) {
 	t.Parallel()
 
@@ -116,6 +187,22 @@ func TestDecodeRequest(t *testing.T) {
 			outEmail:    emailTest,
 			outErr:      "",
 		},
+		{
+			name:        nameNoError + "IDUsernameEmailRequest",
+			inType:      service.IDUsernameEmailRequest{},
+			in:          myReqs.idUsernameEmailReq,
+			outID:       idTest,
+			outUsername: usernameTest,
+			outEmail:    emailTest,
+			outErr:      "",
+		},
+		{
+			name:     nameNoError + "EmailRequest",
+			inType:   service.EmailRequest{},
+			in:       myReqs.emailReq,
+			outEmail: emailTest,
+			outErr:   "",
+		},
 		{
 			name:   "BadRequest",
 			inType: service.IDRequest{},
@@ -184,6 +271,32 @@ func TestDecodeRequest(t *testing.T) {
 				assert.Equal(t, tt.outPassword, result.Password)
 				assert.Equal(t, tt.outEmail, result.Email)
 				assert.Contains(t, resultErr, tt.outErr)
+
+			case service.IDUsernameEmailRequest:
+				req, err = service.DecodeRequest(resultType)(context.TODO(), tt.in)
+				if err != nil {
+					resultErr = err.Error()
+				}
+
+				result, ok := req.(service.IDUsernameEmailRequest)
+				assert.True(t, ok)
+
+				assert.Equal(t, tt.outID, result.ID)
+				assert.Equal(t, tt.outUsername, result.Username)
+				assert.Equal(t, tt.outEmail, result.Email)
+				assert.Contains(t, resultErr, tt.outErr)
+
+			case service.EmailRequest:
+				req, err = service.DecodeRequest(resultType)(context.TODO(), tt.in)
+				if err != nil {
+					resultErr = err.Error()
+				}
+
+				result, ok := req.(service.EmailRequest)
+				assert.True(t, ok)
+
+				assert.Equal(t, tt.outEmail, result.Email)
+				assert.Contains(t, resultErr, tt.outErr)
 			}
 		})
 	}
@@ -227,6 +340,52 @@ func TestEncodeResponse(t *testing.T) {
 	}
 }
 
+func TestEncodeError(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+
+	service.EncodeError(context.TODO(), service.ErrUserAlreadyExists, w)
+
+	var problem service.Problem
+
+	err := json.NewDecoder(w.Body).Decode(&problem)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, service.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusConflict),
+		Detail: service.ErrUserAlreadyExists.Error(),
+		Status: http.StatusConflict,
+	}, problem)
+}
+
+func TestEncodeErrorInternal(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+
+	service.EncodeError(context.TODO(), errors.New(`pq: relation "users" does not exist`), w)
+
+	var problem service.Problem
+
+	err := json.NewDecoder(w.Body).Decode(&problem)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, service.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+	}, problem)
+}
+
 func getRequests() (myReqs *myRequests, err error) {
 	idReq, err := http.NewRequest(
 		http.MethodPost,
@@ -264,6 +423,24 @@ func getRequests() (myReqs *myRequests, err error) {
 		return nil, fmt.Errorf("error: %w", err)
 	}
 
+	idUsernameEmailReq, err := http.NewRequest(
+		http.MethodPatch,
+		urlTest,
+		bytes.NewBuffer([]byte(idUsernameEmailRequestJSON)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error: %w", err)
+	}
+
+	emailReq, err := http.NewRequest(
+		http.MethodGet,
+		urlTest,
+		bytes.NewBuffer([]byte(emailRequestJSON)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error: %w", err)
+	}
+
 	badReq, err := http.NewRequest(http.MethodPost, urlTest, bytes.NewBuffer([]byte{}))
 	if err != nil {
 		return nil, fmt.Errorf("error: %w", err)
@@ -274,6 +451,8 @@ func getRequests() (myReqs *myRequests, err error) {
 		usernameReq:              usernameReq,
 		usernamePasswordReq:      usernamePasswordReq,
 		usernamePasswordEmailReq: usernamePasswordEmailReq,
+		idUsernameEmailReq:       idUsernameEmailReq,
+		emailReq:                 emailReq,
 		badReq:                   badReq,
 	}, nil
 }
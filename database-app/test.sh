@@ -1,5 +1,7 @@
 # GetAllUsers
 # curl -XGET localhost:7070/users
+# curl -XGET -d'{"limit":10,"username":"ce","sort":"desc"}' localhost:7070/users
+# curl -XGET -d'{"limit":10,"cursor":"<next>"}' localhost:7070/users
 
 # GetUserByID
 # curl -XGET -d'{"id":1}' localhost:7070/user/id
@@ -10,8 +12,23 @@ curl -XGET -d'{"username":"cesar","password":"01234"}' localhost:7070/user/usern
 # GetIDByUsername
 # curl -XGET -d'{"username":"cesar"}' localhost:7070/id/username
 
+# GetIDByEmail
+# curl -XGET -d'{"email":"cesar@gmail.com"}' localhost:7070/id/email
+
 # Insert User
 # curl -XPOST -d'{"username":"arturo","password":"nava","email":"arthurnavah@gmail.com"}' localhost:7070/user
 
 # DeleteUserByUsername
 # curl -XDELETE -d'{"username":"arturo","password":"nava","email":"arthurnavah@gmail.com"}' localhost:7070/user
+
+# UpdateUser
+# curl -XPATCH -d'{"id":1,"username":"arturo","email":"arthurnavah@gmail.com"}' localhost:7070/user
+
+# UpdatePassword
+# curl -XPATCH -d'{"id":1,"password":"nava2"}' localhost:7070/user/password
+
+# VerifyEmail
+# curl -XPATCH -d'{"id":1,"email":"arthurnavah@gmail.com"}' localhost:7070/user/email_verified
+
+# SuspendUser
+# curl -XPATCH -d'{"id":2,"suspended":true}' localhost:7070/user/suspended
@@ -0,0 +1,171 @@
+// Package health answers the liveness and readiness probes of the services
+// and shuts them down gracefully.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+const (
+	checkPassed = "ok"
+	checkFailed = "failed"
+)
+
+// Check reports whether a dependency of the service is ready.
+type Check func(ctx context.Context) error
+
+// Health is the body of the liveness and readiness probes, Checks has the
+// result of every check: "ok" or "failed". The probes aren't authenticated,
+// so the errors of the checks are logged instead.
+type Health struct {
+	Checks map[string]string `json:"checks,omitempty"`
+	Status string            `json:"status"`
+}
+
+// LivenessHandler answers while the process is able to serve.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeHealth(w, http.StatusOK, Health{Status: checkPassed})
+	})
+}
+
+// Readiness answers the readiness probe, the service is ready while every
+// check passes and until it starts draining.
+type Readiness struct {
+	logger   log.Logger
+	checks   map[string]Check
+	timeout  time.Duration
+	draining int32
+}
+
+// NewReadiness runs the checks on every probe, each one within timeout, and
+// logs the errors of those that fail.
+func NewReadiness(logger log.Logger, timeout time.Duration, checks map[string]Check) *Readiness {
+	return &Readiness{logger: logger, checks: checks, timeout: timeout}
+}
+
+// Drain makes the probe fail, so no new traffic is routed to the service
+// while it shuts down.
+func (r *Readiness) Drain() {
+	atomic.StoreInt32(&r.draining, 1)
+}
+
+// Check runs every check, it returns whether each one passed and whether all
+// of them did.
+func (r *Readiness) Check(ctx context.Context) (map[string]string, bool) {
+	results := make(map[string]string, len(r.checks))
+	ready := true
+
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		checkCtx, cancel := context.WithTimeout(ctx, r.timeout)
+		err := r.checks[name](checkCtx)
+
+		cancel()
+
+		if err != nil {
+			_ = level.Warn(r.logger).Log("msg", "readiness check failed", "check", name, "err", err)
+
+			results[name] = checkFailed
+			ready = false
+
+			continue
+		}
+
+		results[name] = checkPassed
+	}
+
+	return results, ready
+}
+
+// ServeHTTP answers 200 while the service is ready, 503 with the result of
+// the checks otherwise.
+func (r *Readiness) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if atomic.LoadInt32(&r.draining) == 1 {
+		writeHealth(w, http.StatusServiceUnavailable, Health{Status: "shutting down"})
+
+		return
+	}
+
+	results, ready := r.Check(req.Context())
+	if !ready {
+		writeHealth(w, http.StatusServiceUnavailable, Health{Checks: results, Status: "unavailable"})
+
+		return
+	}
+
+	writeHealth(w, http.StatusOK, Health{Checks: results, Status: checkPassed})
+}
+
+// Failures are the checks that did not pass, or the status without them.
+func (h Health) Failures() string {
+	failed := []string{}
+
+	for name, result := range h.Checks {
+		if result != checkPassed {
+			failed = append(failed, name+": "+result)
+		}
+	}
+
+	if len(failed) == 0 {
+		return h.Status
+	}
+
+	sort.Strings(failed)
+
+	return strings.Join(failed, ", ")
+}
+
+func writeHealth(w http.ResponseWriter, status int, health Health) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(health)
+}
+
+// Serve runs srv until ctx is done, then it drains the readiness and shuts
+// srv down, waiting up to timeout for the requests in flight to finish. The
+// shutdown starts drainDelay after the drain, so the load balancers see the
+// probe fail and stop routing new requests before srv stops accepting them.
+func Serve(ctx context.Context, srv *http.Server, readiness *Readiness, drainDelay, timeout time.Duration) error {
+	errs := make(chan error, 1)
+
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("failed to serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	readiness.Drain()
+
+	time.Sleep(drainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+
+	return nil
+}
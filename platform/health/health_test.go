@@ -0,0 +1,128 @@
+package health_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/platform/health"
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLivenessHandler(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+	health.LivenessHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+}
+
+func TestReadiness(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name      string
+		inChecks  map[string]health.Check
+		inDrain   bool
+		outStatus int
+		outBody   string
+		outLog    string
+	}{
+		{
+			name: "NoError",
+			inChecks: map[string]health.Check{
+				"postgres": func(context.Context) error { return nil },
+			},
+			outStatus: http.StatusOK,
+			outBody:   `{"status":"ok","checks":{"postgres":"ok"}}`,
+		},
+		{
+			name: "ErrorCheck",
+			inChecks: map[string]health.Check{
+				"postgres": func(context.Context) error { return errors.New("connection refused") },
+			},
+			outStatus: http.StatusServiceUnavailable,
+			outBody:   `{"status":"unavailable","checks":{"postgres":"failed"}}`,
+			outLog:    `check=postgres err="connection refused"`,
+		},
+		{
+			name: "ErrorTimeout",
+			inChecks: map[string]health.Check{
+				"postgres": func(ctx context.Context) error {
+					<-ctx.Done()
+
+					return ctx.Err()
+				},
+			},
+			outStatus: http.StatusServiceUnavailable,
+			outBody:   `{"status":"unavailable","checks":{"postgres":"failed"}}`,
+			outLog:    `check=postgres err="context deadline exceeded"`,
+		},
+		{
+			name: "ErrorDraining",
+			inChecks: map[string]health.Check{
+				"postgres": func(context.Context) error { return nil },
+			},
+			inDrain:   true,
+			outStatus: http.StatusServiceUnavailable,
+			outBody:   `{"status":"shutting down"}`,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			readiness := health.NewReadiness(log.NewLogfmtLogger(&buf), 10*time.Millisecond, tt.inChecks)
+			if tt.inDrain {
+				readiness.Drain()
+			}
+
+			w := httptest.NewRecorder()
+			readiness.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, tt.outStatus, w.Code)
+			assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			assert.JSONEq(t, tt.outBody, w.Body.String())
+			assert.Contains(t, buf.String(), tt.outLog)
+		})
+	}
+}
+
+func TestServe(t *testing.T) {
+	t.Parallel()
+
+	readiness := health.NewReadiness(log.NewNopLogger(), time.Second, nil)
+	srv := &http.Server{Addr: "127.0.0.1:0", ReadHeaderTimeout: time.Second}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	drainDelay := 50 * time.Millisecond
+	begin := time.Now()
+
+	assert.NoError(t, health.Serve(ctx, srv, readiness, drainDelay, time.Second))
+	assert.GreaterOrEqual(t, time.Since(begin), drainDelay)
+
+	w := httptest.NewRecorder()
+	readiness.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestServeError(t *testing.T) {
+	t.Parallel()
+
+	srv := &http.Server{Addr: "127.0.0.1:-1", ReadHeaderTimeout: time.Second}
+
+	err := health.Serve(context.Background(), srv, health.NewReadiness(log.NewNopLogger(), time.Second, nil), 0, time.Second)
+	assert.ErrorContains(t, err, "failed to serve")
+}
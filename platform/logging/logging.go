@@ -0,0 +1,228 @@
+// Package logging is how the services log: leveled go-kit loggers, the
+// request and user IDs that correlate the logs of a request across the
+// services, the log line of every request and of every failed endpoint call,
+// and the redaction of the payloads that are logged.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+const (
+	// RequestIDHeader carries the ID that correlates the logs of a request
+	// across the services.
+	RequestIDHeader = "X-Request-ID"
+
+	// UserIDHeader carries the ID of the user the gateway is calling for to
+	// the downstream services.
+	UserIDHeader = "X-User-ID"
+
+	maxRequestIDLength = 128
+	redacted           = "[REDACTED]"
+)
+
+type contextKey int
+
+const (
+	requestIDContextKey contextKey = iota
+	userIDContextKey
+	requestStartContextKey
+)
+
+// NewLogger logs to w as JSON when format is "json" and as logfmt
+// otherwise, dropping what is below lvl: debug, info (the default), warn or
+// error.
+func NewLogger(w io.Writer, format, lvl string) log.Logger {
+	var logger log.Logger
+
+	if format == "json" {
+		logger = log.NewJSONLogger(log.NewSyncWriter(w))
+	} else {
+		logger = log.NewLogfmtLogger(log.NewSyncWriter(w))
+	}
+
+	logger = log.With(logger, "ts", log.DefaultTimestampUTC)
+
+	switch strings.ToLower(lvl) {
+	case "debug":
+		return level.NewFilter(logger, level.AllowDebug())
+	case "warn":
+		return level.NewFilter(logger, level.AllowWarn())
+	case "error":
+		return level.NewFilter(logger, level.AllowError())
+	default:
+		return level.NewFilter(logger, level.AllowInfo())
+	}
+}
+
+// ServerOptions put the request ID and the user ID, as userID reads it from
+// the request, in the context, echo the request ID, the error encoder has to
+// do it for failures, and log every request once it is answered.
+func ServerOptions(logger log.Logger, userID func(r *http.Request) string) []httptransport.ServerOption {
+	return []httptransport.ServerOption{
+		httptransport.ServerBefore(func(ctx context.Context, r *http.Request) context.Context {
+			return NewContext(ctx, r.Header.Get(RequestIDHeader), userID(r))
+		}),
+		httptransport.ServerAfter(func(ctx context.Context, w http.ResponseWriter) context.Context {
+			w.Header().Set(RequestIDHeader, RequestID(ctx))
+
+			return ctx
+		}),
+		httptransport.ServerFinalizer(func(ctx context.Context, code int, r *http.Request) {
+			_ = level.Info(logger).Log(
+				"transport", "http",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"code", code,
+				"duration", Since(ctx),
+				"request_id", RequestID(ctx),
+				"user_id", UserID(ctx),
+			)
+		}),
+	}
+}
+
+// UserIDFromHeader is the user ID the gateway sent in UserIDHeader.
+func UserIDFromHeader(r *http.Request) string {
+	return r.Header.Get(UserIDHeader)
+}
+
+// NewContext starts a request in ctx, with a new request ID when requestID
+// is empty or too long to be one.
+func NewContext(ctx context.Context, requestID, userID string) context.Context {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		requestID = newRequestID()
+	}
+
+	ctx = context.WithValue(ctx, requestStartContextKey, time.Now())
+	ctx = context.WithValue(ctx, requestIDContextKey, requestID)
+
+	return context.WithValue(ctx, userIDContextKey, userID)
+}
+
+// RequestID is the ID of the request being served.
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey).(string)
+
+	return requestID
+}
+
+// UserID is the ID of the user the request is served for, if known.
+func UserID(ctx context.Context) string {
+	id, _ := ctx.Value(userIDContextKey).(string)
+
+	return id
+}
+
+// Since is how long ago the request being served started.
+func Since(ctx context.Context) time.Duration {
+	begin, _ := ctx.Value(requestStartContextKey).(time.Time)
+
+	return time.Since(begin)
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+// Middleware logs the failed calls of the endpoint of method, as errors when
+// statusCode tells they are the fault of the service, and at debug level
+// every call with its redacted request.
+func Middleware(logger log.Logger, method string, statusCode func(err error) int) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request any) (response any, err error) {
+			defer func(begin time.Time) {
+				keyvals := []any{
+					"endpoint", method,
+					"duration", time.Since(begin),
+					"request_id", RequestID(ctx),
+					"user_id", UserID(ctx),
+				}
+
+				switch {
+				case err != nil && statusCode(err) >= http.StatusInternalServerError:
+					_ = level.Error(logger).Log(append(keyvals, "err", err)...)
+
+					return
+				case err != nil:
+					_ = level.Warn(logger).Log(append(keyvals, "err", err)...)
+
+					return
+				}
+
+				_ = level.Debug(logger).Log(append(keyvals, "request", Redact(request))...)
+			}(time.Now())
+
+			return next(ctx, request)
+		}
+	}
+}
+
+// Redact encodes payload to JSON with the values of the fields about
+// passwords, tokens and secrets replaced, so that it can be logged.
+func Redact(payload any) json.RawMessage {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil
+	}
+
+	var value any
+	if err = json.Unmarshal(data, &value); err != nil {
+		return nil
+	}
+
+	data, err = json.Marshal(redactValue(value))
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+func redactValue(value any) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, field := range typed {
+			if isSensitive(key) {
+				typed[key] = redacted
+
+				continue
+			}
+
+			typed[key] = redactValue(field)
+		}
+	case []any:
+		for i, item := range typed {
+			typed[i] = redactValue(item)
+		}
+	}
+
+	return value
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, sensitive := range []string{"password", "token", "secret"} {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+
+	return false
+}
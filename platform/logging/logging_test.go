@@ -0,0 +1,266 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cfabrica46/gokit-crud/platform/logging"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/go-kit/log/level"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errConflict = errors.New("conflict")
+	errInternal = errors.New("internal")
+)
+
+func statusCode(err error) int {
+	if errors.Is(err, errConflict) {
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
+}
+
+// logLines decodes the JSON lines written by a logger.
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var lines []map[string]any
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var decoded map[string]any
+		assert.NoError(t, json.Unmarshal([]byte(line), &decoded))
+
+		lines = append(lines, decoded)
+	}
+
+	return lines
+}
+
+func TestNewLogger(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name     string
+		inFormat string
+		inLevel  string
+		outLog   string
+	}{
+		{
+			name:     "JSON",
+			inFormat: "json",
+			inLevel:  "info",
+			outLog:   `"msg":"info"`,
+		},
+		{
+			name:     "Logfmt",
+			inFormat: "logfmt",
+			inLevel:  "info",
+			outLog:   "msg=info",
+		},
+		{
+			name:     "Filtered",
+			inFormat: "logfmt",
+			inLevel:  "error",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			logger := logging.NewLogger(&buf, tt.inFormat, tt.inLevel)
+			_ = level.Info(logger).Log("msg", "info")
+
+			if tt.outLog == "" {
+				assert.Empty(t, buf.String())
+
+				return
+			}
+
+			assert.Contains(t, buf.String(), tt.outLog)
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		in   any
+		name string
+		out  string
+	}{
+		{
+			name: "Password",
+			in: struct {
+				Username string `json:"username"`
+				Password string `json:"password"`
+			}{"username", "password"},
+			out: `{"password":"[REDACTED]","username":"username"}`,
+		},
+		{
+			name: "Nested",
+			in: map[string]any{
+				"users":  []any{map[string]any{"refreshToken": "a", "id": 1}},
+				"Secret": "s",
+			},
+			out: `{"Secret":"[REDACTED]","users":[{"id":1,"refreshToken":"[REDACTED]"}]}`,
+		},
+		{
+			name: "NotJSON",
+			in:   func() {},
+			out:  ``,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.out, string(logging.Redact(tt.in)))
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		inErr    error
+		name     string
+		inLevel  string
+		outLevel string
+		outLines int
+	}{
+		{
+			name:     "NoError",
+			inLevel:  "debug",
+			outLevel: "debug",
+			outLines: 1,
+		},
+		{
+			name:     "NoErrorInfo",
+			inLevel:  "info",
+			outLines: 0,
+		},
+		{
+			name:     "ErrorConflict",
+			inLevel:  "info",
+			inErr:    errConflict,
+			outLevel: "warn",
+			outLines: 1,
+		},
+		{
+			name:     "ErrorInternal",
+			inLevel:  "error",
+			inErr:    errInternal,
+			outLevel: "error",
+			outLines: 1,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			logger := logging.NewLogger(&buf, "json", tt.inLevel)
+
+			e := logging.Middleware(logger, "SignIn", statusCode)(func(context.Context, any) (any, error) {
+				return nil, tt.inErr
+			})
+
+			ctx := logging.NewContext(context.TODO(), "request", "1")
+
+			_, _ = e(ctx, map[string]string{"password": "hunter2"})
+
+			lines := logLines(t, &buf)
+			if !assert.Len(t, lines, tt.outLines) || tt.outLines == 0 {
+				return
+			}
+
+			assert.Equal(t, tt.outLevel, lines[0]["level"])
+			assert.Equal(t, "SignIn", lines[0]["endpoint"])
+			assert.Equal(t, "request", lines[0]["request_id"])
+			assert.Equal(t, "1", lines[0]["user_id"])
+			assert.NotContains(t, buf.String(), "hunter2")
+		})
+	}
+}
+
+func TestServerOptions(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name         string
+		inRequestID  string
+		outRequestID string
+	}{
+		{
+			name:         "RequestID",
+			inRequestID:  "request-1",
+			outRequestID: "request-1",
+		},
+		{
+			name: "GeneratedID",
+		},
+		{
+			name:        "GeneratedIDTooLong",
+			inRequestID: strings.Repeat("a", 129),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			handler := httptransport.NewServer(
+				func(context.Context, any) (any, error) { return nil, nil },
+				func(context.Context, *http.Request) (any, error) { return nil, nil },
+				httptransport.EncodeJSONResponse,
+				logging.ServerOptions(logging.NewLogger(&buf, "json", "info"), logging.UserIDFromHeader)...,
+			)
+
+			req := httptest.NewRequest(http.MethodPost, "/user", nil)
+			req.Header.Set(logging.UserIDHeader, "1")
+
+			if tt.inRequestID != "" {
+				req.Header.Set(logging.RequestIDHeader, tt.inRequestID)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			requestID := w.Header().Get(logging.RequestIDHeader)
+			if tt.outRequestID != "" {
+				assert.Equal(t, tt.outRequestID, requestID)
+			} else {
+				assert.Len(t, requestID, 32)
+			}
+
+			lines := logLines(t, &buf)
+			if !assert.Len(t, lines, 1) {
+				return
+			}
+
+			assert.Equal(t, "/user", lines[0]["path"])
+			assert.Equal(t, float64(http.StatusOK), lines[0]["code"])
+			assert.Equal(t, requestID, lines[0]["request_id"])
+			assert.Equal(t, "1", lines[0]["user_id"])
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Package metrics is how the services instrument their endpoints: the number
+// of requests and errors and the latency of every endpoint, labelled by
+// method under the namespace of the service. The instruments of the calls a
+// service makes, to other services, Redis or the database, stay in the
+// service.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/endpoint"
+	kitmetrics "github.com/go-kit/kit/metrics"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// EndpointMetrics are the instruments of the endpoints, labelled by method.
+type EndpointMetrics struct {
+	Requests kitmetrics.Counter
+	Errors   kitmetrics.Counter
+	Latency  kitmetrics.Histogram
+}
+
+// NewEndpointMetrics registers the endpoint instruments of the service of
+// namespace in reg.
+func NewEndpointMetrics(reg prometheus.Registerer, namespace string) *EndpointMetrics {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "endpoint",
+		Name:      "requests_total",
+		Help:      "Number of requests received.",
+	}, []string{"method"})
+
+	errs := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "endpoint",
+		Name:      "errors_total",
+		Help:      "Number of requests that failed.",
+	}, []string{"method"})
+
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "endpoint",
+		Name:      "request_duration_seconds",
+		Help:      "Time spent serving the requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	reg.MustRegister(requests, errs, latency)
+
+	return &EndpointMetrics{
+		Requests: kitprometheus.NewCounter(requests),
+		Errors:   kitprometheus.NewCounter(errs),
+		Latency:  kitprometheus.NewHistogram(latency),
+	}
+}
+
+// InstrumentingMiddleware counts the requests and errors of the endpoint of
+// method and observes how long they take.
+func InstrumentingMiddleware(m *EndpointMetrics, method string) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request any) (response any, err error) {
+			defer func(begin time.Time) {
+				m.Requests.With("method", method).Add(1)
+
+				if err != nil {
+					m.Errors.With("method", method).Add(1)
+				}
+
+				m.Latency.With("method", method).Observe(time.Since(begin).Seconds())
+			}(time.Now())
+
+			return next(ctx, request)
+		}
+	}
+}
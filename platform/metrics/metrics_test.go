@@ -0,0 +1,42 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cfabrica46/gokit-crud/platform/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+var errEndpoint = errors.New("endpoint error")
+
+func TestInstrumentingMiddleware(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	instrument := metrics.InstrumentingMiddleware(metrics.NewEndpointMetrics(reg, "app"), "Profile")
+
+	ok := instrument(func(context.Context, any) (any, error) { return nil, nil })
+	fail := instrument(func(context.Context, any) (any, error) { return nil, errEndpoint })
+
+	for _, e := range []func(context.Context, any) (any, error){ok, ok, fail} {
+		_, _ = e(context.TODO(), nil)
+	}
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP app_endpoint_errors_total Number of requests that failed.
+# TYPE app_endpoint_errors_total counter
+app_endpoint_errors_total{method="Profile"} 1
+# HELP app_endpoint_requests_total Number of requests received.
+# TYPE app_endpoint_requests_total counter
+app_endpoint_requests_total{method="Profile"} 3
+`), "app_endpoint_requests_total", "app_endpoint_errors_total"))
+
+	count, err := testutil.GatherAndCount(reg, "app_endpoint_request_duration_seconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,351 @@
+// Package settings loads the configuration of a service into a struct whose
+// fields are described by tags, from flags, the environment, a .env file, a
+// YAML file and the defaults.
+package settings
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalid is returned when values are missing or malformed.
+var ErrInvalid = errors.New("invalid configuration")
+
+// setting is a field of the configuration, named after its env variable.
+//
+// Fields are described with tags: env is the name, default the value used
+// when no source sets it, usage the help of its flag, secret hides it when
+// printed and lets it be read from the file at <name>_FILE, and validate
+// lists the rules it has to meet separated by commas: required, port,
+// positive and oneof=<values separated by spaces>. Nested structs are
+// flattened, with the prefix tag before the names of their fields.
+type setting struct {
+	value                 reflect.Value
+	name, def, usage, val string
+	secret                bool
+}
+
+func fields(v reflect.Value, prefix string) []setting {
+	var all []setting
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			all = append(all, fields(v.Field(i), prefix+field.Tag.Get("prefix"))...)
+
+			continue
+		}
+
+		all = append(all, setting{
+			value:  v.Field(i),
+			name:   prefix + field.Tag.Get("env"),
+			def:    field.Tag.Get("default"),
+			usage:  field.Tag.Get("usage"),
+			val:    field.Tag.Get("validate"),
+			secret: field.Tag.Get("secret") == "true",
+		})
+	}
+
+	return all
+}
+
+// flagName is the flag of an env variable: DB_HOST is -db-host.
+func flagName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
+
+// flagValue keeps the flag as given, boolean settings are boolean flags.
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+// String ...
+func (f *flagValue) String() string {
+	if f == nil {
+		return ""
+	}
+
+	return f.value
+}
+
+// Set ...
+func (f *flagValue) Set(value string) error {
+	f.value = value
+
+	return nil
+}
+
+// IsBoolFlag ...
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+// source is where values come from, it reports whether name is set.
+type source func(name string) (string, bool)
+
+// Load fills cfg, a pointer to a struct, from the flags in args, the
+// environment, the .env file and the YAML file, in this order of precedence,
+// and then from the defaults. The .env file is ENV_FILE or -env-file and the
+// YAML file, whose keys are the env names, is CONFIG_FILE or -config.
+func Load(cfg any, name string, args []string, lookupEnv func(string) (string, bool)) error {
+	all := fields(reflect.ValueOf(cfg).Elem(), "")
+
+	fset := flag.NewFlagSet(name, flag.ContinueOnError)
+	fset.SetOutput(os.Stderr)
+
+	envFile := fset.String("env-file", "", "path of the .env file, ENV_FILE")
+	configFile := fset.String("config", "", "path of a YAML file with the configuration, CONFIG_FILE")
+
+	flags := make(map[string]*flagValue, len(all))
+	for _, s := range all {
+		flags[s.name] = &flagValue{isBool: s.value.Kind() == reflect.Bool}
+		fset.Var(flags[s.name], flagName(s.name), s.usage+", "+s.name)
+	}
+
+	if err := fset.Parse(args); err != nil {
+		return fmt.Errorf("failed to parse flags: %w", err)
+	}
+
+	set := map[string]bool{}
+	fset.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	fromFlags := func(name string) (string, bool) {
+		if !set[flagName(name)] {
+			return "", false
+		}
+
+		return flags[name].value, true
+	}
+
+	dotenv, err := readDotenv(first(*envFile, lookupEnv, "ENV_FILE"))
+	if err != nil {
+		return err
+	}
+
+	fromDotenv := func(name string) (string, bool) {
+		value, ok := dotenv[name]
+
+		return value, ok
+	}
+
+	yamlFile := *configFile
+	if yamlFile == "" {
+		yamlFile = first("", lookupEnv, "CONFIG_FILE")
+	}
+
+	if yamlFile == "" {
+		yamlFile = dotenv["CONFIG_FILE"]
+	}
+
+	fromYAML, err := readYAML(yamlFile)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+
+	for _, s := range all {
+		value, err := resolve(s, fromFlags, lookupEnv, fromDotenv, fromYAML)
+		if err == nil {
+			err = s.set(value)
+		}
+
+		if err == nil {
+			err = s.validate(value)
+		}
+
+		if err != nil {
+			problems = append(problems, s.name+": "+err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalid, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// first is value, or else name in lookup.
+func first(value string, lookup source, name string) string {
+	if value != "" {
+		return value
+	}
+
+	value, _ = lookup(name)
+
+	return value
+}
+
+// readDotenv reads path, or .env when it is not given and only if it exists.
+func readDotenv(path string) (map[string]string, error) {
+	optional := path == ""
+	if optional {
+		path = ".env"
+	}
+
+	values, err := godotenv.Read(path)
+	if err != nil {
+		if optional && errors.Is(err, fs.ErrNotExist) {
+			return map[string]string{}, nil
+		}
+
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+func readYAML(path string) (source, error) {
+	if path == "" {
+		return func(string) (string, bool) { return "", false }, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var values map[string]any
+
+	if err = yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return func(name string) (string, bool) {
+		value, ok := values[name]
+		if !ok || value == nil {
+			return "", false
+		}
+
+		return fmt.Sprint(value), true
+	}, nil
+}
+
+// resolve is the value of s in the first source that sets it or, for
+// secrets, sets <name>_FILE, else its default.
+func resolve(s setting, sources ...source) (string, error) {
+	for _, lookup := range sources {
+		if value, ok := lookup(s.name); ok {
+			return value, nil
+		}
+
+		if !s.secret {
+			continue
+		}
+
+		if path, ok := lookup(s.name + "_FILE"); ok {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("failed to read %s_FILE: %w", s.name, err)
+			}
+
+			return strings.TrimRight(string(data), "\r\n"), nil
+		}
+	}
+
+	return s.def, nil
+}
+
+func (s setting) set(value string) error {
+	if value == "" {
+		s.value.Set(reflect.Zero(s.value.Type()))
+
+		return nil
+	}
+
+	switch {
+	case s.value.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+
+		s.value.SetInt(int64(d))
+	case s.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+
+		s.value.SetInt(int64(n))
+	case s.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+
+		s.value.SetBool(b)
+	default:
+		s.value.SetString(value)
+	}
+
+	return nil
+}
+
+// validate checks the rules of s, but required, against set values only.
+func (s setting) validate(value string) error {
+	for _, rule := range strings.Split(s.val, ",") {
+		rule, arg, _ := strings.Cut(rule, "=")
+
+		switch {
+		case rule == "required" && value == "":
+			return errors.New("required")
+		case value == "":
+		case rule == "port":
+			if n, err := strconv.Atoi(value); err != nil || n < 1 || n > 65535 {
+				return fmt.Errorf("invalid port %q", value)
+			}
+		case rule == "positive":
+			if s.value.Int() <= 0 {
+				return fmt.Errorf("must be positive, not %q", value)
+			}
+		case rule == "oneof":
+			if !contains(strings.Fields(arg), value) {
+				return fmt.Errorf("must be one of %s, not %q", strings.Join(strings.Fields(arg), ", "), value)
+			}
+		}
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// KeyValues are the names and values of cfg to log them, the secrets that
+// are set are redacted.
+func KeyValues(cfg any) []any {
+	all := fields(reflect.ValueOf(cfg).Elem(), "")
+	keyvals := make([]any, 0, 2*len(all))
+
+	for _, s := range all {
+		value := fmt.Sprint(s.value.Interface())
+		if s.secret && value != "" {
+			value = "[redacted]"
+		}
+
+		keyvals = append(keyvals, s.name, value)
+	}
+
+	return keyvals
+}
@@ -0,0 +1,184 @@
+package settings_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/platform/settings"
+	"github.com/stretchr/testify/assert"
+)
+
+type downstream struct {
+	Host string `env:"HOST" usage:"host of the service" validate:"required"`
+	Port string `env:"PORT" default:"7070" usage:"port of the service" validate:"port"`
+}
+
+type config struct {
+	Port      string        `env:"PORT" default:"8080" usage:"port to listen on" validate:"required,port"`
+	Secret    string        `env:"SECRET" usage:"secret of the tokens" secret:"true"`
+	LogLevel  string        `env:"LOG_LEVEL" default:"info" usage:"minimum level logged" validate:"oneof=debug info"`
+	Verbose   bool          `env:"VERBOSE" usage:"log the requests"`
+	Instances int           `env:"INSTANCES" default:"1" usage:"instances to run" validate:"positive"`
+	Timeout   time.Duration `env:"TIMEOUT" default:"5s" usage:"timeout of the calls" validate:"positive"`
+	DB        downstream    `prefix:"DB_"`
+}
+
+func lookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name   string
+		inArgs []string
+		inEnv  map[string]string
+		outCfg config
+		outErr string
+	}{
+		{
+			name:  "Defaults",
+			inEnv: map[string]string{"DB_HOST": "db-app"},
+			outCfg: config{
+				Port:      "8080",
+				LogLevel:  "info",
+				Instances: 1,
+				Timeout:   5 * time.Second,
+				DB:        downstream{Host: "db-app", Port: "7070"},
+			},
+		},
+		{
+			name:   "Flags",
+			inArgs: []string{"-verbose", "-db-port", "7071", "-timeout", "1m"},
+			inEnv:  map[string]string{"DB_HOST": "db-app", "DB_PORT": "7072"},
+			outCfg: config{
+				Port:      "8080",
+				LogLevel:  "info",
+				Verbose:   true,
+				Instances: 1,
+				Timeout:   time.Minute,
+				DB:        downstream{Host: "db-app", Port: "7071"},
+			},
+		},
+		{
+			name:   "ErrorRequired",
+			outErr: "invalid configuration: DB_HOST: required",
+		},
+		{
+			name: "ErrorFormats",
+			inEnv: map[string]string{
+				"DB_HOST":   "db-app",
+				"PORT":      "70000",
+				"LOG_LEVEL": "trace",
+				"VERBOSE":   "sometimes",
+				"INSTANCES": "0",
+				"TIMEOUT":   "soon",
+			},
+			outErr: `invalid configuration: PORT: invalid port "70000"; ` +
+				`LOG_LEVEL: must be one of debug, info, not "trace"; ` +
+				`VERBOSE: invalid boolean "sometimes"; ` +
+				`INSTANCES: must be positive, not "0"; ` +
+				`TIMEOUT: invalid duration "soon"`,
+		},
+		{
+			name:   "ErrorFlag",
+			inArgs: []string{"-unknown"},
+			outErr: "failed to parse flags: flag provided but not defined: -unknown",
+		},
+		{
+			name:   "ErrorEnvFile",
+			inArgs: []string{"-env-file", "missing.env"},
+			outErr: "failed to read missing.env",
+		},
+		{
+			name:   "ErrorConfigFile",
+			inEnv:  map[string]string{"CONFIG_FILE": "missing.yaml"},
+			outErr: "failed to read missing.yaml",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var cfg config
+
+			err := settings.Load(&cfg, "app", tt.inArgs, lookup(tt.inEnv))
+
+			if tt.outErr != "" {
+				assert.ErrorContains(t, err, tt.outErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.outCfg, cfg)
+		})
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	t.Parallel()
+
+	yamlFile := writeFile(t, "config.yaml", "DB_HOST: yaml\nLOG_LEVEL: debug\nINSTANCES: 4\nPORT: 8084\n")
+	envFile := writeFile(t, ".env", "DB_HOST=dotenv\nINSTANCES=3\nPORT=8083\n")
+
+	var cfg config
+
+	err := settings.Load(
+		&cfg,
+		"app",
+		[]string{"-env-file", envFile, "-port", "8081"},
+		lookup(map[string]string{"CONFIG_FILE": yamlFile, "INSTANCES": "2", "PORT": "8082"}),
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "8081", cfg.Port)
+	assert.Equal(t, 2, cfg.Instances)
+	assert.Equal(t, "dotenv", cfg.DB.Host)
+	assert.Equal(t, "debug", cfg.LogLevel)
+}
+
+func TestLoadSecretFile(t *testing.T) {
+	t.Parallel()
+
+	secret := writeFile(t, "secret", "s3cret\n")
+
+	var cfg config
+
+	err := settings.Load(&cfg, "app", nil, lookup(map[string]string{"DB_HOST": "db-app", "SECRET_FILE": secret}))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "s3cret", cfg.Secret)
+
+	err = settings.Load(&cfg, "app", nil, lookup(map[string]string{"DB_HOST": "db-app", "SECRET_FILE": "missing"}))
+	assert.ErrorIs(t, err, settings.ErrInvalid)
+	assert.ErrorContains(t, err, "SECRET: failed to read SECRET_FILE")
+}
+
+func TestKeyValues(t *testing.T) {
+	t.Parallel()
+
+	cfg := config{Port: "8080", Secret: "s3cret", DB: downstream{Host: "db-app"}}
+
+	keyvals := settings.KeyValues(&cfg)
+
+	assert.Subset(t, keyvals, []any{"PORT", "8080", "SECRET", "[redacted]", "DB_HOST", "db-app", "VERBOSE", "false"})
+	assert.NotContains(t, keyvals, "s3cret")
+	assert.Subset(t, settings.KeyValues(&config{}), []any{"SECRET", ""})
+}
@@ -0,0 +1,147 @@
+// Package tracing is how the services trace: the export of the spans, the
+// propagation of the trace context and the server span of every request. The
+// spans of the calls a service makes, to other services, Redis or the
+// database, stay in the service.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/cfabrica46/gokit-crud/platform/tracing"
+
+// NewTracerProvider exports the spans of serviceName to w, one JSON document
+// per span.
+func NewTracerProvider(w io.Writer, serviceName string) (*sdktrace.TracerProvider, error) {
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(w))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(serviceName),
+		)),
+	), nil
+}
+
+// Setup exports the spans to output, "stdout" or the path of a file, tracing
+// is off without it. The trace context of the requests is propagated either
+// way. The returned func flushes the spans and closes the file, it waits up
+// to timeout for the spans to be exported.
+func Setup(logger log.Logger, serviceName, output string) (stop func(timeout time.Duration)) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	stop = func(time.Duration) {}
+
+	if output == "" {
+		return stop
+	}
+
+	w := io.Writer(os.Stdout)
+
+	var file *os.File
+
+	if output != "stdout" {
+		var err error
+
+		file, err = os.OpenFile(output, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			_ = level.Error(logger).Log("err", err)
+
+			return stop
+		}
+
+		w = file
+	}
+
+	provider, err := NewTracerProvider(w, serviceName)
+	if err != nil {
+		_ = level.Error(logger).Log("err", err)
+
+		if file != nil {
+			_ = file.Close()
+		}
+
+		return stop
+	}
+
+	otel.SetTracerProvider(provider)
+
+	return func(timeout time.Duration) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		if err := provider.Shutdown(ctx); err != nil {
+			_ = level.Error(logger).Log("msg", "failed to flush the spans", "err", err)
+		}
+
+		if file != nil {
+			if err := file.Close(); err != nil {
+				_ = level.Error(logger).Log("err", err)
+			}
+		}
+	}
+}
+
+// ServerTracing starts a server span for every request, continuing the trace
+// of its traceparent header, and ends it with the status of the response.
+func ServerTracing() []httptransport.ServerOption {
+	return []httptransport.ServerOption{
+		httptransport.ServerBefore(startServerSpan),
+		httptransport.ServerFinalizer(endServerSpan),
+	}
+}
+
+func startServerSpan(ctx context.Context, r *http.Request) context.Context {
+	route := r.URL.Path
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			route = template
+		}
+	}
+
+	ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(r.Header))
+	ctx, _ = otel.Tracer(instrumentationName).Start(
+		ctx,
+		r.Method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(r.Method),
+			semconv.HTTPRouteKey.String(route),
+		),
+	)
+
+	return ctx
+}
+
+func endServerSpan(ctx context.Context, code int, _ *http.Request) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(semconv.HTTPStatusCodeKey.Int(code))
+
+	if code >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(code))
+	}
+
+	span.End()
+}
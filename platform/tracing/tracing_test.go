@@ -0,0 +1,158 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/platform/tracing"
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	recorderOnce sync.Once
+	recorder     *tracetest.SpanRecorder
+)
+
+// spanRecorder installs, once for every test, a global tracer provider that
+// records the spans.
+func spanRecorder() *tracetest.SpanRecorder {
+	recorderOnce.Do(func() {
+		recorder = tracetest.NewSpanRecorder()
+
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+
+	return recorder
+}
+
+// endedSpans are the ended spans of the trace of traceID.
+func endedSpans(traceID trace.TraceID) map[string]sdktrace.ReadOnlySpan {
+	spans := map[string]sdktrace.ReadOnlySpan{}
+
+	for _, span := range spanRecorder().Ended() {
+		if span.SpanContext().TraceID() == traceID {
+			spans[span.Name()] = span
+		}
+	}
+
+	return spans
+}
+
+func TestNewTracerProvider(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	provider, err := tracing.NewTracerProvider(&buf, "app")
+	assert.NoError(t, err)
+
+	_, span := provider.Tracer("test").Start(context.TODO(), "span")
+	span.End()
+
+	assert.NoError(t, provider.Shutdown(context.TODO()))
+	assert.Contains(t, buf.String(), `"Name":"span"`)
+	assert.Contains(t, buf.String(), `"Value":"app"`)
+}
+
+// TestSetup isn't parallel, it replaces the global tracer provider before
+// the parallel tests install the recorder.
+func TestSetup(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "traces.json")
+
+	stop := tracing.Setup(log.NewNopLogger(), "app", output)
+
+	_, span := otel.Tracer("test").Start(context.TODO(), "span")
+	span.End()
+
+	stop(time.Second)
+
+	traces, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Contains(t, string(traces), `"Name":"span"`)
+
+	// without output the spans aren't exported, the failures are logged.
+	tracing.Setup(log.NewNopLogger(), "app", "")(time.Second)
+	tracing.Setup(log.NewNopLogger(), "app", filepath.Join(output, "missing", "traces.json"))(time.Second)
+}
+
+func TestServerTracing(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name      string
+		inTraceID string
+		inStatus  int
+		outCode   codes.Code
+	}{
+		{
+			name:      "NoError",
+			inTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			inStatus:  http.StatusOK,
+			outCode:   codes.Unset,
+		},
+		{
+			name:      "ErrorInternal",
+			inTraceID: "4bf92f3577b34da6a3ce929d0e0e4737",
+			inStatus:  http.StatusInternalServerError,
+			outCode:   codes.Error,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			spanRecorder()
+
+			handler := httptransport.NewServer(
+				endpoint.Nop,
+				func(context.Context, *http.Request) (any, error) { return nil, nil },
+				func(_ context.Context, w http.ResponseWriter, _ any) error {
+					w.WriteHeader(tt.inStatus)
+
+					return nil
+				},
+				tracing.ServerTracing()...,
+			)
+
+			router := mux.NewRouter()
+			router.Methods(http.MethodGet).Path("/user/{id}").Handler(handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/user/1", nil)
+			req.Header.Set("traceparent", "00-"+tt.inTraceID+"-00f067aa0ba902b7-01")
+
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			traceID, err := trace.TraceIDFromHex(tt.inTraceID)
+			assert.NoError(t, err)
+
+			span, ok := endedSpans(traceID)["GET /user/{id}"]
+			if !assert.True(t, ok) {
+				return
+			}
+
+			assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+			assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+			assert.Contains(t, span.Attributes(), semconv.HTTPStatusCodeKey.Int(tt.inStatus))
+			assert.Equal(t, tt.outCode, span.Status().Code)
+		})
+	}
+}
@@ -13,13 +13,41 @@ token=$(echo "$response" | jq -r '.token')
 
 # echo "$token"
 
-#ShowUsers
-# curl -X GET -Lk http://localhost:8080/users
+#ShowUsers (admin only)
+# curl -X GET -Lk http://localhost:8080/users -H "Authorization: $token"
+# curl -X GET -Lk "http://localhost:8080/users?fields=id,username" -H "Authorization: $token"
+# curl -X GET -Lk "http://localhost:8080/users?limit=10&username=ce&sort=desc" -H "Authorization: $token"
+# curl -X GET -Lk "http://localhost:8080/users?limit=10&cursor=<next>" -H "Authorization: $token"
+
+#DeleteUser (admin only)
+# curl -X DELETE -Lk http://localhost:8080/users -H "Authorization: $token" -d '{"id":2}'
+
+#SuspendUser (admin only)
+# curl -X PATCH -Lk http://localhost:8080/users/suspended -H "Authorization: $token" -d '{"id":2,"suspended":true}'
 
 #Profile
 # curl -X POST -Lk http://localhost:8080/profile -d {"token":"${token}"}
 curl -X POST -k http://localhost:8080/profile -H "Authorization: $token"
+# curl -X POST -k "http://localhost:8080/profile?fields=id,email" -H "Authorization: $token"
+
+#UpdateProfile
+# curl -X PUT -k http://localhost:8080/profile -H "Authorization: $token" -d '{"username":"cesar2","email":"cesar2@gmail.com"}'
+
+#ChangePassword
+# curl -X POST -k http://localhost:8080/profile/password -H "Authorization: $token" -d '{"oldPassword":"01234","newPassword":"56789"}'
+
+#ForgotPassword (the reset token is written to app's mail outbox)
+# curl -X POST -k http://localhost:8080/password/forgot -d '{"email":"cfabrica46@gmail.com"}'
+
+#ResetPassword
+# curl -X POST -k http://localhost:8080/password/reset -d '{"token":"reset-token","password":"56789"}'
 
 #Delete
 # curl -X DELETE -k http://localhost:8080/profile -d "{'token':$(token)}"
 # curl -X DELETE -Lk http://localhost:8080/profile -H "Authorization: $token"
+
+#VerifyEmail (the verification token is written to app's mail outbox)
+# curl -X GET -k "http://localhost:8080/verify-email?token=verification-token"
+
+#RefreshToken (rotates the refresh token returned by signup/signin)
+# curl -X POST -k http://localhost:8080/token/refresh -d '{"refreshToken":"refresh-token"}'
@@ -0,0 +1,48 @@
+// Package config is the configuration of token-app.
+package config
+
+import (
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/platform/settings"
+)
+
+// Config is read by Load, see settings.Load for the sources and the tags.
+type Config struct {
+	Port          string `env:"PORT" default:"9090" usage:"port to listen on" validate:"required,port"`
+	RedisHost     string `env:"REDIS_HOST" usage:"host of Redis" validate:"required"`
+	RedisPort     string `env:"REDIS_PORT" default:"6379" usage:"port of Redis" validate:"required,port"`
+	RedisPassword string `env:"REDIS_PASSWORD" usage:"password of Redis" secret:"true"`
+	TraceFile     string `env:"TRACE_FILE" usage:"where the spans are exported: stdout or a path, off if empty"`
+	LogLevel      string `env:"LOG_LEVEL" default:"info" usage:"minimum level logged" validate:"oneof=debug info warn error"`
+	LogFormat     string `env:"LOG_FORMAT" default:"logfmt" usage:"format of the logs" validate:"oneof=logfmt json"`
+
+	SignInMaxFailures int           `env:"SIGNIN_MAX_FAILURES" default:"5" usage:"failed sign ins before the lockout" validate:"positive"`
+	SignInLockout     time.Duration `env:"SIGNIN_LOCKOUT" default:"15m" usage:"lockout after too many failed sign ins" validate:"positive"`
+	ShutdownTimeout   time.Duration `env:"SHUTDOWN_TIMEOUT" default:"15s" usage:"wait for the requests in flight on shutdown" validate:"positive"`
+	DrainDelay        time.Duration `env:"DRAIN_DELAY" default:"5s" usage:"wait after failing the readiness probe before shutting down"`
+}
+
+// Load reads the configuration from the flags in args, the environment
+// through lookupEnv, the .env file and the YAML file, it fails when a value
+// is missing or malformed.
+func Load(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := &Config{}
+
+	if err := settings.Load(cfg, "token-app", args, lookupEnv); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// KeyValues is the effective configuration with the secrets redacted, to be
+// logged.
+func (c *Config) KeyValues() []any {
+	return settings.KeyValues(c)
+}
+
+// RedisAddr is the host:port of Redis.
+func (c *Config) RedisAddr() string {
+	return c.RedisHost + ":" + c.RedisPort
+}
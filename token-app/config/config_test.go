@@ -0,0 +1,110 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/token-app/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func lookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+
+		return value, ok
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	password := filepath.Join(t.TempDir(), "password")
+	assert.NoError(t, os.WriteFile(password, []byte("s3cret\n"), 0o600))
+
+	yamlFile := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(yamlFile, []byte("REDIS_HOST: redis\nSIGNIN_LOCKOUT: 1h\n"), 0o600))
+
+	for _, tt := range []struct {
+		name   string
+		inArgs []string
+		inEnv  map[string]string
+		outCfg *config.Config
+		outErr string
+	}{
+		{
+			name:  "Defaults",
+			inEnv: map[string]string{"REDIS_HOST": "redis"},
+			outCfg: &config.Config{
+				Port:              "9090",
+				RedisHost:         "redis",
+				RedisPort:         "6379",
+				LogLevel:          "info",
+				LogFormat:         "logfmt",
+				SignInMaxFailures: 5,
+				SignInLockout:     15 * time.Minute,
+				ShutdownTimeout:   15 * time.Second,
+				DrainDelay:        5 * time.Second,
+			},
+		},
+		{
+			name:   "YAMLAndSecretFile",
+			inArgs: []string{"-config", yamlFile, "-signin-max-failures", "3"},
+			inEnv:  map[string]string{"REDIS_PASSWORD_FILE": password, "LOG_FORMAT": "json"},
+			outCfg: &config.Config{
+				Port:              "9090",
+				RedisHost:         "redis",
+				RedisPort:         "6379",
+				RedisPassword:     "s3cret",
+				LogLevel:          "info",
+				LogFormat:         "json",
+				SignInMaxFailures: 3,
+				SignInLockout:     time.Hour,
+				ShutdownTimeout:   15 * time.Second,
+				DrainDelay:        5 * time.Second,
+			},
+		},
+		{
+			name:   "ErrorRequired",
+			inEnv:  map[string]string{"REDIS_PORT": ""},
+			outErr: "invalid configuration: REDIS_HOST: required; REDIS_PORT: required",
+		},
+		{
+			name:   "ErrorFormats",
+			inEnv:  map[string]string{"REDIS_HOST": "redis", "SIGNIN_MAX_FAILURES": "0", "LOG_FORMAT": "xml"},
+			outErr: `invalid configuration: LOG_FORMAT: must be one of logfmt, json, not "xml"; SIGNIN_MAX_FAILURES: must be positive, not "0"`,
+		},
+		{
+			name:   "ErrorSecretFile",
+			inEnv:  map[string]string{"REDIS_HOST": "redis", "REDIS_PASSWORD_FILE": "missing"},
+			outErr: "invalid configuration: REDIS_PASSWORD: failed to read REDIS_PASSWORD_FILE",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, err := config.Load(tt.inArgs, lookup(tt.inEnv))
+
+			if tt.outErr != "" {
+				assert.ErrorContains(t, err, tt.outErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.outCfg, cfg)
+		})
+	}
+}
+
+func TestKeyValues(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{RedisHost: "redis", RedisPassword: "s3cret"}
+
+	assert.Subset(t, cfg.KeyValues(), []any{"REDIS_HOST", "redis", "REDIS_PASSWORD", "[redacted]", "SIGNIN_LOCKOUT", "0s"})
+	assert.NotContains(t, cfg.KeyValues(), "s3cret")
+}
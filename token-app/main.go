@@ -1,72 +1,249 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/cfabrica46/gokit-crud/platform/health"
+	"github.com/cfabrica46/gokit-crud/platform/logging"
+	"github.com/cfabrica46/gokit-crud/platform/metrics"
+	"github.com/cfabrica46/gokit-crud/platform/tracing"
+	"github.com/cfabrica46/gokit-crud/token-app/config"
 	"github.com/cfabrica46/gokit-crud/token-app/service"
+	"github.com/go-kit/kit/endpoint"
 	httptransport "github.com/go-kit/kit/transport/http"
-	"github.com/go-redis/redis"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/go-redis/redis/v8"
 	"github.com/gorilla/mux"
-	"github.com/joho/godotenv"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 func main() {
-	if err := godotenv.Load(".env"); err != nil {
-		log.Println(err)
+	cfg, err := config.Load(os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		return
 	}
 
+	logger := logging.NewLogger(os.Stderr, cfg.LogFormat, cfg.LogLevel)
+	logger = log.With(logger, "service", "token-app")
+
+	if err != nil {
+		_ = level.Error(logger).Log("err", err)
+		os.Exit(1)
+	}
+
+	_ = level.Info(logger).Log(append([]any{"msg", "configuration"}, cfg.KeyValues()...)...)
+
+	stopTracing := tracing.Setup(logger, "token-app", cfg.TraceFile)
+	defer stopTracing(cfg.ShutdownTimeout)
+
 	options := &redis.Options{
-		Addr:     os.Getenv("REDIS_HOST") + ":" + os.Getenv("REDIS_PORT"),
-		Password: "",
+		Addr:     cfg.RedisAddr(),
+		Password: cfg.RedisPassword,
 		DB:       0,
 	}
 	db := redis.NewClient(options)
+	db.AddHook(service.RedisTracingHook{})
+	db.AddHook(service.NewRedisMetricsHook(prometheus.DefaultRegisterer))
+
+	runServer(logger, cfg, db)
+}
 
-	runServer(os.Getenv("PORT"), db)
+// signInLimits are the default limits with the lockout of the
+// configuration.
+func signInLimits(cfg *config.Config) service.SignInLimits {
+	limits := service.DefaultSignInLimits()
+	limits.MaxFailures = cfg.SignInMaxFailures
+	limits.Lockout = cfg.SignInLockout
+
+	return limits
 }
 
-func runServer(port string, db *redis.Client) {
+// runServer serves until SIGINT or SIGTERM, then it stops being ready, waits
+// DRAIN_DELAY for the traffic to move away and up to SHUTDOWN_TIMEOUT for the
+// requests in flight.
+func runServer(logger log.Logger, cfg *config.Config, db *redis.Client) {
 	svc := service.GetService(db)
+	svc.Limits = signInLimits(cfg)
+
+	readiness := health.NewReadiness(logger, time.Second, map[string]health.Check{
+		"redis": func(ctx context.Context) error {
+			return db.Ping(ctx).Err()
+		},
+	})
+
+	r := newRouter(logger, svc, readiness, prometheus.DefaultRegisterer)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: r, ReadHeaderTimeout: 10 * time.Second}
+
+	_ = level.Info(logger).Log("msg", "listening", "addr", srv.Addr)
+
+	if err := health.Serve(ctx, srv, readiness, cfg.DrainDelay, cfg.ShutdownTimeout); err != nil {
+		_ = level.Error(logger).Log("err", err)
+
+		return
+	}
+
+	_ = level.Info(logger).Log("msg", "stopped")
+}
+
+// newRouter routes the endpoints of svc.
+func newRouter(
+	logger log.Logger,
+	svc *service.Service,
+	readiness *health.Readiness,
+	reg prometheus.Registerer,
+) *mux.Router {
+	options := []httptransport.ServerOption{
+		httptransport.ServerErrorEncoder(service.EncodeError),
+	}
+	options = append(options, tracing.ServerTracing()...)
+	options = append(options, logging.ServerOptions(logger, logging.UserIDFromHeader)...)
+
+	endpointMetrics := metrics.NewEndpointMetrics(reg, service.MetricsNamespace)
+	instrument := func(method string) endpoint.Middleware {
+		return endpoint.Chain(
+			service.LoggingMiddleware(logger, method),
+			metrics.InstrumentingMiddleware(endpointMetrics, method),
+		)
+	}
 
 	getGenerateTokenHandler := httptransport.NewServer(
-		service.MakeGenerateTokenEndpoint(svc),
+		instrument("GenerateToken")(service.MakeGenerateTokenEndpoint(svc)),
 		service.DecodeRequest(service.IDUsernameEmailSecretRequest{}),
 		service.EncodeResponse,
+		options...,
 	)
 
 	getExtractTokenHandler := httptransport.NewServer(
-		service.MakeExtractTokenEndpoint(svc),
+		instrument("ExtractToken")(service.MakeExtractTokenEndpoint(svc)),
 		service.DecodeRequest(service.TokenSecretRequest{}),
 		service.EncodeResponse,
+		options...,
 	)
 
 	getSetTokenHandler := httptransport.NewServer(
-		service.MakeManageTokenEndpoint(svc, service.NewSetTokenState()),
+		instrument("SetToken")(service.MakeManageTokenEndpoint(svc, service.NewSetTokenState())),
 		service.DecodeRequest(service.Token{}),
 		service.EncodeResponse,
+		options...,
 	)
 
 	getDeleteTokenHandler := httptransport.NewServer(
-		service.MakeManageTokenEndpoint(svc, service.NewDeleteTokenState()),
+		instrument("DeleteToken")(service.MakeManageTokenEndpoint(svc, service.NewDeleteTokenState())),
 		service.DecodeRequest(service.Token{}),
 		service.EncodeResponse,
+		options...,
 	)
 
 	getCheckTokenHandler := httptransport.NewServer(
-		service.MakeCheckTokenEndpoint(svc),
+		instrument("CheckToken")(service.MakeCheckTokenEndpoint(svc)),
 		service.DecodeRequest(service.Token{}),
 		service.EncodeResponse,
+		options...,
+	)
+
+	getRevokeUserTokensHandler := httptransport.NewServer(
+		instrument("RevokeUserTokens")(service.MakeRevokeUserTokensEndpoint(svc)),
+		service.DecodeRequest(service.IDRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	getRevokeSessionHandler := httptransport.NewServer(
+		instrument("RevokeSession")(service.MakeRevokeSessionEndpoint(svc)),
+		service.DecodeRequest(service.TokenSecretRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	getGenerateOneTimeTokenHandler := httptransport.NewServer(
+		instrument("GenerateOneTimeToken")(service.MakeGenerateOneTimeTokenEndpoint(svc)),
+		service.DecodeRequest(service.IDEmailPurposeRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	getConsumeOneTimeTokenHandler := httptransport.NewServer(
+		instrument("ConsumeOneTimeToken")(service.MakeConsumeOneTimeTokenEndpoint(svc)),
+		service.DecodeRequest(service.TokenPurposeRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	getRefreshTokenHandler := httptransport.NewServer(
+		instrument("RefreshToken")(service.MakeRefreshTokenEndpoint(svc)),
+		service.DecodeRequest(service.RefreshTokenSecretRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	getAllowSignInHandler := httptransport.NewServer(
+		instrument("AllowSignIn")(service.MakeAllowSignInEndpoint(svc)),
+		service.DecodeRequest(service.IPUsernameRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	getRecordSignInHandler := httptransport.NewServer(
+		instrument("RecordSignIn")(service.MakeRecordSignInEndpoint(svc)),
+		service.DecodeRequest(service.UsernameSuccessRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	getReserveIdempotencyKeyHandler := httptransport.NewServer(
+		instrument("ReserveIdempotencyKey")(service.MakeReserveIdempotencyKeyEndpoint(svc)),
+		service.DecodeRequest(service.IdempotencyRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	getSaveIdempotentResponseHandler := httptransport.NewServer(
+		instrument("SaveIdempotentResponse")(service.MakeSaveIdempotentResponseEndpoint(svc)),
+		service.DecodeRequest(service.IdempotencyRequest{}),
+		service.EncodeResponse,
+		options...,
+	)
+
+	getReleaseIdempotencyKeyHandler := httptransport.NewServer(
+		instrument("ReleaseIdempotencyKey")(service.MakeReleaseIdempotencyKeyEndpoint(svc)),
+		service.DecodeRequest(service.IdempotencyRequest{}),
+		service.EncodeResponse,
+		options...,
 	)
 
 	r := mux.NewRouter()
+	r.Methods(http.MethodGet).Path("/healthz").Handler(health.LivenessHandler())
+	r.Methods(http.MethodGet).Path("/readyz").Handler(readiness)
+	r.Methods(http.MethodGet).Path("/metrics").Handler(promhttp.Handler())
+	r.Methods(http.MethodGet).Path("/openapi.json").Handler(service.OpenAPIHandler())
 	r.Methods(http.MethodPost).Path("/generate").Handler(getGenerateTokenHandler)
 	r.Methods(http.MethodPost).Path("/extract").Handler(getExtractTokenHandler)
 	r.Methods(http.MethodPost).Path("/token").Handler(getSetTokenHandler)
 	r.Methods(http.MethodDelete).Path("/token").Handler(getDeleteTokenHandler)
 	r.Methods(http.MethodPost).Path("/check").Handler(getCheckTokenHandler)
+	r.Methods(http.MethodDelete).Path("/tokens").Handler(getRevokeUserTokensHandler)
+	r.Methods(http.MethodDelete).Path("/session").Handler(getRevokeSessionHandler)
+	r.Methods(http.MethodPost).Path("/onetime").Handler(getGenerateOneTimeTokenHandler)
+	r.Methods(http.MethodPost).Path("/onetime/consume").Handler(getConsumeOneTimeTokenHandler)
+	r.Methods(http.MethodPost).Path("/refresh").Handler(getRefreshTokenHandler)
+	r.Methods(http.MethodPost).Path("/signin/allow").Handler(getAllowSignInHandler)
+	r.Methods(http.MethodPost).Path("/signin/result").Handler(getRecordSignInHandler)
+	r.Methods(http.MethodPost).Path("/idempotency").Handler(getReserveIdempotencyKeyHandler)
+	r.Methods(http.MethodPut).Path("/idempotency").Handler(getSaveIdempotentResponseHandler)
+	r.Methods(http.MethodDelete).Path("/idempotency").Handler(getReleaseIdempotencyKeyHandler)
 
-	log.Println("ListenAndServe on localhost:" + os.Getenv("PORT"))
-	log.Println(http.ListenAndServe(":"+port, r))
+	return r
 }
@@ -12,71 +12,240 @@ var ErrRequest = errors.New("error to request")
 
 // MakeGenerateTokenEndpoint ...
 func MakeGenerateTokenEndpoint(svc serviceInterface) endpoint.Endpoint {
-	return func(_ context.Context, request any) (any, error) {
+	return func(ctx context.Context, request any) (any, error) {
 		req, ok := request.(IDUsernameEmailSecretRequest)
 		if !ok {
 			return nil, fmt.Errorf("%w: isn't of type GenerateTokenRequest", ErrRequest)
 		}
 
-		token := svc.GenerateToken(req.ID, req.Username, req.Email, []byte(req.Secret))
+		refreshToken, family, err := svc.GenerateRefreshToken(ctx, req.ID, req.Username, req.Email, req.Role)
+		if err != nil {
+			return nil, err
+		}
+
+		token := svc.GenerateToken(ctx, req.ID, req.Username, req.Email, req.Role, family, []byte(req.Secret))
 
-		return Token{Token: token}, nil
+		return TokenRefreshErrResponse{Token: token, RefreshToken: refreshToken}, nil
 	}
 }
 
 // MakeExtractTokenEndpoint ...
 func MakeExtractTokenEndpoint(svc serviceInterface) endpoint.Endpoint {
-	return func(_ context.Context, request any) (any, error) {
-		var errMessage string
-
+	return func(ctx context.Context, request any) (any, error) {
 		req, ok := request.(TokenSecretRequest)
 		if !ok {
 			return nil, fmt.Errorf("%w: isn't of type GenerateTokenRequest", ErrRequest)
 		}
 
-		id, username, email, err := svc.ExtractToken(req.Token, []byte(req.Secret))
+		id, username, email, role, err := svc.ExtractToken(ctx, req.Token, []byte(req.Secret))
 		if err != nil {
-			errMessage = err.Error()
+			return nil, err
 		}
 
-		return IDUsernameEmailErrResponse{ID: id, Username: username, Email: email, Err: errMessage}, nil
+		return IDUsernameEmailErrResponse{
+			ID:       id,
+			Username: username,
+			Email:    email,
+			Role:     role,
+		}, nil
 	}
 }
 
 // MakeManageTokenEndpoint ...
 func MakeManageTokenEndpoint(svc serviceInterface, st State) endpoint.Endpoint {
-	return func(_ context.Context, request any) (any, error) {
-		var errMessage string
-
+	return func(ctx context.Context, request any) (any, error) {
 		req, ok := request.(Token)
 		if !ok {
 			return nil, fmt.Errorf("%w: isn't of type Token", ErrRequest)
 		}
 
-		err := svc.ManageToken(st, req.Token)
+		err := svc.ManageToken(ctx, st, req.Token)
 		if err != nil {
-			errMessage = err.Error()
+			return nil, err
 		}
 
-		return ErrorResponse{Err: errMessage}, nil
+		return ErrorResponse{}, nil
 	}
 }
 
 // MakeCheckTokenEndpoint ...
 func MakeCheckTokenEndpoint(svc serviceInterface) endpoint.Endpoint {
-	return func(_ context.Context, request any) (any, error) {
-		var errMessage string
-
+	return func(ctx context.Context, request any) (any, error) {
 		req, ok := request.(Token)
 		if !ok {
 			return nil, fmt.Errorf("%w: isn't of type Token", ErrRequest)
 		}
 
-		check, err := svc.CheckToken(req.Token)
+		check, err := svc.CheckToken(ctx, req.Token)
+		if err != nil {
+			return nil, err
+		}
+
+		return CheckErrResponse{Check: check}, nil
+	}
+}
+
+// MakeRevokeUserTokensEndpoint ...
+func MakeRevokeUserTokensEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(IDRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type IDRequest", ErrRequest)
+		}
+
+		err := svc.RevokeUserTokens(ctx, req.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return ErrorResponse{}, nil
+	}
+}
+
+// MakeRevokeSessionEndpoint ...
+func MakeRevokeSessionEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(TokenSecretRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type TokenSecretRequest", ErrRequest)
+		}
+
+		if err := svc.RevokeSession(ctx, req.Token, []byte(req.Secret)); err != nil {
+			return nil, err
+		}
+
+		return ErrorResponse{}, nil
+	}
+}
+
+// MakeGenerateOneTimeTokenEndpoint ...
+func MakeGenerateOneTimeTokenEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(IDEmailPurposeRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type IDEmailPurposeRequest", ErrRequest)
+		}
+
+		token, err := svc.GenerateOneTimeToken(ctx, req.ID, req.Email, req.Purpose)
+		if err != nil {
+			return nil, err
+		}
+
+		return TokenErrResponse{Token: token}, nil
+	}
+}
+
+// MakeConsumeOneTimeTokenEndpoint ...
+func MakeConsumeOneTimeTokenEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(TokenPurposeRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type TokenPurposeRequest", ErrRequest)
+		}
+
+		id, email, err := svc.ConsumeOneTimeToken(ctx, req.Token, req.Purpose)
+		if err != nil {
+			return nil, err
+		}
+
+		return IDEmailErrResponse{ID: id, Email: email}, nil
+	}
+}
+
+// MakeRefreshTokenEndpoint ...
+func MakeRefreshTokenEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(RefreshTokenSecretRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type RefreshTokenSecretRequest", ErrRequest)
+		}
+
+		token, refreshToken, err := svc.RefreshToken(ctx, req.RefreshToken, []byte(req.Secret))
+		if err != nil {
+			return nil, err
+		}
+
+		return TokenRefreshErrResponse{Token: token, RefreshToken: refreshToken}, nil
+	}
+}
+
+// MakeAllowSignInEndpoint ...
+func MakeAllowSignInEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(IPUsernameRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type IPUsernameRequest", ErrRequest)
+		}
+
+		if err := svc.AllowSignIn(ctx, req.IP, req.Username); err != nil {
+			return nil, err
+		}
+
+		return ErrorResponse{}, nil
+	}
+}
+
+// MakeRecordSignInEndpoint ...
+func MakeRecordSignInEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(UsernameSuccessRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type UsernameSuccessRequest", ErrRequest)
+		}
+
+		if err := svc.RecordSignIn(ctx, req.Username, req.Success); err != nil {
+			return nil, err
+		}
+
+		return ErrorResponse{}, nil
+	}
+}
+
+// MakeReserveIdempotencyKeyEndpoint ...
+func MakeReserveIdempotencyKeyEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(IdempotencyRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type IdempotencyRequest", ErrRequest)
+		}
+
+		record, reserved, err := svc.ReserveIdempotencyKey(ctx, req.Key, req.Record, req.TTL)
 		if err != nil {
-			errMessage = err.Error()
+			return nil, err
+		}
+
+		return IdempotencyErrResponse{Record: record, Reserved: reserved}, nil
+	}
+}
+
+// MakeSaveIdempotentResponseEndpoint ...
+func MakeSaveIdempotentResponseEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(IdempotencyRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type IdempotencyRequest", ErrRequest)
+		}
+
+		if err := svc.SaveIdempotentResponse(ctx, req.Key, req.Record, req.TTL); err != nil {
+			return nil, err
+		}
+
+		return ErrorResponse{}, nil
+	}
+}
+
+// MakeReleaseIdempotencyKeyEndpoint ...
+func MakeReleaseIdempotencyKeyEndpoint(svc serviceInterface) endpoint.Endpoint {
+	return func(ctx context.Context, request any) (any, error) {
+		req, ok := request.(IdempotencyRequest)
+		if !ok {
+			return nil, fmt.Errorf("%w: isn't of type IdempotencyRequest", ErrRequest)
+		}
+
+		if err := svc.ReleaseIdempotencyKey(ctx, req.Key); err != nil {
+			return nil, err
 		}
 
-		return CheckErrResponse{Check: check, Err: errMessage}, nil
+		return ErrorResponse{}, nil
 	}
 }
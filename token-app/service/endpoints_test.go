@@ -6,7 +6,7 @@ import (
 
 	"github.com/alicebob/miniredis"
 	"github.com/cfabrica46/gokit-crud/token-app/service"
-	"github.com/go-redis/redis"
+	"github.com/go-redis/redis/v8"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -30,6 +30,7 @@ func TestMakeGenerateTokenEndpoint(t *testing.T) {
 				ID:       idTest,
 				Username: usernameTest,
 				Email:    emailTest,
+				Role:     roleTest,
 				Secret:   secretTest,
 			},
 			outErr: "",
@@ -62,16 +63,15 @@ func TestMakeGenerateTokenEndpoint(t *testing.T) {
 				resultErr = err.Error()
 			}
 
-			result, ok := r.(service.Token)
-			if !ok {
-				if tt.name != nameErrorRequest {
-					assert.Fail(t, "response is not of the type indicated")
-				}
+			result, ok := r.(service.TokenRefreshErrResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
 			}
 
 			if tt.name == nameNoError {
 				assert.Empty(t, resultErr)
 				assert.NotEmpty(t, result.Token)
+				assert.NotEmpty(t, result.RefreshToken)
 			} else {
 				assert.Contains(t, resultErr, tt.outErr)
 				assert.Empty(t, result.Token)
@@ -144,17 +144,13 @@ func TestMakeExtractTokenEndpoint(t *testing.T) {
 				resultErr = err.Error()
 			}
 
-			result, ok := r.(service.IDUsernameEmailErrResponse)
-			if !ok {
-				if tt.name != nameErrorRequest {
-					assert.Fail(t, "response is not of the type indicated")
-				}
-			} else {
-				resultErr = result.Err
+			_, ok := r.(service.IDUsernameEmailErrResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
 			}
 
 			if tt.name == nameNoError {
-				assert.Empty(t, result.Err)
+				assert.Empty(t, resultErr)
 			} else {
 				assert.Contains(t, resultErr, tt.outErr)
 			}
@@ -215,17 +211,13 @@ func TestMakeManageTokenEndpoint(t *testing.T) {
 				resultErr = err.Error()
 			}
 
-			result, ok := r.(service.ErrorResponse)
-			if !ok {
-				if tt.name != nameErrorRequest {
-					assert.Fail(t, "response is not of the type indicated")
-				}
-			} else {
-				resultErr = result.Err
+			_, ok := r.(service.ErrorResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
 			}
 
 			if tt.name == nameNoError {
-				assert.Empty(t, result.Err)
+				assert.Empty(t, resultErr)
 			} else {
 				assert.Contains(t, resultErr, tt.outErr)
 			}
@@ -283,17 +275,490 @@ func TestMakeCheckTokenEndpoint(t *testing.T) {
 				resultErr = err.Error()
 			}
 
-			result, ok := r.(service.CheckErrResponse)
-			if !ok {
-				if tt.name != nameErrorRequest {
-					assert.Fail(t, "response is not of the type indicated")
-				}
+			_, ok := r.(service.CheckErrResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestMakeRevokeUserTokensEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		in     any
+		name   string
+		outErr string
+	}{
+		{
+			name:   nameNoError,
+			in:     service.IDRequest{ID: idTest},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:   nameErrorRedisClose,
+			in:     service.IDRequest{ID: idTest},
+			outErr: errRedisClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+
+			if tt.name == nameErrorRedisClose {
+				svc.DB.Close()
+			}
+
+			r, err := service.MakeRevokeUserTokensEndpoint(svc)(context.TODO(), tt.in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			_, ok := r.(service.ErrorResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestMakeRevokeSessionEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		in     any
+		name   string
+		outErr string
+	}{
+		{
+			name:   nameNoError,
+			in:     service.TokenSecretRequest{Secret: secretTest},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:   nameErrorRedisClose,
+			in:     service.TokenSecretRequest{Secret: secretTest},
+			outErr: errRedisClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+
+			in := tt.in
+
+			if req, ok := in.(service.TokenSecretRequest); ok {
+				req.Token = svc.GenerateToken(context.TODO(), idTest, usernameTest, emailTest, roleTest, "family", []byte(secretTest))
+
+				in = req
+			}
+
+			if tt.name == nameErrorRedisClose {
+				svc.DB.Close()
+			}
+
+			r, err := service.MakeRevokeSessionEndpoint(svc)(context.TODO(), in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			_, ok := r.(service.ErrorResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestMakeGenerateOneTimeTokenEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		in     any
+		name   string
+		outErr string
+	}{
+		{
+			name:   nameNoError,
+			in:     service.IDEmailPurposeRequest{ID: idTest, Purpose: service.PurposePasswordReset},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:   nameErrorRedisClose,
+			in:     service.IDEmailPurposeRequest{ID: idTest, Purpose: service.PurposePasswordReset},
+			outErr: errRedisClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+
+			if tt.name == nameErrorRedisClose {
+				svc.DB.Close()
+			}
+
+			r, err := service.MakeGenerateOneTimeTokenEndpoint(svc)(context.TODO(), tt.in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			result, ok := r.(service.TokenErrResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+				assert.NotEmpty(t, result.Token)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestMakeConsumeOneTimeTokenEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		in       any
+		name     string
+		outErr   string
+		outEmail string
+		outID    int
+	}{
+		{
+			name:     nameNoError,
+			in:       service.TokenPurposeRequest{Purpose: service.PurposeEmailVerification},
+			outID:    idTest,
+			outEmail: emailTest,
+			outErr:   "",
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:   "ErrorNotValid",
+			in:     service.TokenPurposeRequest{Token: tokenTest, Purpose: service.PurposePasswordReset},
+			outErr: service.ErrOneTimeTokenNotValid.Error(),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+
+			in := tt.in
+
+			if req, ok := in.(service.TokenPurposeRequest); ok && req.Token == "" {
+				req.Token, err = svc.GenerateOneTimeToken(context.TODO(), idTest, emailTest, req.Purpose)
+				assert.Nil(t, err)
+
+				in = req
+			}
+
+			r, err := service.MakeConsumeOneTimeTokenEndpoint(svc)(context.TODO(), in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			result, ok := r.(service.IDEmailErrResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
 			} else {
-				resultErr = result.Err
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+
+			assert.Equal(t, tt.outID, result.ID)
+			assert.Equal(t, tt.outEmail, result.Email)
+		})
+	}
+}
+
+func TestMakeRefreshTokenEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		in     any
+		name   string
+		outErr string
+	}{
+		{
+			name:   nameNoError,
+			in:     service.RefreshTokenSecretRequest{Secret: secretTest},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:   "ErrorNotValid",
+			in:     service.RefreshTokenSecretRequest{RefreshToken: tokenTest, Secret: secretTest},
+			outErr: service.ErrRefreshTokenNotValid.Error(),
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+
+			in := tt.in
+
+			if req, ok := in.(service.RefreshTokenSecretRequest); ok && req.RefreshToken == "" {
+				req.RefreshToken, _, err = svc.GenerateRefreshToken(context.TODO(), idTest, usernameTest, emailTest, roleTest)
+				assert.Nil(t, err)
+
+				in = req
+			}
+
+			r, err := service.MakeRefreshTokenEndpoint(svc)(context.TODO(), in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			result, ok := r.(service.TokenRefreshErrResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
 			}
 
 			if tt.name == nameNoError {
-				assert.Empty(t, result.Err)
+				assert.Empty(t, resultErr)
+				assert.NotEmpty(t, result.Token)
+				assert.NotEmpty(t, result.RefreshToken)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+				assert.Empty(t, result.Token)
+			}
+		})
+	}
+}
+
+func TestMakeAllowSignInEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		in     any
+		name   string
+		outErr string
+	}{
+		{
+			name:   nameNoError,
+			in:     service.IPUsernameRequest{IP: ipTest, Username: usernameTest},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:   nameErrorRedisClose,
+			in:     service.IPUsernameRequest{IP: ipTest, Username: usernameTest},
+			outErr: errRedisClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+
+			if tt.name == nameErrorRedisClose {
+				svc.DB.Close()
+			}
+
+			r, err := service.MakeAllowSignInEndpoint(svc)(context.TODO(), tt.in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			_, ok := r.(service.ErrorResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
+
+func TestMakeRecordSignInEndpoint(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		in     any
+		name   string
+		outErr string
+	}{
+		{
+			name:   nameNoError,
+			in:     service.UsernameSuccessRequest{Username: usernameTest},
+			outErr: "",
+		},
+		{
+			name: nameErrorRequest,
+			in: incorrectRequest{
+				incorrect: true,
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name:   nameErrorRedisClose,
+			in:     service.UsernameSuccessRequest{Username: usernameTest},
+			outErr: errRedisClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+
+			if tt.name == nameErrorRedisClose {
+				svc.DB.Close()
+			}
+
+			r, err := service.MakeRecordSignInEndpoint(svc)(context.TODO(), tt.in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			_, ok := r.(service.ErrorResponse)
+			if !ok && err == nil {
+				assert.Fail(t, "response is not of the type indicated")
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
 			} else {
 				assert.Contains(t, resultErr, tt.outErr)
 			}
@@ -0,0 +1,63 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cfabrica46/gokit-crud/token-app/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusCode(t *testing.T) {
+	t.Parallel()
+
+	_, _, _, _, errExtract := service.GetService(nil).ExtractToken(context.TODO(), "notatoken", []byte(secretTest))
+
+	for _, tt := range []struct {
+		in   error
+		name string
+		out  int
+	}{
+		{
+			name: "Unauthorized",
+			in:   service.ErrRefreshTokenNotValid,
+			out:  http.StatusUnauthorized,
+		},
+		{
+			name: "UnauthorizedClaims",
+			in:   fmt.Errorf("%w: claims['id'] isn't of type float64", service.ErrClaims),
+			out:  http.StatusUnauthorized,
+		},
+		{
+			name: "UnauthorizedExtract",
+			in:   errExtract,
+			out:  http.StatusUnauthorized,
+		},
+		{
+			name: "Validation",
+			in:   fmt.Errorf("%w: other", service.ErrUnknownPurpose),
+			out:  http.StatusBadRequest,
+		},
+		{
+			name: "TooManyRequests",
+			in:   &service.Error{Kind: service.ErrTooManyRequests, Err: service.ErrSignInLimited, RetryAfter: time.Minute},
+			out:  http.StatusTooManyRequests,
+		},
+		{
+			name: "Internal",
+			in:   errors.New("redis: client is closed"),
+			out:  http.StatusInternalServerError,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.out, service.StatusCode(tt.in))
+		})
+	}
+}
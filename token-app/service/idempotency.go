@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// reserveScript sets KEYS[1] to ARGV[1] for ARGV[2] milliseconds unless it
+// exists, it returns "" when it was set and the value already there
+// otherwise.
+var reserveScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return ""
+end
+
+return redis.call("GET", KEYS[1])
+`)
+
+// ReserveIdempotencyKey claims key for the request with the fingerprint of
+// record, until ttl passes. When the key is already taken nothing is
+// reserved and the record stored under it is returned instead.
+func (s *Service) ReserveIdempotencyKey(
+	ctx context.Context,
+	key string,
+	record IdempotencyRecord,
+	ttl time.Duration,
+) (stored IdempotencyRecord, reserved bool, err error) {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return IdempotencyRecord{}, false, fmt.Errorf("error to reserve idempotency key: %w", err)
+	}
+
+	existing, err := reserveScript.Run(ctx, s.DB, []string{idempotencyKey(key)}, value, ttl.Milliseconds()).Text()
+	if err != nil {
+		return IdempotencyRecord{}, false, fmt.Errorf("error to reserve idempotency key: %w", err)
+	}
+
+	if existing == "" {
+		return record, true, nil
+	}
+
+	if err = json.Unmarshal([]byte(existing), &stored); err != nil {
+		return IdempotencyRecord{}, false, fmt.Errorf("error to reserve idempotency key: %w", err)
+	}
+
+	return stored, false, nil
+}
+
+// SaveIdempotentResponse stores the response of the request that reserved
+// key, it is replayed to the retries of the request until ttl passes.
+func (s *Service) SaveIdempotentResponse(ctx context.Context, key string, record IdempotencyRecord, ttl time.Duration) (err error) {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("error to save idempotent response: %w", err)
+	}
+
+	if err = s.DB.Set(ctx, idempotencyKey(key), value, ttl).Err(); err != nil {
+		return fmt.Errorf("error to save idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseIdempotencyKey frees key, so that the request can be tried again
+// when it failed without a response worth replaying.
+func (s *Service) ReleaseIdempotencyKey(ctx context.Context, key string) (err error) {
+	if err = s.DB.Del(ctx, idempotencyKey(key)).Err(); err != nil {
+		return fmt.Errorf("error to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// idempotencyKey is the redis string holding the record of an idempotency
+// key as JSON.
+func idempotencyKey(key string) string {
+	return "idempotency:" + key
+}
@@ -0,0 +1,214 @@
+package service_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/cfabrica46/gokit-crud/token-app/service"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	idempotencyKeyTest string = "key"
+	fingerprintTest    string = "fingerprint"
+)
+
+func TestReserveIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	response := service.IdempotencyRecord{
+		Fingerprint: fingerprintTest,
+		Header:      http.Header{"Content-Type": []string{"application/json"}},
+		Body:        []byte(`{"token":"token"}`),
+		Status:      http.StatusOK,
+	}
+
+	for _, tt := range []struct {
+		name        string
+		outErr      string
+		inStored    *service.IdempotencyRecord
+		outRecord   service.IdempotencyRecord
+		outReserved bool
+	}{
+		{
+			name:        nameNoError,
+			outRecord:   service.IdempotencyRecord{Fingerprint: fingerprintTest},
+			outReserved: true,
+		},
+		{
+			name:      "InProgress",
+			inStored:  &service.IdempotencyRecord{Fingerprint: "other"},
+			outRecord: service.IdempotencyRecord{Fingerprint: "other"},
+		},
+		{
+			name:      "Answered",
+			inStored:  &response,
+			outRecord: response,
+		},
+		{
+			name:   nameErrorRedisClose,
+			outErr: errRedisClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+
+			if tt.inStored != nil {
+				err = svc.SaveIdempotentResponse(context.TODO(), idempotencyKeyTest, *tt.inStored, time.Hour)
+				assert.NoError(t, err)
+			}
+
+			if tt.name == nameErrorRedisClose {
+				client.Close()
+			}
+
+			record, reserved, err := svc.ReserveIdempotencyKey(
+				context.TODO(),
+				idempotencyKeyTest,
+				service.IdempotencyRecord{Fingerprint: fingerprintTest},
+				time.Hour,
+			)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			assert.Contains(t, resultErr, tt.outErr)
+			assert.Equal(t, tt.outRecord, record)
+			assert.Equal(t, tt.outReserved, reserved)
+
+			if tt.outReserved {
+				assert.InDelta(t, time.Hour, mr.TTL("idempotency:"+idempotencyKeyTest), float64(time.Second))
+			}
+		})
+	}
+}
+
+func TestReleaseIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	svc := service.GetService(client)
+
+	record := service.IdempotencyRecord{Fingerprint: fingerprintTest}
+
+	_, reserved, err := svc.ReserveIdempotencyKey(context.TODO(), idempotencyKeyTest, record, time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+
+	assert.NoError(t, svc.ReleaseIdempotencyKey(context.TODO(), idempotencyKeyTest))
+
+	_, reserved, err = svc.ReserveIdempotencyKey(context.TODO(), idempotencyKeyTest, record, time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, reserved)
+
+	client.Close()
+
+	assert.ErrorContains(t, svc.ReleaseIdempotencyKey(context.TODO(), idempotencyKeyTest), errRedisClosed)
+}
+
+func TestMakeIdempotencyEndpoints(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		in           any
+		makeEndpoint func(*service.Service) func(context.Context, any) (any, error)
+		name         string
+		outErr       string
+	}{
+		{
+			name: "Reserve" + nameNoError,
+			in:   service.IdempotencyRequest{Key: idempotencyKeyTest, TTL: time.Hour},
+			makeEndpoint: func(svc *service.Service) func(context.Context, any) (any, error) {
+				return service.MakeReserveIdempotencyKeyEndpoint(svc)
+			},
+		},
+		{
+			name: "Save" + nameNoError,
+			in:   service.IdempotencyRequest{Key: idempotencyKeyTest, TTL: time.Hour},
+			makeEndpoint: func(svc *service.Service) func(context.Context, any) (any, error) {
+				return service.MakeSaveIdempotentResponseEndpoint(svc)
+			},
+		},
+		{
+			name: "Release" + nameNoError,
+			in:   service.IdempotencyRequest{Key: idempotencyKeyTest},
+			makeEndpoint: func(svc *service.Service) func(context.Context, any) (any, error) {
+				return service.MakeReleaseIdempotencyKeyEndpoint(svc)
+			},
+		},
+		{
+			name: "Reserve" + nameErrorRequest,
+			in:   incorrectRequest{incorrect: true},
+			makeEndpoint: func(svc *service.Service) func(context.Context, any) (any, error) {
+				return service.MakeReserveIdempotencyKeyEndpoint(svc)
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name: "Save" + nameErrorRequest,
+			in:   incorrectRequest{incorrect: true},
+			makeEndpoint: func(svc *service.Service) func(context.Context, any) (any, error) {
+				return service.MakeSaveIdempotentResponseEndpoint(svc)
+			},
+			outErr: "isn't of type",
+		},
+		{
+			name: "Release" + nameErrorRequest,
+			in:   incorrectRequest{incorrect: true},
+			makeEndpoint: func(svc *service.Service) func(context.Context, any) (any, error) {
+				return service.MakeReleaseIdempotencyKeyEndpoint(svc)
+			},
+			outErr: "isn't of type",
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+
+			r, err := tt.makeEndpoint(svc)(context.TODO(), tt.in)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			if tt.outErr == "" {
+				assert.Empty(t, resultErr)
+				assert.NotNil(t, r)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"github.com/cfabrica46/gokit-crud/platform/logging"
+	"github.com/go-kit/kit/endpoint"
+	"github.com/go-kit/log"
+)
+
+// LoggingMiddleware logs the calls of the endpoint of method, the failures
+// with a 5xx StatusCode as errors.
+func LoggingMiddleware(logger log.Logger, method string) endpoint.Middleware {
+	return logging.Middleware(logger, method, StatusCode)
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kit/kit/metrics"
+	kitprometheus "github.com/go-kit/kit/metrics/prometheus"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricsNamespace prefixes the metrics of the service.
+const MetricsNamespace = "token_app"
+
+type redisStartKey struct{}
+
+// RedisMetricsHook is a redis.Hook that observes the latency of every
+// command, pipelines are observed as a whole.
+type RedisMetricsHook struct {
+	Latency metrics.Histogram
+}
+
+// NewRedisMetricsHook registers the Redis instruments in reg.
+func NewRedisMetricsHook(reg prometheus.Registerer) RedisMetricsHook {
+	latency := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: MetricsNamespace,
+		Subsystem: "redis",
+		Name:      "command_duration_seconds",
+		Help:      "Time spent running the Redis commands.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command"})
+
+	reg.MustRegister(latency)
+
+	return RedisMetricsHook{Latency: kitprometheus.NewHistogram(latency)}
+}
+
+// BeforeProcess ...
+func (RedisMetricsHook) BeforeProcess(ctx context.Context, _ redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, redisStartKey{}, time.Now()), nil
+}
+
+// AfterProcess ...
+func (h RedisMetricsHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.observe(ctx, cmd.Name())
+
+	return nil
+}
+
+// BeforeProcessPipeline ...
+func (RedisMetricsHook) BeforeProcessPipeline(ctx context.Context, _ []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, redisStartKey{}, time.Now()), nil
+}
+
+// AfterProcessPipeline ...
+func (h RedisMetricsHook) AfterProcessPipeline(ctx context.Context, _ []redis.Cmder) error {
+	h.observe(ctx, "pipeline")
+
+	return nil
+}
+
+func (h RedisMetricsHook) observe(ctx context.Context, command string) {
+	if begin, ok := ctx.Value(redisStartKey{}).(time.Time); ok {
+		h.Latency.With("command", command).Observe(time.Since(begin).Seconds())
+	}
+}
@@ -0,0 +1,39 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis"
+	"github.com/cfabrica46/gokit-crud/token-app/service"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisMetricsHook(t *testing.T) {
+	t.Parallel()
+
+	mr, err := miniredis.Run()
+	assert.NoError(t, err)
+
+	defer mr.Close()
+
+	reg := prometheus.NewRegistry()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	client.AddHook(service.NewRedisMetricsHook(reg))
+
+	svc := service.GetService(client)
+
+	_, _, err = svc.GenerateRefreshToken(context.TODO(), idTest, usernameTest, emailTest, roleTest)
+	assert.NoError(t, err)
+
+	_, err = svc.CheckToken(context.TODO(), "missing")
+	assert.NoError(t, err)
+
+	count, err := testutil.GatherAndCount(reg, "token_app_redis_command_duration_seconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	_ "embed"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+//go:generate go test -run TestOpenAPI -update .
+
+// openAPIDocument is the spec served at /openapi.json, TestOpenAPI checks
+// that it is the one OpenAPI generates.
+//
+//go:embed openapi.json
+var openAPIDocument []byte
+
+// Operation documents a route: the JSON body of its request and what it
+// answers with.
+type Operation struct {
+	Body     any
+	Response any
+	Name     string
+	Method   string
+	Path     string
+	Summary  string
+}
+
+// Operations are the routes of token-app.
+var Operations = []Operation{
+	{
+		Name: "GenerateToken", Method: http.MethodPost, Path: "/generate",
+		Summary: "Sign a token and a refresh token for a user.",
+		Body:    IDUsernameEmailSecretRequest{}, Response: TokenRefreshErrResponse{},
+	},
+	{
+		Name: "ExtractToken", Method: http.MethodPost, Path: "/extract",
+		Summary: "Read the claims of a token.",
+		Body:    TokenSecretRequest{}, Response: IDUsernameEmailErrResponse{},
+	},
+	{
+		Name: "SetToken", Method: http.MethodPost, Path: "/token",
+		Summary: "Mark a token as valid.",
+		Body:    Token{}, Response: ErrorResponse{},
+	},
+	{
+		Name: "DeleteToken", Method: http.MethodDelete, Path: "/token",
+		Summary: "Revoke a token.",
+		Body:    Token{}, Response: ErrorResponse{},
+	},
+	{
+		Name: "CheckToken", Method: http.MethodPost, Path: "/check",
+		Summary: "Check that a token is valid.",
+		Body:    Token{}, Response: CheckErrResponse{},
+	},
+	{
+		Name: "RevokeUserTokens", Method: http.MethodDelete, Path: "/tokens",
+		Summary: "Revoke every token of a user.",
+		Body:    IDRequest{}, Response: ErrorResponse{},
+	},
+	{
+		Name: "RevokeSession", Method: http.MethodDelete, Path: "/session",
+		Summary: "Revoke a token and every token of its session.",
+		Body:    TokenSecretRequest{}, Response: ErrorResponse{},
+	},
+	{
+		Name: "GenerateOneTimeToken", Method: http.MethodPost, Path: "/onetime",
+		Summary: "Issue a single use token for a purpose.",
+		Body:    IDEmailPurposeRequest{}, Response: TokenErrResponse{},
+	},
+	{
+		Name: "ConsumeOneTimeToken", Method: http.MethodPost, Path: "/onetime/consume",
+		Summary: "Use a single use token, it answers the id of its user.",
+		Body:    TokenPurposeRequest{}, Response: IDEmailErrResponse{},
+	},
+	{
+		Name: "RefreshToken", Method: http.MethodPost, Path: "/refresh",
+		Summary: "Trade a refresh token for a new pair.",
+		Body:    RefreshTokenSecretRequest{}, Response: TokenRefreshErrResponse{},
+	},
+	{
+		Name: "AllowSignIn", Method: http.MethodPost, Path: "/signin/allow",
+		Summary: "Check the sign in limits of an IP and a username.",
+		Body:    IPUsernameRequest{}, Response: ErrorResponse{},
+	},
+	{
+		Name: "RecordSignIn", Method: http.MethodPost, Path: "/signin/result",
+		Summary: "Record the result of a sign in attempt.",
+		Body:    UsernameSuccessRequest{}, Response: ErrorResponse{},
+	},
+	{
+		Name: "ReserveIdempotencyKey", Method: http.MethodPost, Path: "/idempotency",
+		Summary: "Reserve an idempotency key, or get the record stored under it.",
+		Body:    IdempotencyRequest{}, Response: IdempotencyErrResponse{},
+	},
+	{
+		Name: "SaveIdempotentResponse", Method: http.MethodPut, Path: "/idempotency",
+		Summary: "Store the response of an idempotency key.",
+		Body:    IdempotencyRequest{}, Response: ErrorResponse{},
+	},
+	{
+		Name: "ReleaseIdempotencyKey", Method: http.MethodDelete, Path: "/idempotency",
+		Summary: "Free an idempotency key.",
+		Body:    IdempotencyRequest{}, Response: ErrorResponse{},
+	},
+}
+
+// OpenAPIHandler serves the OpenAPI document of token-app.
+func OpenAPIHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(openAPIDocument)
+	})
+}
+
+// OpenAPI is the OpenAPI 3 document of the operations, their schemas are
+// read from the JSON names of the request and response structs.
+func OpenAPI(operations []Operation) map[string]any {
+	schemas := newSchemas()
+	paths := map[string]any{}
+
+	for _, op := range operations {
+		item, _ := paths[op.Path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[op.Path] = item
+		}
+
+		item[strings.ToLower(op.Method)] = map[string]any{
+			"operationId": op.Name,
+			"summary":     op.Summary,
+			"requestBody": map[string]any{
+				"content": jsonContent("application/json", schemas.of(reflect.TypeOf(op.Body))),
+			},
+			"responses": map[string]any{
+				"200": map[string]any{
+					"description": "OK",
+					"content":     jsonContent("application/json", schemas.of(reflect.TypeOf(op.Response))),
+				},
+				"default": map[string]any{
+					"description": "Problem",
+					"content":     jsonContent("application/problem+json", schemas.of(reflect.TypeOf(Problem{}))),
+				},
+			},
+		}
+	}
+
+	return map[string]any{
+		"openapi":    "3.0.3",
+		"info":       map[string]any{"title": "token-app", "version": "1.0.0"},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas.components},
+	}
+}
+
+func jsonContent(mediaType string, schema map[string]any) map[string]any {
+	return map[string]any{mediaType: map[string]any{"schema": schema}}
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// schemas keeps the schemas of the named structs as components.
+type schemas struct {
+	components map[string]any
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: map[string]any{}}
+}
+
+// of is the schema of t, a reference for named structs.
+func (s *schemas) of(t reflect.Type) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]any{"type": "integer", "format": "int64", "description": "nanoseconds"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.of(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+
+		return map[string]any{"type": "array", "items": s.of(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+
+		if _, ok := s.components[t.Name()]; !ok {
+			// reserved first, so that a struct can refer to itself.
+			s.components[t.Name()] = nil
+			s.components[t.Name()] = s.object(t)
+		}
+
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	default:
+		return map[string]any{}
+	}
+}
+
+// object is the schema of the JSON fields of a struct, those without
+// omitempty are required.
+func (s *schemas) object(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = s.of(field.Type)
+
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
@@ -0,0 +1,73 @@
+package service_test
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/cfabrica46/gokit-crud/token-app/service"
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "write the generated openapi.json")
+
+func TestOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	generated, err := json.MarshalIndent(service.OpenAPI(service.Operations), "", "  ")
+	assert.NoError(t, err)
+
+	generated = append(generated, '\n')
+
+	if *update {
+		assert.NoError(t, os.WriteFile("openapi.json", generated, 0o644))
+
+		return
+	}
+
+	w := httptest.NewRecorder()
+	service.OpenAPIHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.Equal(t, string(generated), w.Body.String(), "openapi.json is out of date, run go generate ./service")
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	t.Parallel()
+
+	document := service.OpenAPI([]service.Operation{{
+		Name:     "ReserveIdempotencyKey",
+		Method:   http.MethodPost,
+		Path:     "/idempotency",
+		Body:     service.IdempotencyRequest{},
+		Response: service.IdempotencyErrResponse{},
+	}})
+
+	schemas := document["components"].(map[string]any)["schemas"].(map[string]any)
+
+	assert.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"key":    map[string]any{"type": "string"},
+			"record": map[string]any{"$ref": "#/components/schemas/IdempotencyRecord"},
+			"ttl":    map[string]any{"type": "integer", "format": "int64", "description": "nanoseconds"},
+		},
+		"required": []string{"key", "record", "ttl"},
+	}, schemas["IdempotencyRequest"])
+	assert.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"header": map[string]any{
+				"type":                 "object",
+				"additionalProperties": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			},
+			"fingerprint": map[string]any{"type": "string"},
+			"body":        map[string]any{"type": "string", "format": "byte"},
+			"status":      map[string]any{"type": "integer"},
+		},
+		"required": []string{"fingerprint"},
+	}, schemas["IdempotencyRecord"])
+}
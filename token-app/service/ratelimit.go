@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrSignInLimited is returned while the sign in attempts of an IP or a
+// username are over their limit or the account is locked out.
+var ErrSignInLimited = &Error{Kind: ErrTooManyRequests, Err: errors.New("too many sign in attempts")}
+
+// LimitPolicy is a token bucket: Capacity attempts in a burst, given back one
+// every Refill.
+type LimitPolicy struct {
+	Capacity int
+	Refill   time.Duration
+}
+
+// ttl is how long a bucket takes to fill up, an untouched bucket is dropped
+// after it since it is as good as a new one.
+func (p LimitPolicy) ttl() time.Duration {
+	return time.Duration(p.Capacity) * p.Refill
+}
+
+// SignInLimits are the limits of the sign in attempts, shared by every
+// gateway replica through redis.
+type SignInLimits struct {
+	PerIP       LimitPolicy
+	PerUsername LimitPolicy
+
+	// MaxFailures consecutive failures within FailureWindow lock the account
+	// out for Lockout.
+	MaxFailures   int
+	FailureWindow time.Duration
+	Lockout       time.Duration
+}
+
+// DefaultSignInLimits ...
+func DefaultSignInLimits() SignInLimits {
+	return SignInLimits{
+		PerIP:         LimitPolicy{Capacity: 20, Refill: 3 * time.Second},
+		PerUsername:   LimitPolicy{Capacity: 5, Refill: 12 * time.Second},
+		MaxFailures:   5,
+		FailureWindow: 15 * time.Minute,
+		Lockout:       15 * time.Minute,
+	}
+}
+
+// allowScript returns the milliseconds left of the lockout of KEYS[1], if
+// any, or else takes a token from every bucket in the rest of KEYS and
+// returns 0. When a bucket is empty nothing is taken and the milliseconds
+// until it has a token again are returned. ARGV holds the current time in
+// milliseconds followed by the capacity, refill and time to live, in
+// milliseconds, of each bucket.
+var allowScript = redis.NewScript(`
+local locked = redis.call("PTTL", KEYS[1])
+if locked > 0 then
+	return locked
+end
+
+local now = tonumber(ARGV[1])
+local wait = 0
+local tokens = {}
+
+for i = 2, #KEYS do
+	local capacity = tonumber(ARGV[3 * i - 4])
+	local refill = tonumber(ARGV[3 * i - 3])
+	local bucket = redis.call("HMGET", KEYS[i], "tokens", "ts")
+	local left = tonumber(bucket[1]) or capacity
+	local ts = tonumber(bucket[2]) or now
+
+	left = math.min(capacity, left + math.max(0, now - ts) / refill)
+	if left < 1 then
+		wait = math.max(wait, math.ceil((1 - left) * refill))
+	end
+
+	tokens[i] = left
+end
+
+if wait > 0 then
+	return wait
+end
+
+for i = 2, #KEYS do
+	redis.call("HMSET", KEYS[i], "tokens", tostring(tokens[i] - 1), "ts", ARGV[1])
+	redis.call("PEXPIRE", KEYS[i], ARGV[3 * i - 2])
+end
+
+return 0
+`)
+
+// failureScript counts a failed sign in on KEYS[1], the count lives ARGV[1]
+// milliseconds from the first failure. Reaching ARGV[2] failures resets the
+// count and sets the lockout KEYS[2] for ARGV[3] milliseconds.
+var failureScript = redis.NewScript(`
+local failures = redis.call("INCR", KEYS[1])
+if failures == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+
+if failures >= tonumber(ARGV[2]) then
+	redis.call("SET", KEYS[2], "1", "PX", ARGV[3])
+	redis.call("DEL", KEYS[1])
+end
+
+return failures
+`)
+
+// AllowSignIn takes a sign in attempt from the buckets of the IP and the
+// username, it fails with ErrSignInLimited, telling how long to wait, when
+// either is empty or the account is locked out.
+func (s *Service) AllowSignIn(ctx context.Context, ip, username string) (err error) {
+	limits := s.Limits
+
+	wait, err := allowScript.Run(
+		ctx,
+		s.DB,
+		[]string{signInLockoutKey(username), signInIPKey(ip), signInUsernameKey(username)},
+		time.Now().UnixMilli(),
+		limits.PerIP.Capacity, limits.PerIP.Refill.Milliseconds(), limits.PerIP.ttl().Milliseconds(),
+		limits.PerUsername.Capacity, limits.PerUsername.Refill.Milliseconds(), limits.PerUsername.ttl().Milliseconds(),
+	).Int64()
+	if err != nil {
+		return fmt.Errorf("error to check sign in limits: %w", err)
+	}
+
+	if wait > 0 {
+		return &Error{
+			Kind:       ErrTooManyRequests,
+			Err:        ErrSignInLimited,
+			RetryAfter: time.Duration(wait) * time.Millisecond,
+		}
+	}
+
+	return nil
+}
+
+// RecordSignIn counts the consecutive failed sign ins of the username,
+// locking the account out when they reach the limit, a successful one
+// starts the count again.
+func (s *Service) RecordSignIn(ctx context.Context, username string, success bool) (err error) {
+	if success {
+		if err = s.DB.Del(ctx, signInFailuresKey(username)).Err(); err != nil {
+			return fmt.Errorf("error to record sign in: %w", err)
+		}
+
+		return nil
+	}
+
+	limits := s.Limits
+
+	err = failureScript.Run(
+		ctx,
+		s.DB,
+		[]string{signInFailuresKey(username), signInLockoutKey(username)},
+		limits.FailureWindow.Milliseconds(),
+		limits.MaxFailures,
+		limits.Lockout.Milliseconds(),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("error to record sign in: %w", err)
+	}
+
+	return nil
+}
+
+// signInIPKey is the redis hash holding the sign in bucket of an IP.
+func signInIPKey(ip string) string {
+	return "signin:ip:" + ip
+}
+
+// signInUsernameKey is the redis hash holding the sign in bucket of a
+// username.
+func signInUsernameKey(username string) string {
+	return "signin:username:" + username
+}
+
+// signInFailuresKey counts the consecutive failed sign ins of a username.
+func signInFailuresKey(username string) string {
+	return "signin:failures:" + username
+}
+
+// signInLockoutKey exists while a username is locked out.
+func signInLockoutKey(username string) string {
+	return "signin:lockout:" + username
+}
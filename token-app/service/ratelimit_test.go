@@ -0,0 +1,165 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis"
+	"github.com/cfabrica46/gokit-crud/token-app/service"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+)
+
+const ipTest string = "10.0.0.1"
+
+func TestAllowSignIn(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name       string
+		inIPs      []string
+		inLocked   bool
+		outAllowed int
+		outWait    time.Duration
+	}{
+		{
+			name:       nameNoError,
+			inIPs:      []string{ipTest, ipTest},
+			outAllowed: 2,
+		},
+		{
+			name:       "ErrorUsernameLimited",
+			inIPs:      []string{ipTest, "10.0.0.2", "10.0.0.3", "10.0.0.4"},
+			outAllowed: 3,
+			outWait:    time.Hour,
+		},
+		{
+			name:       "ErrorIPLimited",
+			inIPs:      []string{ipTest, ipTest, ipTest},
+			outAllowed: 2,
+			outWait:    time.Minute,
+		},
+		{
+			name:     "ErrorLockedOut",
+			inIPs:    []string{ipTest},
+			inLocked: true,
+			outWait:  time.Hour,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+			svc.Limits = service.SignInLimits{
+				PerIP:         service.LimitPolicy{Capacity: 2, Refill: time.Minute},
+				PerUsername:   service.LimitPolicy{Capacity: 3, Refill: time.Hour},
+				MaxFailures:   1,
+				FailureWindow: time.Hour,
+				Lockout:       time.Hour,
+			}
+
+			if tt.inLocked {
+				assert.NoError(t, svc.RecordSignIn(context.TODO(), usernameTest, false))
+			}
+
+			var allowed int
+
+			for _, ip := range tt.inIPs {
+				if err = svc.AllowSignIn(context.TODO(), ip, usernameTest); err != nil {
+					break
+				}
+
+				allowed++
+			}
+
+			assert.Equal(t, tt.outAllowed, allowed)
+
+			if tt.outWait == 0 {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			assert.ErrorIs(t, err, service.ErrSignInLimited)
+			assert.InDelta(t, tt.outWait, service.RetryAfter(err), float64(time.Second))
+		})
+	}
+}
+
+func TestRecordSignIn(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name      string
+		outErr    string
+		inResults []bool
+		outLocked bool
+	}{
+		{
+			name:      nameNoError,
+			inResults: []bool{false, false},
+		},
+		{
+			name:      "LockedOut",
+			inResults: []bool{false, false, false},
+			outLocked: true,
+		},
+		{
+			name:      "SuccessResetsFailures",
+			inResults: []bool{false, false, true, false, false},
+		},
+		{
+			name:      nameErrorRedisClose,
+			inResults: []bool{false},
+			outErr:    errRedisClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+			svc.Limits.MaxFailures = 3
+
+			if tt.name == nameErrorRedisClose {
+				client.Close()
+			}
+
+			for _, success := range tt.inResults {
+				if err = svc.RecordSignIn(context.TODO(), usernameTest, success); err != nil {
+					resultErr = err.Error()
+
+					break
+				}
+			}
+
+			assert.Contains(t, resultErr, tt.outErr)
+
+			if tt.outErr != "" {
+				return
+			}
+
+			err = svc.AllowSignIn(context.TODO(), ipTest, usernameTest)
+			assert.Equal(t, tt.outLocked, errors.Is(err, service.ErrSignInLimited))
+		})
+	}
+}
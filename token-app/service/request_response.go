@@ -1,9 +1,15 @@
 package service
 
+import (
+	"net/http"
+	"time"
+)
+
 // IDUsernameEmailSecretRequest ...
 type IDUsernameEmailSecretRequest struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
+	Role     string `json:"role"`
 	Secret   string `json:"secret"`
 	ID       int    `json:"id"`
 }
@@ -19,21 +25,106 @@ type Token struct {
 	Token string `json:"token"`
 }
 
+// IDRequest ...
+type IDRequest struct {
+	ID int `json:"id"`
+}
+
+// IDEmailPurposeRequest binds the one-time token to Email too when it is
+// set.
+type IDEmailPurposeRequest struct {
+	Email   string `json:"email,omitempty"`
+	Purpose string `json:"purpose"`
+	ID      int    `json:"id"`
+}
+
+// TokenPurposeRequest ...
+type TokenPurposeRequest struct {
+	Token   string `json:"token"`
+	Purpose string `json:"purpose"`
+}
+
+// RefreshTokenSecretRequest ...
+type RefreshTokenSecretRequest struct {
+	RefreshToken string `json:"refreshToken"`
+	Secret       string `json:"secret"`
+}
+
+// IPUsernameRequest ...
+type IPUsernameRequest struct {
+	IP       string `json:"ip"`
+	Username string `json:"username"`
+}
+
+// UsernameSuccessRequest ...
+type UsernameSuccessRequest struct {
+	Username string `json:"username"`
+	Success  bool   `json:"success"`
+}
+
+// IdempotencyRequest carries the idempotency key and, to reserve it or save
+// the response, the record and how long it is kept.
+type IdempotencyRequest struct {
+	Key    string            `json:"key"`
+	Record IdempotencyRecord `json:"record"`
+	TTL    time.Duration     `json:"ttl"`
+}
+
+// IdempotencyRecord is what is stored under an idempotency key: the
+// fingerprint of the request and, once it is answered, the response
+// replayed to its retries.
+type IdempotencyRecord struct {
+	Header      http.Header `json:"header,omitempty"`
+	Fingerprint string      `json:"fingerprint"`
+	Body        []byte      `json:"body,omitempty"`
+	Status      int         `json:"status,omitempty"`
+}
+
 // IDUsernameEmailErrResponse ...
 type IDUsernameEmailErrResponse struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
-	Err      string `json:"err,omitempty"`
+	Role     string `json:"role"`
 	ID       int    `json:"id"`
 }
 
-// ErrorResponse ...
-type ErrorResponse struct {
-	Err string `json:"err,omitempty"`
-}
+// ErrorResponse is the body of the calls that return nothing, failures are
+// reported as a Problem.
+type ErrorResponse struct{}
 
 // CheckErrResponse ...
 type CheckErrResponse struct {
-	Err   string `json:"err,omitempty"`
-	Check bool   `json:"check"`
+	Check bool `json:"check"`
+}
+
+// TokenErrResponse ...
+type TokenErrResponse struct {
+	Token string `json:"token"`
+}
+
+// IDEmailErrResponse ...
+type IDEmailErrResponse struct {
+	Email string `json:"email,omitempty"`
+	ID    int    `json:"id"`
+}
+
+// TokenRefreshErrResponse ...
+type TokenRefreshErrResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+// IdempotencyErrResponse ...
+type IdempotencyErrResponse struct {
+	Record   IdempotencyRecord `json:"record"`
+	Reserved bool              `json:"reserved"`
+}
+
+// Problem is the RFC 7807 application/problem+json body errors are reported
+// with.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Detail string `json:"detail,omitempty"`
+	Status int    `json:"status"`
 }
@@ -1,48 +1,97 @@
 package service
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
-	"github.com/go-redis/redis"
+	"github.com/go-redis/redis/v8"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
 )
 
 const (
-	lifeOfToken int = 10
+	lifeOfToken              int = 10
+	lifeOfPasswordResetToken int = 15
+	lifeOfVerificationToken  int = 60 * 24
+	lifeOfRefreshToken       int = 60 * 24 * 7
+
+	oneTimeTokenSize int = 32
+
+	// PurposePasswordReset binds a one-time token to the forgot/reset password flow.
+	PurposePasswordReset string = "password_reset"
+
+	// PurposeEmailVerification binds a one-time token to the email verification flow.
+	PurposeEmailVerification string = "email_verification"
 )
 
 var (
 	ErrUnexpectedSigningMethod = errors.New("unexpected signing method")
-	ErrClaims                  = errors.New("error to claims")
+	ErrClaims                  = &Error{Kind: ErrUnauthorized, Err: errors.New("error to claims")}
+	ErrUnknownPurpose          = &Error{Kind: ErrValidation, Err: errors.New("unknown token purpose")}
+	ErrOneTimeTokenNotValid    = &Error{Kind: ErrUnauthorized, Err: errors.New("one-time token not valid")}
+	ErrRefreshTokenNotValid    = &Error{Kind: ErrUnauthorized, Err: errors.New("refresh token not valid")}
+	ErrRefreshTokenReused      = &Error{
+		Kind: ErrUnauthorized,
+		Err:  errors.New("refresh token reused, session revoked"),
+	}
 )
 
+// lifeOfOneTimeTokens are the minutes a one-time token lives, by purpose.
+var lifeOfOneTimeTokens = map[string]int{ //nolint:gochecknoglobals
+	PurposePasswordReset:     lifeOfPasswordResetToken,
+	PurposeEmailVerification: lifeOfVerificationToken,
+}
+
 type serviceInterface interface {
-	GenerateToken(int, string, string, []byte) string
-	ExtractToken(string, []byte) (int, string, string, error)
-	ManageToken(State, string) error
-	CheckToken(string) (bool, error)
+	GenerateToken(context.Context, int, string, string, string, string, []byte) string
+	ExtractToken(context.Context, string, []byte) (int, string, string, string, error)
+	ManageToken(context.Context, State, string) error
+	CheckToken(context.Context, string) (bool, error)
+	RevokeUserTokens(context.Context, int) error
+	RevokeSession(context.Context, string, []byte) error
+	GenerateOneTimeToken(context.Context, int, string, string) (string, error)
+	ConsumeOneTimeToken(context.Context, string, string) (int, string, error)
+	GenerateRefreshToken(context.Context, int, string, string, string) (string, string, error)
+	RefreshToken(context.Context, string, []byte) (string, string, error)
+	AllowSignIn(context.Context, string, string) error
+	RecordSignIn(context.Context, string, bool) error
+	ReserveIdempotencyKey(context.Context, string, IdempotencyRecord, time.Duration) (IdempotencyRecord, bool, error)
+	SaveIdempotentResponse(context.Context, string, IdempotencyRecord, time.Duration) error
+	ReleaseIdempotencyKey(context.Context, string) error
 }
 
 // Service ...
 type Service struct {
-	DB *redis.Client
+	DB     *redis.Client
+	Limits SignInLimits
 }
 
 // GetService ...
 func GetService(db *redis.Client) *Service {
-	return &Service{db}
+	return &Service{DB: db, Limits: DefaultSignInLimits()}
 }
 
-// GenerateToken ...
-func (Service) GenerateToken(id int, username, email string, secret []byte) (token string) {
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+// GenerateToken issues an access token, bound to the refresh token family
+// of its session when family is not empty.
+func (Service) GenerateToken(_ context.Context, id int, username, email, role, family string, secret []byte) (token string) {
+	claims := jwt.MapClaims{
 		"id":       id,
 		"username": username,
 		"email":    email,
+		"role":     role,
 		"uuid":     uuid.NewString(),
-	})
+	}
+
+	if family != "" {
+		claims["family"] = family
+	}
+
+	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	token, _ = t.SignedString(secret)
 
@@ -50,37 +99,40 @@ func (Service) GenerateToken(id int, username, email string, secret []byte) (tok
 }
 
 // ExtractToken ...
-func (Service) ExtractToken(token string, secret []byte) (id int, username, email string, err error) {
+func (Service) ExtractToken(_ context.Context, token string, secret []byte) (id int, username, email, role string, err error) {
 	t, err := jwt.Parse(token, KeyFunc(secret))
 	if err != nil {
-		return 0, "", "", fmt.Errorf("error to extract token: %w", err)
+		return 0, "", "", "", &Error{Kind: ErrUnauthorized, Err: fmt.Errorf("error to extract token: %w", err)}
 	}
 
 	claims, _ := t.Claims.(jwt.MapClaims)
 
 	idAux, ok := claims["id"].(float64)
 	if !ok {
-		return 0, "", "", fmt.Errorf("%w: claims['id'] isn't of type float64", ErrClaims)
+		return 0, "", "", "", fmt.Errorf("%w: claims['id'] isn't of type float64", ErrClaims)
 	}
 
 	id = int(idAux)
 
 	username, ok = claims["username"].(string)
 	if !ok {
-		return 0, "", "", fmt.Errorf("%w: claims['username'] isn't of type string", ErrClaims)
+		return 0, "", "", "", fmt.Errorf("%w: claims['username'] isn't of type string", ErrClaims)
 	}
 
 	email, ok = claims["email"].(string)
 	if !ok {
-		return 0, "", "", fmt.Errorf("%w: claims['email'] isn't of type string", ErrClaims)
+		return 0, "", "", "", fmt.Errorf("%w: claims['email'] isn't of type string", ErrClaims)
 	}
 
-	return id, username, email, nil
+	// tokens issued before roles existed carry no role claim.
+	role, _ = claims["role"].(string)
+
+	return id, username, email, role, nil
 }
 
 // ManageToken ...
-func (s *Service) ManageToken(st State, token string) (err error) {
-	err = st.ManageToken(s.DB, token)
+func (s *Service) ManageToken(ctx context.Context, st State, token string) (err error) {
+	err = st.ManageToken(ctx, s.DB, token)
 	if err != nil {
 		return fmt.Errorf("error when managing token: %w", err)
 	}
@@ -89,8 +141,8 @@ func (s *Service) ManageToken(st State, token string) (err error) {
 }
 
 // CheckToken ...
-func (s Service) CheckToken(token string) (check bool, err error) {
-	result, err := s.DB.Get(token).Result()
+func (s Service) CheckToken(ctx context.Context, token string) (check bool, err error) {
+	result, err := s.DB.Get(ctx, token).Result()
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
 			return false, nil
@@ -106,6 +158,255 @@ func (s Service) CheckToken(token string) (check bool, err error) {
 	return check, nil
 }
 
+// RevokeUserTokens deletes every active token issued to the user, including
+// its refresh tokens.
+func (s *Service) RevokeUserTokens(ctx context.Context, id int) (err error) {
+	families, err := s.DB.SMembers(ctx, userRefreshFamiliesKey(id)).Result()
+	if err != nil {
+		return fmt.Errorf("error to get user refresh tokens: %w", err)
+	}
+
+	for _, family := range families {
+		if err = s.revokeFamily(ctx, id, family); err != nil {
+			return err
+		}
+	}
+
+	tokens, err := s.DB.SMembers(ctx, userTokensKey(id)).Result()
+	if err != nil {
+		return fmt.Errorf("error to get user tokens: %w", err)
+	}
+
+	tokens = append(tokens, userTokensKey(id))
+
+	if err = s.DB.Del(ctx, tokens...).Err(); err != nil {
+		return fmt.Errorf("error to revoke user tokens: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeSession deletes token and, when it belongs to a session, every
+// access and refresh token of its family, the other sessions of the user
+// stay open.
+func (s *Service) RevokeSession(ctx context.Context, token string, secret []byte) (err error) {
+	t, err := jwt.Parse(token, KeyFunc(secret))
+	if err != nil {
+		return &Error{Kind: ErrUnauthorized, Err: fmt.Errorf("error to extract token: %w", err)}
+	}
+
+	claims, _ := t.Claims.(jwt.MapClaims)
+
+	idAux, ok := claims["id"].(float64)
+	if !ok {
+		return fmt.Errorf("%w: claims['id'] isn't of type float64", ErrClaims)
+	}
+
+	if err = NewDeleteTokenState().ManageToken(ctx, s.DB, token); err != nil {
+		return fmt.Errorf("error to revoke session: %w", err)
+	}
+
+	// tokens issued before the families existed carry no family claim.
+	family, _ := claims["family"].(string)
+	if family == "" {
+		return nil
+	}
+
+	return s.revokeFamily(ctx, int(idAux), family)
+}
+
+// GenerateOneTimeToken issues a random single-use token bound to a purpose,
+// to the user and, if it is not empty, to the email it was sent to.
+func (s *Service) GenerateOneTimeToken(ctx context.Context, id int, email, purpose string) (token string, err error) {
+	life, ok := lifeOfOneTimeTokens[purpose]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownPurpose, purpose)
+	}
+
+	token, err = randomToken()
+	if err != nil {
+		return "", fmt.Errorf("error to generate one-time token: %w", err)
+	}
+
+	pipe := s.DB.TxPipeline()
+	pipe.HMSet(ctx, oneTimeTokenKey(purpose, token), map[string]any{
+		"id":    id,
+		"email": email,
+	})
+	pipe.Expire(ctx, oneTimeTokenKey(purpose, token), time.Minute*time.Duration(life))
+
+	if _, err = pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("error to set one-time token: %w", err)
+	}
+
+	return token, nil
+}
+
+// ConsumeOneTimeToken burns the token and returns the user and the email it
+// was issued to.
+func (s *Service) ConsumeOneTimeToken(ctx context.Context, token, purpose string) (id int, email string, err error) {
+	if _, ok := lifeOfOneTimeTokens[purpose]; !ok {
+		return 0, "", fmt.Errorf("%w: %s", ErrUnknownPurpose, purpose)
+	}
+
+	pipe := s.DB.TxPipeline()
+	get := pipe.HGetAll(ctx, oneTimeTokenKey(purpose, token))
+	pipe.Del(ctx, oneTimeTokenKey(purpose, token))
+
+	if _, err = pipe.Exec(ctx); err != nil {
+		return 0, "", fmt.Errorf("error to consume one-time token: %w", err)
+	}
+
+	fields := get.Val()
+	if len(fields) == 0 {
+		return 0, "", ErrOneTimeTokenNotValid
+	}
+
+	id, err = strconv.Atoi(fields["id"])
+	if err != nil {
+		return 0, "", fmt.Errorf("error to consume one-time token: %w", err)
+	}
+
+	return id, fields["email"], nil
+}
+
+// GenerateRefreshToken starts a new refresh token family for the user, the
+// session the access tokens of family are bound to.
+func (s *Service) GenerateRefreshToken(
+	ctx context.Context,
+	id int,
+	username, email, role string,
+) (refreshToken, family string, err error) {
+	family = uuid.NewString()
+
+	refreshToken, err = s.issueRefreshToken(ctx, id, username, email, role, family)
+	if err != nil {
+		return "", "", err
+	}
+
+	return refreshToken, family, nil
+}
+
+// RefreshToken rotates the refresh token and issues a new access token of
+// its family. Presenting a refresh token that was already rotated revokes
+// the family, the session it was stolen from.
+func (s *Service) RefreshToken(ctx context.Context, refreshToken string, secret []byte) (token, newRefreshToken string, err error) {
+	pipe := s.DB.TxPipeline()
+	get := pipe.HGetAll(ctx, refreshTokenKey(refreshToken))
+	uses := pipe.HIncrBy(ctx, refreshTokenKey(refreshToken), "uses", 1)
+
+	if _, err = pipe.Exec(ctx); err != nil {
+		return "", "", fmt.Errorf("error to get refresh token: %w", err)
+	}
+
+	fields := get.Val()
+	if len(fields) == 0 {
+		// HIncrBy created the key, it must not outlive this call.
+		if err = s.DB.Del(ctx, refreshTokenKey(refreshToken)).Err(); err != nil {
+			return "", "", fmt.Errorf("error to get refresh token: %w", err)
+		}
+
+		return "", "", ErrRefreshTokenNotValid
+	}
+
+	id, err := strconv.Atoi(fields["id"])
+	if err != nil {
+		return "", "", fmt.Errorf("%w: %s", ErrRefreshTokenNotValid, err)
+	}
+
+	if uses.Val() > 1 {
+		if err = s.revokeFamily(ctx, id, fields["family"]); err != nil {
+			return "", "", err
+		}
+
+		return "", "", ErrRefreshTokenReused
+	}
+
+	newRefreshToken, err = s.issueRefreshToken(
+		ctx,
+		id,
+		fields["username"],
+		fields["email"],
+		fields["role"],
+		fields["family"],
+	)
+	if err != nil {
+		return "", "", err
+	}
+
+	token = s.GenerateToken(ctx, id, fields["username"], fields["email"], fields["role"], fields["family"], secret)
+
+	return token, newRefreshToken, nil
+}
+
+// issueRefreshToken stores a new refresh token in the family. Rotated tokens
+// are kept until they expire so that reusing them can be detected.
+func (s *Service) issueRefreshToken(ctx context.Context, id int, username, email, role, family string) (refreshToken string, err error) {
+	refreshToken, err = randomToken()
+	if err != nil {
+		return "", fmt.Errorf("error to generate refresh token: %w", err)
+	}
+
+	life := time.Minute * time.Duration(lifeOfRefreshToken)
+
+	pipe := s.DB.TxPipeline()
+	pipe.HMSet(ctx, refreshTokenKey(refreshToken), map[string]any{
+		"id":       id,
+		"username": username,
+		"email":    email,
+		"role":     role,
+		"family":   family,
+		"uses":     0,
+	})
+	pipe.Expire(ctx, refreshTokenKey(refreshToken), life)
+	pipe.SAdd(ctx, refreshFamilyKey(family), refreshToken)
+	pipe.Expire(ctx, refreshFamilyKey(family), life)
+	pipe.SAdd(ctx, userRefreshFamiliesKey(id), family)
+	pipe.Expire(ctx, userRefreshFamiliesKey(id), life)
+
+	if _, err = pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("error to set refresh token: %w", err)
+	}
+
+	return refreshToken, nil
+}
+
+// revokeFamily deletes every refresh token of the family and the access
+// tokens issued from it.
+func (s *Service) revokeFamily(ctx context.Context, id int, family string) (err error) {
+	refreshTokens, err := s.DB.SMembers(ctx, refreshFamilyKey(family)).Result()
+	if err != nil {
+		return fmt.Errorf("error to get refresh token family: %w", err)
+	}
+
+	tokens, err := s.DB.SMembers(ctx, familyTokensKey(family)).Result()
+	if err != nil {
+		return fmt.Errorf("error to get family tokens: %w", err)
+	}
+
+	keys := make([]string, 0, len(refreshTokens)+len(tokens)+2)
+	for _, refreshToken := range refreshTokens {
+		keys = append(keys, refreshTokenKey(refreshToken))
+	}
+
+	keys = append(keys, tokens...)
+	keys = append(keys, refreshFamilyKey(family), familyTokensKey(family))
+
+	pipe := s.DB.TxPipeline()
+	pipe.Del(ctx, keys...)
+	pipe.SRem(ctx, userRefreshFamiliesKey(id), family)
+
+	if len(tokens) > 0 {
+		pipe.SRem(ctx, userTokensKey(id), toAny(tokens)...)
+	}
+
+	if _, err = pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("error to revoke refresh token family: %w", err)
+	}
+
+	return nil
+}
+
 func KeyFunc(secret []byte) func(token *jwt.Token) (any, error) {
 	return func(token *jwt.Token) (any, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -115,3 +416,78 @@ func KeyFunc(secret []byte) func(token *jwt.Token) (any, error) {
 		return secret, nil
 	}
 }
+
+// userTokensKey is the redis set holding the active tokens of a user.
+func userTokensKey(id int) string {
+	return "tokens:user:" + strconv.Itoa(id)
+}
+
+// familyTokensKey is the redis set holding the active access tokens issued
+// from a refresh token family.
+func familyTokensKey(family string) string {
+	return "tokens:family:" + family
+}
+
+// oneTimeTokenKey namespaces one-time tokens by purpose so a token issued for
+// one flow can't be redeemed in another.
+func oneTimeTokenKey(purpose, token string) string {
+	return "onetime:" + purpose + ":" + token
+}
+
+// refreshTokenKey is the redis hash holding the claims of a refresh token.
+func refreshTokenKey(token string) string {
+	return "refresh:" + token
+}
+
+// refreshFamilyKey is the redis set holding every refresh token rotated from
+// the same sign in.
+func refreshFamilyKey(family string) string {
+	return "refresh:family:" + family
+}
+
+// userRefreshFamiliesKey is the redis set holding the refresh token families of
+// a user.
+func userRefreshFamiliesKey(id int) string {
+	return "refresh:user:" + strconv.Itoa(id)
+}
+
+// randomToken returns an opaque random token encoded as hex.
+func randomToken() (token string, err error) {
+	b := make([]byte, oneTimeTokenSize)
+
+	if _, err = rand.Read(b); err != nil {
+		return "", fmt.Errorf("error to read random bytes: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// tokenOwner reads the id and family claims without verifying the
+// signature, it is only used to index tokens that were already issued by
+// this service.
+func tokenOwner(token string) (id int, family string, ok bool) {
+	t, _, err := new(jwt.Parser).ParseUnverified(token, jwt.MapClaims{})
+	if err != nil {
+		return 0, "", false
+	}
+
+	claims, _ := t.Claims.(jwt.MapClaims)
+
+	idAux, ok := claims["id"].(float64)
+	if !ok {
+		return 0, "", false
+	}
+
+	family, _ = claims["family"].(string)
+
+	return int(idAux), family, true
+}
+
+func toAny(values []string) []any {
+	all := make([]any, len(values))
+	for i, value := range values {
+		all[i] = value
+	}
+
+	return all
+}
@@ -1,11 +1,12 @@
 package service_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/alicebob/miniredis"
 	"github.com/cfabrica46/gokit-crud/token-app/service"
-	"github.com/go-redis/redis"
+	"github.com/go-redis/redis/v8"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -19,6 +20,7 @@ const (
 	emailTest    string = "email@email.com"
 	secretTest   string = "secret"
 	tokenTest    string = "token"
+	roleTest     string = "admin"
 
 	errRedisClosed string = "redis: client is closed"
 
@@ -33,6 +35,7 @@ func TestGenerateToken(t *testing.T) {
 	for _, tt := range []struct {
 		name                string
 		inUsername, inEmail string
+		inRole, inFamily    string
 		outToken, outErr    string
 		inSecret            []byte
 		inID                int
@@ -42,6 +45,18 @@ func TestGenerateToken(t *testing.T) {
 			inID:       idTest,
 			inUsername: usernameTest,
 			inEmail:    emailTest,
+			inRole:     roleTest,
+			inSecret:   []byte(secretTest),
+			outToken:   "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.",
+			outErr:     "",
+		},
+		{
+			name:       "NoErrorFamily",
+			inID:       idTest,
+			inUsername: usernameTest,
+			inEmail:    emailTest,
+			inRole:     roleTest,
+			inFamily:   "family",
 			inSecret:   []byte(secretTest),
 			outToken:   "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.",
 			outErr:     "",
@@ -62,9 +77,20 @@ func TestGenerateToken(t *testing.T) {
 
 			svc := service.GetService(client)
 
-			result = svc.GenerateToken(tt.inID, tt.inUsername, tt.inEmail, tt.inSecret)
+			result = svc.GenerateToken(context.TODO(), tt.inID, tt.inUsername, tt.inEmail, tt.inRole, tt.inFamily, tt.inSecret)
 
 			assert.Contains(t, result, tt.outToken)
+
+			claims := jwt.MapClaims{}
+			_, err = jwt.ParseWithClaims(result, claims, service.KeyFunc(tt.inSecret))
+			assert.Nil(t, err)
+
+			family, ok := claims["family"]
+			assert.Equal(t, tt.inFamily != "", ok)
+
+			if ok {
+				assert.Equal(t, tt.inFamily, family)
+			}
 		})
 	}
 }
@@ -73,6 +99,14 @@ func TestExtractToken(t *testing.T) {
 	t.Parallel()
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"id":       idTest,
+		"username": usernameTest,
+		"email":    emailTest,
+		"role":     roleTest,
+		"uuid":     uuid.NewString(),
+	})
+
+	tokenWithoutRole := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"id":       idTest,
 		"username": usernameTest,
 		"email":    emailTest,
@@ -105,6 +139,11 @@ func TestExtractToken(t *testing.T) {
 		assert.Error(t, err)
 	}
 
+	tokenSignedWithoutRole, err := tokenWithoutRole.SignedString([]byte(secretTest))
+	if err != nil {
+		assert.Error(t, err)
+	}
+
 	tokenSignedBadID, err := tokenBadID.SignedString([]byte(secretTest))
 	if err != nil {
 		assert.Error(t, err)
@@ -124,6 +163,7 @@ func TestExtractToken(t *testing.T) {
 		name                          string
 		inToken                       string
 		outUsername, outEmail, outErr string
+		outRole                       string
 		inSecret                      []byte
 		outID                         int
 	}{
@@ -134,6 +174,17 @@ func TestExtractToken(t *testing.T) {
 			outID:       idTest,
 			outUsername: usernameTest,
 			outEmail:    emailTest,
+			outRole:     roleTest,
+			outErr:      "",
+		},
+		{
+			name:        nameNoError + "WithoutRole",
+			inToken:     tokenSignedWithoutRole,
+			inSecret:    []byte(secretTest),
+			outID:       idTest,
+			outUsername: usernameTest,
+			outEmail:    emailTest,
+			outRole:     "",
 			outErr:      "",
 		},
 		{
@@ -178,7 +229,7 @@ func TestExtractToken(t *testing.T) {
 			t.Parallel()
 
 			var resultID int
-			var resultUsername, resultEmail, resultErr string
+			var resultUsername, resultEmail, resultRole, resultErr string
 
 			mr, err := miniredis.Run()
 			if err != nil {
@@ -189,12 +240,12 @@ func TestExtractToken(t *testing.T) {
 
 			svc := service.GetService(client)
 
-			resultID, resultUsername, resultEmail, err = svc.ExtractToken(tt.inToken, tt.inSecret)
+			resultID, resultUsername, resultEmail, resultRole, err = svc.ExtractToken(context.TODO(), tt.inToken, tt.inSecret)
 			if err != nil {
 				resultErr = err.Error()
 			}
 
-			if tt.name == nameNoError {
+			if tt.outErr == "" {
 				assert.Empty(t, resultErr)
 			} else {
 				assert.Contains(t, resultErr, tt.outErr)
@@ -203,6 +254,7 @@ func TestExtractToken(t *testing.T) {
 			assert.Equal(t, tt.outID, resultID, "they should be equal")
 			assert.Equal(t, tt.outUsername, resultUsername, "they should be equal")
 			assert.Equal(t, tt.outEmail, resultEmail, "they should be equal")
+			assert.Equal(t, tt.outRole, resultRole, "they should be equal")
 		})
 	}
 }
@@ -269,7 +321,7 @@ func TestManageToken(t *testing.T) {
 				svc.DB.Close()
 			}
 
-			err = svc.ManageToken(tt.inState, tt.in)
+			err = svc.ManageToken(context.TODO(), tt.inState, tt.in)
 			if err != nil {
 				resultErr = err.Error()
 			}
@@ -337,7 +389,7 @@ func TestCheckToken(t *testing.T) {
 			svc := service.GetService(client)
 
 			if tt.in != "" {
-				err = svc.ManageToken(service.NewSetTokenState(), tt.in)
+				err = svc.ManageToken(context.TODO(), service.NewSetTokenState(), tt.in)
 				if err != nil {
 					assert.Error(t, err)
 				}
@@ -347,7 +399,7 @@ func TestCheckToken(t *testing.T) {
 				svc.DB.Close()
 			}
 
-			resultCheck, err = svc.CheckToken(tt.in)
+			resultCheck, err = svc.CheckToken(context.TODO(), tt.in)
 			if err != nil {
 				resultErr = err.Error()
 			}
@@ -363,6 +415,461 @@ func TestCheckToken(t *testing.T) {
 	}
 }
 
+func TestRevokeUserTokens(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name     string
+		outErr   string
+		inTokens int
+		inID     int
+	}{
+		{
+			name:     nameNoError,
+			inID:     idTest,
+			inTokens: 2,
+			outErr:   "",
+		},
+		{
+			name:     "NoErrorWithoutTokens",
+			inID:     idTest,
+			inTokens: 0,
+			outErr:   "",
+		},
+		{
+			name:   nameErrorRedisClose,
+			inID:   idTest,
+			outErr: errRedisClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+
+			tokens := make([]string, 0, tt.inTokens)
+			refreshTokens := make([]string, 0, tt.inTokens)
+
+			for i := 0; i < tt.inTokens; i++ {
+				refreshToken, family, err := svc.GenerateRefreshToken(context.TODO(), tt.inID, usernameTest, emailTest, roleTest)
+				if err != nil {
+					assert.Error(t, err)
+				}
+
+				refreshTokens = append(refreshTokens, refreshToken)
+
+				token := svc.GenerateToken(context.TODO(), tt.inID, usernameTest, emailTest, roleTest, family, []byte(secretTest))
+
+				err = svc.ManageToken(context.TODO(), service.NewSetTokenState(), token)
+				if err != nil {
+					assert.Error(t, err)
+				}
+
+				tokens = append(tokens, token)
+			}
+
+			if tt.name == nameErrorRedisClose {
+				svc.DB.Close()
+			}
+
+			err = svc.RevokeUserTokens(context.TODO(), tt.inID)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			if tt.name != nameErrorRedisClose {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+
+				return
+			}
+
+			for _, token := range tokens {
+				check, err := svc.CheckToken(context.TODO(), token)
+				assert.Nil(t, err)
+				assert.False(t, check)
+			}
+
+			for _, refreshToken := range refreshTokens {
+				_, _, err = svc.RefreshToken(context.TODO(), refreshToken, []byte(secretTest))
+				assert.ErrorIs(t, err, service.ErrRefreshTokenNotValid)
+			}
+		})
+	}
+}
+
+func TestGenerateOneTimeToken(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name      string
+		inPurpose string
+		outErr    string
+		inID      int
+	}{
+		{
+			name:      nameNoError,
+			inID:      idTest,
+			inPurpose: service.PurposePasswordReset,
+			outErr:    "",
+		},
+		{
+			name:      nameNoError + "EmailVerification",
+			inID:      idTest,
+			inPurpose: service.PurposeEmailVerification,
+			outErr:    "",
+		},
+		{
+			name:      "ErrorUnknownPurpose",
+			inID:      idTest,
+			inPurpose: "unknown",
+			outErr:    service.ErrUnknownPurpose.Error(),
+		},
+		{
+			name:      nameErrorRedisClose,
+			inID:      idTest,
+			inPurpose: service.PurposePasswordReset,
+			outErr:    errRedisClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+
+			if tt.name == nameErrorRedisClose {
+				svc.DB.Close()
+			}
+
+			result, err := svc.GenerateOneTimeToken(context.TODO(), tt.inID, emailTest, tt.inPurpose)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			if tt.outErr == "" {
+				assert.Empty(t, resultErr)
+				assert.NotEmpty(t, result)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+				assert.Empty(t, result)
+			}
+		})
+	}
+}
+
+func TestConsumeOneTimeToken(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name      string
+		inPurpose string
+		outErr    string
+		outEmail  string
+		outID     int
+		isReplay  bool
+	}{
+		{
+			name:      nameNoError,
+			inPurpose: service.PurposePasswordReset,
+			outID:     idTest,
+			outEmail:  emailTest,
+			outErr:    "",
+		},
+		{
+			name:      "ErrorReplay",
+			inPurpose: service.PurposePasswordReset,
+			isReplay:  true,
+			outErr:    service.ErrOneTimeTokenNotValid.Error(),
+		},
+		{
+			name:      "ErrorUnknownPurpose",
+			inPurpose: "unknown",
+			outErr:    service.ErrUnknownPurpose.Error(),
+		},
+		{
+			name:      nameErrorRedisClose,
+			inPurpose: service.PurposePasswordReset,
+			outErr:    errRedisClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+
+			token, err := svc.GenerateOneTimeToken(context.TODO(), idTest, emailTest, service.PurposePasswordReset)
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			if tt.isReplay {
+				_, _, err = svc.ConsumeOneTimeToken(context.TODO(), token, tt.inPurpose)
+				assert.Nil(t, err)
+			}
+
+			if tt.name == nameErrorRedisClose {
+				svc.DB.Close()
+			}
+
+			result, email, err := svc.ConsumeOneTimeToken(context.TODO(), token, tt.inPurpose)
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			if tt.name == nameNoError {
+				assert.Empty(t, resultErr)
+			} else {
+				assert.Contains(t, resultErr, tt.outErr)
+			}
+
+			assert.Equal(t, tt.outID, result)
+			assert.Equal(t, tt.outEmail, email)
+		})
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name    string
+		outErr  string
+		isReuse bool
+	}{
+		{
+			name:   nameNoError,
+			outErr: "",
+		},
+		{
+			name:    "ErrorReuse",
+			isReuse: true,
+			outErr:  service.ErrRefreshTokenReused.Error(),
+		},
+		{
+			name:   "ErrorNotValid",
+			outErr: service.ErrRefreshTokenNotValid.Error(),
+		},
+		{
+			name:   nameErrorRedisClose,
+			outErr: errRedisClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var resultErr string
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+
+			refreshToken, _, err := svc.GenerateRefreshToken(context.TODO(), idTest, usernameTest, emailTest, roleTest)
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			// another session of the user, the reuse must not revoke it.
+			otherRefreshToken, otherFamily, err := svc.GenerateRefreshToken(context.TODO(), idTest, usernameTest, emailTest, roleTest)
+			assert.Nil(t, err)
+
+			otherToken := svc.GenerateToken(context.TODO(), idTest, usernameTest, emailTest, roleTest, otherFamily, []byte(secretTest))
+			assert.Nil(t, svc.ManageToken(context.TODO(), service.NewSetTokenState(), otherToken))
+
+			var rotatedToken, rotatedRefreshToken string
+
+			if tt.isReuse {
+				rotatedToken, rotatedRefreshToken, err = svc.RefreshToken(context.TODO(), refreshToken, []byte(secretTest))
+				assert.Nil(t, err)
+
+				err = svc.ManageToken(context.TODO(), service.NewSetTokenState(), rotatedToken)
+				assert.Nil(t, err)
+			}
+
+			if tt.name == "ErrorNotValid" {
+				refreshToken = tokenTest
+			}
+
+			if tt.name == nameErrorRedisClose {
+				svc.DB.Close()
+			}
+
+			token, newRefreshToken, err := svc.RefreshToken(context.TODO(), refreshToken, []byte(secretTest))
+			if err != nil {
+				resultErr = err.Error()
+			}
+
+			if tt.outErr != "" {
+				assert.Contains(t, resultErr, tt.outErr)
+				assert.Empty(t, token)
+				assert.Empty(t, newRefreshToken)
+			} else {
+				assert.Empty(t, resultErr)
+				assert.NotEqual(t, refreshToken, newRefreshToken)
+
+				id, username, email, role, err := svc.ExtractToken(context.TODO(), token, []byte(secretTest))
+				assert.Nil(t, err)
+				assert.Equal(t, idTest, id)
+				assert.Equal(t, usernameTest, username)
+				assert.Equal(t, emailTest, email)
+				assert.Equal(t, roleTest, role)
+			}
+
+			// the reuse revokes the tokens rotated from it as well.
+			if tt.isReuse {
+				_, _, err = svc.RefreshToken(context.TODO(), rotatedRefreshToken, []byte(secretTest))
+				assert.ErrorIs(t, err, service.ErrRefreshTokenNotValid)
+
+				check, err := svc.CheckToken(context.TODO(), rotatedToken)
+				assert.Nil(t, err)
+				assert.False(t, check)
+
+				check, err = svc.CheckToken(context.TODO(), otherToken)
+				assert.Nil(t, err)
+				assert.True(t, check)
+
+				_, _, err = svc.RefreshToken(context.TODO(), otherRefreshToken, []byte(secretTest))
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name     string
+		inFamily bool
+		inToken  string
+		outErr   string
+	}{
+		{
+			name:     nameNoError,
+			inFamily: true,
+			outErr:   "",
+		},
+		{
+			name:   "NoErrorWithoutFamily",
+			outErr: "",
+		},
+		{
+			name:    "ErrorToken",
+			inToken: tokenTest,
+			outErr:  "error to extract token",
+		},
+		{
+			name:     nameErrorRedisClose,
+			inFamily: true,
+			outErr:   errRedisClosed,
+		},
+	} {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mr, err := miniredis.Run()
+			if err != nil {
+				assert.Error(t, err)
+			}
+
+			client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+			svc := service.GetService(client)
+
+			// two sessions of the user, only the first one is revoked.
+			refreshTokens := make([]string, 2)
+			tokens := make([]string, 2)
+
+			for i := range tokens {
+				var family string
+
+				refreshTokens[i], family, err = svc.GenerateRefreshToken(context.TODO(), idTest, usernameTest, emailTest, roleTest)
+				assert.Nil(t, err)
+
+				if !tt.inFamily {
+					family = ""
+				}
+
+				tokens[i] = svc.GenerateToken(context.TODO(), idTest, usernameTest, emailTest, roleTest, family, []byte(secretTest))
+				assert.Nil(t, svc.ManageToken(context.TODO(), service.NewSetTokenState(), tokens[i]))
+			}
+
+			token := tokens[0]
+			if tt.inToken != "" {
+				token = tt.inToken
+			}
+
+			if tt.name == nameErrorRedisClose {
+				svc.DB.Close()
+			}
+
+			err = svc.RevokeSession(context.TODO(), token, []byte(secretTest))
+			if tt.outErr != "" {
+				assert.ErrorContains(t, err, tt.outErr)
+
+				return
+			}
+
+			assert.Nil(t, err)
+
+			check, err := svc.CheckToken(context.TODO(), tokens[0])
+			assert.Nil(t, err)
+			assert.False(t, check)
+
+			check, err = svc.CheckToken(context.TODO(), tokens[1])
+			assert.Nil(t, err)
+			assert.True(t, check)
+
+			_, _, err = svc.RefreshToken(context.TODO(), refreshTokens[0], []byte(secretTest))
+			if tt.inFamily {
+				assert.ErrorIs(t, err, service.ErrRefreshTokenNotValid)
+			} else {
+				assert.Nil(t, err)
+			}
+
+			_, _, err = svc.RefreshToken(context.TODO(), refreshTokens[1], []byte(secretTest))
+			assert.Nil(t, err)
+		})
+	}
+}
+
 func TestKeyFunc(t *testing.T) {
 	t.Parallel()
 
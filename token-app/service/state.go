@@ -1,14 +1,15 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"time"
 
-	"github.com/go-redis/redis"
+	"github.com/go-redis/redis/v8"
 )
 
 type State interface {
-	ManageToken(*redis.Client, string) error
+	ManageToken(context.Context, *redis.Client, string) error
 }
 
 type (
@@ -20,12 +21,30 @@ func NewSetTokenState() SetTokenState {
 	return SetTokenState{}
 }
 
-func (SetTokenState) ManageToken(db *redis.Client, token string) (err error) {
-	err = db.Set(token, true, time.Minute*time.Duration(lifeOfToken)).Err()
+func (SetTokenState) ManageToken(ctx context.Context, db *redis.Client, token string) (err error) {
+	err = db.Set(ctx, token, true, time.Minute*time.Duration(lifeOfToken)).Err()
 	if err != nil {
 		return fmt.Errorf("error to set token: %w", err)
 	}
 
+	id, family, ok := tokenOwner(token)
+	if !ok {
+		return nil
+	}
+
+	pipe := db.TxPipeline()
+	pipe.SAdd(ctx, userTokensKey(id), token)
+	pipe.Expire(ctx, userTokensKey(id), time.Minute*time.Duration(lifeOfToken))
+
+	if family != "" {
+		pipe.SAdd(ctx, familyTokensKey(family), token)
+		pipe.Expire(ctx, familyTokensKey(family), time.Minute*time.Duration(lifeOfToken))
+	}
+
+	if _, err = pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("error to index token: %w", err)
+	}
+
 	return nil
 }
 
@@ -33,10 +52,26 @@ func NewDeleteTokenState() DeleteTokenState {
 	return DeleteTokenState{}
 }
 
-func (DeleteTokenState) ManageToken(db *redis.Client, token string) (err error) {
-	if err := db.Del(token).Err(); err != nil {
+func (DeleteTokenState) ManageToken(ctx context.Context, db *redis.Client, token string) (err error) {
+	if err := db.Del(ctx, token).Err(); err != nil {
 		return fmt.Errorf("failed to delete token: %w", err)
 	}
 
+	id, family, ok := tokenOwner(token)
+	if !ok {
+		return nil
+	}
+
+	pipe := db.TxPipeline()
+	pipe.SRem(ctx, userTokensKey(id), token)
+
+	if family != "" {
+		pipe.SRem(ctx, familyTokensKey(family), token)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to unindex token: %w", err)
+	}
+
 	return nil
 }
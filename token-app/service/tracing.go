@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/cfabrica46/gokit-crud/token-app/service"
+
+// RedisTracingHook is a redis.Hook that makes a child span of every command
+// and pipeline.
+type RedisTracingHook struct{}
+
+// BeforeProcess ...
+func (RedisTracingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	ctx, _ = startRedisSpan(ctx, cmd.Name())
+
+	return ctx, nil
+}
+
+// AfterProcess ...
+func (RedisTracingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	endRedisSpan(ctx, cmd.Err())
+
+	return nil
+}
+
+// BeforeProcessPipeline ...
+func (RedisTracingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	ctx, span := startRedisSpan(ctx, "pipeline")
+
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.Name()
+	}
+
+	span.SetAttributes(semconv.DBStatementKey.StringSlice(names))
+
+	return ctx, nil
+}
+
+// AfterProcessPipeline ...
+func (RedisTracingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+
+	for _, cmd := range cmds {
+		if err = cmd.Err(); err != nil && !errors.Is(err, redis.Nil) {
+			break
+		}
+	}
+
+	endRedisSpan(ctx, err)
+
+	return nil
+}
+
+func startRedisSpan(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(
+		ctx,
+		operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemRedis,
+			semconv.DBOperationKey.String(operation),
+		),
+	)
+}
+
+// endRedisSpan ends the span of ctx, a missing key is not an error.
+func endRedisSpan(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,89 @@
+package service_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis"
+	"github.com/cfabrica46/gokit-crud/token-app/service"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	recorderOnce sync.Once
+	recorder     *tracetest.SpanRecorder
+)
+
+// spanRecorder installs, once for every test, a global tracer provider that
+// records the spans.
+func spanRecorder() *tracetest.SpanRecorder {
+	recorderOnce.Do(func() {
+		recorder = tracetest.NewSpanRecorder()
+
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+	})
+
+	return recorder
+}
+
+// endedSpans are the ended spans of the trace of traceID.
+func endedSpans(traceID trace.TraceID) map[string]sdktrace.ReadOnlySpan {
+	spans := map[string]sdktrace.ReadOnlySpan{}
+
+	for _, span := range spanRecorder().Ended() {
+		if span.SpanContext().TraceID() == traceID {
+			spans[span.Name()] = span
+		}
+	}
+
+	return spans
+}
+
+func TestRedisTracingHook(t *testing.T) {
+	t.Parallel()
+
+	spanRecorder()
+
+	mr, err := miniredis.Run()
+	assert.NoError(t, err)
+
+	defer mr.Close()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	client.AddHook(service.RedisTracingHook{})
+
+	svc := service.GetService(client)
+
+	ctx, parent := otel.Tracer("test").Start(context.TODO(), "parent")
+
+	_, _, err = svc.GenerateRefreshToken(ctx, idTest, usernameTest, emailTest, roleTest)
+	assert.NoError(t, err)
+
+	_, err = svc.CheckToken(ctx, "missing")
+	assert.NoError(t, err)
+
+	parent.End()
+
+	spans := endedSpans(parent.SpanContext().TraceID())
+
+	for _, name := range []string{"pipeline", "get"} {
+		span, ok := spans[name]
+		if !assert.True(t, ok, name) {
+			continue
+		}
+
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID(), name)
+		assert.Contains(t, span.Attributes(), semconv.DBSystemRedis, name)
+		assert.Equal(t, codes.Unset, span.Status().Code, name)
+	}
+}
@@ -3,9 +3,12 @@ package service_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/cfabrica46/gokit-crud/token-app/service"
 	"github.com/stretchr/testify/assert"
@@ -30,6 +33,27 @@ const (
 	tokenRequestJSON = `{
 		"token": "token"
 	}`
+
+	idRequestJSON = `{
+		"id": 1
+	}`
+
+	idPurposeRequestJSON = `{
+		"id": 1,
+		"purpose": "password_reset"
+	}`
+
+	//nolint:gosec
+	tokenPurposeRequestJSON = `{
+		"token": "token",
+		"purpose": "password_reset"
+	}`
+
+	//nolint:gosec
+	refreshTokenRequestJSON = `{
+		"refreshToken": "token",
+		"secret": "secret"
+	}`
 )
 
 func TestDecodeRequest(t *testing.T) {
@@ -62,6 +86,42 @@ func TestDecodeRequest(t *testing.T) {
 		assert.Error(t, err)
 	}
 
+	idReq, err := http.NewRequest(
+		http.MethodDelete,
+		urlTest,
+		bytes.NewBuffer([]byte(idRequestJSON)),
+	)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	idPurposeReq, err := http.NewRequest(
+		http.MethodPost,
+		urlTest,
+		bytes.NewBuffer([]byte(idPurposeRequestJSON)),
+	)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	tokenPurposeReq, err := http.NewRequest(
+		http.MethodPost,
+		urlTest,
+		bytes.NewBuffer([]byte(tokenPurposeRequestJSON)),
+	)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	refreshTokenReq, err := http.NewRequest(
+		http.MethodPost,
+		urlTest,
+		bytes.NewBuffer([]byte(refreshTokenRequestJSON)),
+	)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
 	badReq, err := http.NewRequest(http.MethodPost, urlTest, bytes.NewBuffer([]byte{}))
 	if err != nil {
 		assert.Error(t, err)
@@ -75,6 +135,7 @@ func TestDecodeRequest(t *testing.T) {
 		outEmail    string
 		outToken    string
 		outSecret   string
+		outPurpose  string
 		outErr      string
 		outID       int
 	}{
@@ -103,6 +164,37 @@ func TestDecodeRequest(t *testing.T) {
 			outToken: tokenTest,
 			outErr:   "",
 		},
+		{
+			name:   nameNoError + "ID",
+			inType: service.IDRequest{},
+			in:     idReq,
+			outID:  idTest,
+			outErr: "",
+		},
+		{
+			name:       nameNoError + "IDPurpose",
+			inType:     service.IDEmailPurposeRequest{},
+			in:         idPurposeReq,
+			outID:      idTest,
+			outPurpose: service.PurposePasswordReset,
+			outErr:     "",
+		},
+		{
+			name:       nameNoError + "TokenPurpose",
+			inType:     service.TokenPurposeRequest{},
+			in:         tokenPurposeReq,
+			outToken:   tokenTest,
+			outPurpose: service.PurposePasswordReset,
+			outErr:     "",
+		},
+		{
+			name:      nameNoError + "RefreshToken",
+			inType:    service.RefreshTokenSecretRequest{},
+			in:        refreshTokenReq,
+			outToken:  tokenTest,
+			outSecret: secretTest,
+			outErr:    "",
+		},
 		{
 			name:   "BadRequest",
 			inType: service.IDUsernameEmailSecretRequest{},
@@ -152,11 +244,66 @@ func TestDecodeRequest(t *testing.T) {
 			case service.Token:
 				req, err = service.DecodeRequest(resultType)(context.TODO(), tt.in)
 
+				if err != nil {
+					resultErr = err.Error()
+				}
+
 				result, ok := req.(service.Token)
 				assert.True(t, ok)
 
 				assert.Equal(t, tt.outToken, result.Token)
-				assert.ErrorContains(t, err, tt.outErr)
+				assert.Contains(t, resultErr, tt.outErr)
+
+			case service.IDRequest:
+				req, err = service.DecodeRequest(resultType)(context.TODO(), tt.in)
+				if err != nil {
+					resultErr = err.Error()
+				}
+
+				result, ok := req.(service.IDRequest)
+				assert.True(t, ok)
+
+				assert.Equal(t, tt.outID, result.ID)
+				assert.Contains(t, resultErr, tt.outErr)
+
+			case service.IDEmailPurposeRequest:
+				req, err = service.DecodeRequest(resultType)(context.TODO(), tt.in)
+				if err != nil {
+					resultErr = err.Error()
+				}
+
+				result, ok := req.(service.IDEmailPurposeRequest)
+				assert.True(t, ok)
+
+				assert.Equal(t, tt.outID, result.ID)
+				assert.Equal(t, tt.outPurpose, result.Purpose)
+				assert.Contains(t, resultErr, tt.outErr)
+
+			case service.TokenPurposeRequest:
+				req, err = service.DecodeRequest(resultType)(context.TODO(), tt.in)
+				if err != nil {
+					resultErr = err.Error()
+				}
+
+				result, ok := req.(service.TokenPurposeRequest)
+				assert.True(t, ok)
+
+				assert.Equal(t, tt.outToken, result.Token)
+				assert.Equal(t, tt.outPurpose, result.Purpose)
+				assert.Contains(t, resultErr, tt.outErr)
+
+			case service.RefreshTokenSecretRequest:
+				req, err = service.DecodeRequest(resultType)(context.TODO(), tt.in)
+				if err != nil {
+					resultErr = err.Error()
+				}
+
+				result, ok := req.(service.RefreshTokenSecretRequest)
+				assert.True(t, ok)
+
+				assert.Equal(t, tt.outToken, result.RefreshToken)
+				assert.Equal(t, tt.outSecret, result.Secret)
+				assert.Contains(t, resultErr, tt.outErr)
 			}
 		})
 	}
@@ -205,3 +352,64 @@ func TestEncodeResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestEncodeError(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+
+	service.EncodeError(context.TODO(), service.ErrRefreshTokenReused, w)
+
+	var problem service.Problem
+
+	err := json.NewDecoder(w.Body).Decode(&problem)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	assert.Equal(t, service.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusUnauthorized),
+		Detail: service.ErrRefreshTokenReused.Error(),
+		Status: http.StatusUnauthorized,
+	}, problem)
+}
+
+func TestEncodeErrorInternal(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+
+	service.EncodeError(context.TODO(), errors.New("dial tcp 10.0.0.3:6379: connection refused"), w)
+
+	var problem service.Problem
+
+	err := json.NewDecoder(w.Body).Decode(&problem)
+	if err != nil {
+		assert.Error(t, err)
+	}
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, service.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+	}, problem)
+}
+
+func TestEncodeErrorRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	w := httptest.NewRecorder()
+
+	service.EncodeError(context.TODO(), &service.Error{
+		Kind:       service.ErrTooManyRequests,
+		Err:        service.ErrSignInLimited,
+		RetryAfter: 1500 * time.Millisecond,
+	}, w)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "2", w.Header().Get("Retry-After"))
+}
@@ -1,7 +1,7 @@
 #!/bin/bash
 
 # GenerateToken
-curl -XPOST -d'{"id":1,"username":"cesar","email":"cesar@email.com","secret":"secret"}' localhost:9090/generate
+curl -XPOST -d'{"id":1,"username":"cesar","email":"cesar@email.com","role":"admin","secret":"secret"}' localhost:9090/generate
 
 # ExtractToken
 # curl -XPOST -d'{"token":"token","secret":"secret"}' localhost:9090/extract
@@ -14,3 +14,15 @@ curl -XPOST -d'{"id":1,"username":"cesar","email":"cesar@email.com","secret":"se
 
 # CheckToken
 # curl -XPOST -d'{"token":"token"}' localhost:9090/check
+
+# RevokeUserTokens
+# curl -XDELETE -d'{"id":1}' localhost:9090/tokens
+
+# GenerateOneTimeToken
+# curl -XPOST -d'{"id":1,"purpose":"password_reset"}' localhost:9090/onetime
+
+# ConsumeOneTimeToken
+# curl -XPOST -d'{"token":"token","purpose":"password_reset"}' localhost:9090/onetime/consume
+
+# RefreshToken
+# curl -XPOST -d'{"refreshToken":"refresh-token","secret":"secret"}' localhost:9090/refresh